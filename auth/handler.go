@@ -8,19 +8,27 @@ import (
 	"rest-api-example/entities"
 	"rest-api-example/utils"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 var (
 	ErrInvalidJsonFormat = errors.New("invalid json format")
 )
 
+// LoginHook runs after a successful login, before the tokens are returned.
+// Its errors are logged and never fail the login.
+type LoginHook func(ctx context.Context, login string, r *http.Request) error
+
 type AuthHandler struct {
 	authService AuthService
+	loginHooks  []LoginHook
 }
 
-func NewAuthHandler(authService AuthService) AuthHandler {
+func NewAuthHandler(authService AuthService, loginHooks ...LoginHook) AuthHandler {
 	return AuthHandler{
 		authService: authService,
+		loginHooks:  loginHooks,
 	}
 }
 
@@ -41,38 +49,46 @@ func (h AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var credentials entities.Credentials
 	err := json.NewDecoder(r.Body).Decode(&credentials)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, ErrInvalidJsonFormat.Error(), op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrInvalidJsonFormat.Error(), op))
 		return
 	}
 
 	tokenPair, err := h.authService.Login(ctx, credentials)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
+	for _, hook := range h.loginHooks {
+		err = hook(ctx, credentials.Login, r)
+		if err != nil {
+			log.WithError(err).WithField("login", credentials.Login).Warn("login hook failed")
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(w).Encode(AuthenticationResponse{AccessToken(tokenPair.AccessToken), RefreshToken(tokenPair.RefreshToken)})
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 }
 
 func (h AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	op := "AuthHandler.RefreshToken()"
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
 
 	var refreshTokenRequest RefreshTokenRequest
 	err := json.NewDecoder(r.Body).Decode(&refreshTokenRequest)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, ErrInvalidJsonFormat.Error(), op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrInvalidJsonFormat.Error(), op))
 		return
 	}
 
-	tokenPair, err := h.authService.RefreshToken(refreshTokenRequest.RefreshToken)
+	tokenPair, err := h.authService.RefreshToken(ctx, refreshTokenRequest.RefreshToken)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 
@@ -80,7 +96,59 @@ func (h AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(w).Encode(AuthenticationResponse{AccessToken(tokenPair.AccessToken), RefreshToken(tokenPair.RefreshToken)})
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
+		return
+	}
+}
+
+func (h AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	op := "AuthHandler.Logout()"
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var refreshTokenRequest RefreshTokenRequest
+	err := json.NewDecoder(r.Body).Decode(&refreshTokenRequest)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrInvalidJsonFormat.Error(), op))
+		return
+	}
+
+	err = h.authService.Logout(ctx, refreshTokenRequest.RefreshToken)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	op := "AuthHandler.LogoutAll()"
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		utils.JSONError(w, r, entities.NewUnauthorizedError(ErrInvalidToken, ErrInvalidToken.Error(), op))
+		return
+	}
+
+	err := h.authService.LogoutAll(ctx, principal.Login)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(h.authService.keys.JWKS())
+	if err != nil {
+		utils.JSONError(w, r, err)
 		return
 	}
 }
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"rest-api-example/config"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey       = errors.New("no signing key configured")
+	ErrUnknownKeyId       = errors.New("unknown key id")
+	ErrRetiredKey         = errors.New("signing key retired")
+	ErrUnsupportedKeyType = errors.New("unsupported private key type, use RSA or ECDSA P-256")
+)
+
+type SigningKey struct {
+	Kid        string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	ActiveFrom time.Time
+	RetireAt   time.Time
+}
+
+// KeySet signs tokens with the active asymmetric key and verifies them with
+// any key that was not retired yet. Without asymmetric keys it falls back to
+// HS256 with the shared secret.
+type KeySet struct {
+	keys   []SigningKey
+	secret []byte
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+func NewKeySet(cfg config.JwtConfig, secret string) (KeySet, error) {
+	keys := make([]SigningKey, 0, len(cfg.Keys))
+	for _, keyConfig := range cfg.Keys {
+		key, err := loadSigningKey(keyConfig)
+		if err != nil {
+			return KeySet{}, fmt.Errorf("jwt key %q: %w", keyConfig.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ActiveFrom.Before(keys[j].ActiveFrom)
+	})
+	// a replaced key stays valid long enough for every token it signed to expire
+	for i := 0; i < len(keys)-1; i++ {
+		if keys[i].RetireAt.IsZero() {
+			keys[i].RetireAt = keys[i+1].ActiveFrom.Add(refreshTokenDuration)
+		}
+	}
+
+	if len(keys) == 0 && secret == "" {
+		return KeySet{}, ErrNoSigningKey
+	}
+	return KeySet{keys: keys, secret: []byte(secret)}, nil
+}
+
+func loadSigningKey(cfg config.JwtKeyConfig) (SigningKey, error) {
+	if cfg.Kid == "" {
+		return SigningKey{}, errors.New("kid is required")
+	}
+	content, err := os.ReadFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return SigningKey{}, errors.New("private key file is not PEM encoded")
+	}
+
+	var privateKey any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	key := SigningKey{
+		Kid:        cfg.Kid,
+		ActiveFrom: cfg.ActiveFrom,
+		RetireAt:   cfg.RetireAt,
+	}
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.PrivateKey = k
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return SigningKey{}, ErrUnsupportedKeyType
+		}
+		key.Method = jwt.SigningMethodES256
+		key.PrivateKey = k
+	default:
+		return SigningKey{}, ErrUnsupportedKeyType
+	}
+	return key, nil
+}
+
+// activeKey is the most recently activated key at the given time.
+func (k KeySet) activeKey(now time.Time) (SigningKey, bool) {
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		if !k.keys[i].ActiveFrom.After(now) {
+			return k.keys[i], true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func (k KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	if len(k.keys) == 0 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.secret)
+	}
+	key, ok := k.activeKey(time.Now())
+	if !ok {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.PrivateKey)
+}
+
+// ValidMethods lists the algorithms of the tokens the set verifies: HS256
+// only while no asymmetric key is configured, so the shared secret can no
+// longer sign tokens once the keys are in place.
+func (k KeySet) ValidMethods() []string {
+	if len(k.keys) == 0 {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+	return []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}
+}
+
+// Keyfunc resolves the verification key from the token kid header.
+func (k KeySet) Keyfunc(t *jwt.Token) (any, error) {
+	kid, hasKid := t.Header["kid"].(string)
+	if !hasKid {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok && len(k.keys) == 0 {
+			return k.secret, nil
+		}
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+
+	for _, key := range k.keys {
+		if key.Kid != kid {
+			continue
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		if !key.RetireAt.IsZero() && time.Now().After(key.RetireAt) {
+			return nil, ErrRetiredKey
+		}
+		return key.PrivateKey.Public(), nil
+	}
+	return nil, ErrUnknownKeyId
+}
+
+// JWKS publishes the public part of every key not retired yet, including the
+// ones scheduled for the future so verifiers learn them before they are used.
+func (k KeySet) JWKS() JSONWebKeySet {
+	now := time.Now()
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range k.keys {
+		if !key.RetireAt.IsZero() && now.After(key.RetireAt) {
+			continue
+		}
+		jwk := JSONWebKey{Kid: key.Kid, Use: "sig", Alg: key.Method.Alg()}
+		switch publicKey := key.PrivateKey.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case *ecdsa.PublicKey:
+			ecdhKey, err := publicKey.ECDH()
+			if err != nil {
+				continue
+			}
+			// uncompressed point: 0x04 || X || Y
+			point := ecdhKey.Bytes()
+			size := (len(point) - 1) / 2
+			jwk.Kty = "EC"
+			jwk.Crv = "P-256"
+			jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+			jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"rest-api-example/config"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeECKey writes a new P-256 key to a PEM file and returns its path.
+func writeECKey(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: content}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func hs256Token(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestValidateTokenSecretFallback(t *testing.T) {
+	claims := jwt.MapClaims{"sub": "admin", "role": "admin", "exp": time.Now().Add(time.Minute).Unix()}
+	keyed := config.JwtConfig{Keys: []config.JwtKeyConfig{
+		{Kid: "k1", PrivateKeyFile: writeECKey(t), ActiveFrom: time.Now().Add(-time.Hour)},
+	}}
+
+	tests := []struct {
+		name  string
+		cfg   config.JwtConfig
+		valid bool
+	}{
+		{name: "without keys the secret signs", cfg: config.JwtConfig{}, valid: true},
+		{name: "with a key the secret is rejected", cfg: keyed, valid: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keys, err := NewKeySet(test.cfg, "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			service := AuthService{keys: keys}
+			_, err = service.validateToken(hs256Token(t, "secret", claims))
+			if (err == nil) != test.valid {
+				t.Errorf("validateToken() error = %v, want valid %v", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestKeySetSignAndVerify(t *testing.T) {
+	now := time.Now()
+	keys, err := NewKeySet(config.JwtConfig{Keys: []config.JwtKeyConfig{
+		{Kid: "old", PrivateKeyFile: writeECKey(t), ActiveFrom: now.Add(-48 * time.Hour)},
+		{Kid: "new", PrivateKeyFile: writeECKey(t), ActiveFrom: now.Add(-time.Hour)},
+		{Kid: "next", PrivateKeyFile: writeECKey(t), ActiveFrom: now.Add(time.Hour)},
+	}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := AuthService{keys: keys}
+
+	signed, err := keys.Sign(jwt.MapClaims{"sub": "a", "exp": now.Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := service.validateToken(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := token.Header["kid"]; kid != "new" {
+		t.Errorf("signed with kid %v, want the active key new", kid)
+	}
+
+	var published []string
+	for _, key := range keys.JWKS().Keys {
+		published = append(published, key.Kid)
+		if key.Kty != "EC" || key.Crv != "P-256" || key.X == "" || key.Y == "" {
+			t.Errorf("JWKS key %s = %+v", key.Kid, key)
+		}
+	}
+	// the old key stays valid for the refresh token lifetime after the rotation
+	if len(published) != 3 {
+		t.Errorf("JWKS kids = %v, want old, new and next", published)
+	}
+}
+
+func TestKeySetRejectsRetiredKey(t *testing.T) {
+	now := time.Now()
+	path := writeECKey(t)
+	keys, err := NewKeySet(config.JwtConfig{Keys: []config.JwtKeyConfig{
+		{Kid: "old", PrivateKeyFile: path, ActiveFrom: now.Add(-48 * time.Hour), RetireAt: now.Add(-time.Minute)},
+		{Kid: "new", PrivateKeyFile: writeECKey(t), ActiveFrom: now.Add(-time.Hour)},
+	}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired, err := NewKeySet(config.JwtConfig{Keys: []config.JwtKeyConfig{
+		{Kid: "old", PrivateKeyFile: path, ActiveFrom: now.Add(-48 * time.Hour)},
+	}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := retired.Sign(jwt.MapClaims{"sub": "a", "exp": now.Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = AuthService{keys: keys}.validateToken(signed)
+	if err == nil {
+		t.Error("a token of a retired key was accepted")
+	}
+	for _, key := range keys.JWKS().Keys {
+		if key.Kid == "old" {
+			t.Error("the retired key is still published")
+		}
+	}
+}
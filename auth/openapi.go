@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"net/http"
+	"rest-api-example/entities"
+	"rest-api-example/openapi"
+	"rest-api-example/user"
+)
+
+// Operations documents the routes of SetupAuthRoutes.
+func Operations() []openapi.Operation {
+	const tag = "Autenticação"
+	return []openapi.Operation{
+		{
+			Method: http.MethodPost, Path: "/auth/login", Tag: tag, Summary: "Autentica o usuário e emite os tokens",
+			Request:  entities.Credentials{},
+			Response: AuthenticationResponse{},
+			Raw:      true,
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/refresh", Tag: tag, Summary: "Troca o refresh token por um novo par de tokens",
+			Request:  RefreshTokenRequest{},
+			Response: AuthenticationResponse{},
+			Raw:      true,
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/logout", Tag: tag, Summary: "Revoga o refresh token",
+			Request: RefreshTokenRequest{},
+			Status:  http.StatusNoContent,
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/logout-all", Tag: tag, Summary: "Revoga todos os refresh tokens do usuário",
+			Authenticated: true,
+			Status:        http.StatusNoContent,
+		},
+		{
+			Method: http.MethodGet, Path: "/.well-known/jwks.json", Tag: tag, Summary: "Publica as chaves públicas que assinam os tokens",
+			Response: JSONWebKeySet{},
+			Raw:      true,
+		},
+		{
+			Method: http.MethodPut, Path: "/admin/users/{login}/role", Tag: "Usuários", Summary: "Altera o papel do usuário",
+			Permission: entities.PermissionUserManage,
+			Request:    user.UpdateRoleRequest{},
+			Status:     http.StatusNoContent,
+		},
+	}
+}
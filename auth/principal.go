@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"context"
+	"rest-api-example/entities"
+)
+
+type contextKey string
+
+const principalContextKey contextKey = "principal"
+
+// Principal is the authenticated user taken from the access token.
+type Principal struct {
+	Login string
+	Role  entities.Role
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepositoryPostgres(db *sql.DB) entities.RefreshTokenInterface {
+	return RefreshTokenRepositoryPostgres{
+		db: db,
+	}
+}
+
+func (r RefreshTokenRepositoryPostgres) CreateRefreshToken(ctx context.Context, token entities.RefreshTokenRecord) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Insert("refresh_tokens").
+		Columns("id", "family_id", "login", "expires_at", "created_at").
+		Values(token.Id, token.FamilyId, token.Login, token.ExpiresAt, token.CreatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r RefreshTokenRepositoryPostgres) GetRefreshTokenById(ctx context.Context, id uuid.UUID) (entities.RefreshTokenRecord, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Select("id", "family_id", "login", "expires_at", "created_at", "used_at", "revoked_at").
+		From("refresh_tokens").
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return entities.RefreshTokenRecord{}, err
+	}
+
+	var token entities.RefreshTokenRecord
+	var usedAt, revokedAt sql.NullTime
+	err = r.db.QueryRowContext(ctx, query, args...).
+		Scan(&token.Id, &token.FamilyId, &token.Login, &token.ExpiresAt, &token.CreatedAt, &usedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.RefreshTokenRecord{}, nil
+	}
+	if err != nil {
+		return entities.RefreshTokenRecord{}, err
+	}
+	token.UsedAt = nullTimePointer(usedAt)
+	token.RevokedAt = nullTimePointer(revokedAt)
+	return token, nil
+}
+
+func (r RefreshTokenRepositoryPostgres) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) (bool, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Update("refresh_tokens").
+		Set("used_at", usedAt).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r RefreshTokenRepositoryPostgres) RevokeRefreshTokenFamily(ctx context.Context, familyId uuid.UUID, revokedAt time.Time) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Update("refresh_tokens").
+		Set("revoked_at", revokedAt).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r RefreshTokenRepositoryPostgres) RevokeUserRefreshTokens(ctx context.Context, login string, revokedAt time.Time) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Update("refresh_tokens").
+		Set("revoked_at", revokedAt).
+		Where("login = ? AND revoked_at IS NULL", login).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func nullTimePointer(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
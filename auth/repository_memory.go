@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRepositoryMemory struct {
+	store *memory.Store
+}
+
+func NewRefreshTokenRepositoryMemory(store *memory.Store) entities.RefreshTokenInterface {
+	return RefreshTokenRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r RefreshTokenRepositoryMemory) CreateRefreshToken(ctx context.Context, token entities.RefreshTokenRecord) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+	r.store.RefreshTokens[token.Id] = token
+	return nil
+}
+
+func (r RefreshTokenRepositoryMemory) GetRefreshTokenById(ctx context.Context, id uuid.UUID) (entities.RefreshTokenRecord, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	return r.store.RefreshTokens[id], nil
+}
+
+func (r RefreshTokenRepositoryMemory) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) (bool, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	token, exists := r.store.RefreshTokens[id]
+	if !exists || token.UsedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &usedAt
+	r.store.RefreshTokens[id] = token
+	return true, nil
+}
+
+func (r RefreshTokenRepositoryMemory) RevokeRefreshTokenFamily(ctx context.Context, familyId uuid.UUID, revokedAt time.Time) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for id, token := range r.store.RefreshTokens {
+		if token.FamilyId == familyId && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+			r.store.RefreshTokens[id] = token
+		}
+	}
+	return nil
+}
+
+func (r RefreshTokenRepositoryMemory) RevokeUserRefreshTokens(ctx context.Context, login string, revokedAt time.Time) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for id, token := range r.store.RefreshTokens {
+		if token.Login == login && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+			r.store.RefreshTokens[id] = token
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRepositorySqlServer struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepositorySqlServer(db *sql.DB) entities.RefreshTokenInterface {
+	return RefreshTokenRepositorySqlServer{
+		db: db,
+	}
+}
+
+func (r RefreshTokenRepositorySqlServer) CreateRefreshToken(ctx context.Context, token entities.RefreshTokenRecord) error {
+	query, args, err := sq.Insert("refresh_tokens").
+		Columns("id", "family_id", "login", "expires_at", "created_at").
+		Values(token.Id.String(), token.FamilyId.String(), token.Login, token.ExpiresAt, token.CreatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r RefreshTokenRepositorySqlServer) GetRefreshTokenById(ctx context.Context, id uuid.UUID) (entities.RefreshTokenRecord, error) {
+	query, args, err := sq.Select("id", "family_id", "login", "expires_at", "created_at", "used_at", "revoked_at").
+		From("refresh_tokens").
+		Where("id = ?", id.String()).
+		ToSql()
+	if err != nil {
+		return entities.RefreshTokenRecord{}, err
+	}
+
+	var token entities.RefreshTokenRecord
+	var tokenId, familyId mssql.UniqueIdentifier
+	var usedAt, revokedAt sql.NullTime
+	err = r.db.QueryRowContext(ctx, query, args...).
+		Scan(&tokenId, &familyId, &token.Login, &token.ExpiresAt, &token.CreatedAt, &usedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.RefreshTokenRecord{}, nil
+	}
+	if err != nil {
+		return entities.RefreshTokenRecord{}, err
+	}
+	token.Id = uuid.UUID(tokenId)
+	token.FamilyId = uuid.UUID(familyId)
+	token.UsedAt = nullTimePointer(usedAt)
+	token.RevokedAt = nullTimePointer(revokedAt)
+	return token, nil
+}
+
+func (r RefreshTokenRepositorySqlServer) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) (bool, error) {
+	query, args, err := sq.Update("refresh_tokens").
+		Set("used_at", usedAt).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id.String()).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r RefreshTokenRepositorySqlServer) RevokeRefreshTokenFamily(ctx context.Context, familyId uuid.UUID, revokedAt time.Time) error {
+	query, args, err := sq.Update("refresh_tokens").
+		Set("revoked_at", revokedAt).
+		Where("family_id = ? AND revoked_at IS NULL", familyId.String()).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r RefreshTokenRepositorySqlServer) RevokeUserRefreshTokens(ctx context.Context, login string, revokedAt time.Time) error {
+	query, args, err := sq.Update("refresh_tokens").
+		Set("revoked_at", revokedAt).
+		Where("login = ? AND revoked_at IS NULL", login).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"net/http"
+	"rest-api-example/entities"
 	"rest-api-example/middlewares"
 	"rest-api-example/user"
 
@@ -14,4 +15,16 @@ func SetupAuthRoutes(mux *mux.Router, authHandler AuthHandler, userHandler user.
 		middlewares.ValidadeAcceptHeader([]string{"application/json"}, authHandler.Login)).Methods(http.MethodPost)
 	authRoutes.Path("/refresh").HandlerFunc(
 		middlewares.ValidadeAcceptHeader([]string{"application/json"}, authHandler.RefreshToken)).Methods(http.MethodPost)
+	authRoutes.Path("/logout").HandlerFunc(
+		middlewares.ValidateSupportedMediaTypes([]string{"application/json"}, authHandler.Logout)).Methods(http.MethodPost)
+	authRoutes.Path("/logout-all").Handler(
+		authHandler.authService.AuthenticationMiddleware(http.HandlerFunc(authHandler.LogoutAll))).Methods(http.MethodPost)
+
+	mux.Path("/.well-known/jwks.json").HandlerFunc(authHandler.JWKS).Methods(http.MethodGet)
+
+	adminUsers := mux.PathPrefix("/admin/users").Subrouter()
+	adminUsers.Use(authHandler.authService.AuthenticationMiddleware)
+	adminUsers.Path("/{login}/role").HandlerFunc(
+		authHandler.authService.RequirePermission(entities.PermissionUserManage,
+			middlewares.ValidateSupportedMediaTypes([]string{"application/json"}, userHandler.UpdateUserRole))).Methods(http.MethodPut)
 }
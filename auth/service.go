@@ -8,9 +8,11 @@ import (
 	"net/http"
 	"rest-api-example/entities"
 	"rest-api-example/utils"
+	"rest-api-example/validation"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -19,6 +21,14 @@ var (
 	ErrInvalidToken         = errors.New("invalid token")
 	ErrExpectedAccessToken  = errors.New("expected access token")
 	ErrExpectedRefreshToken = errors.New("expected refresh token")
+	ErrPermissionDenied     = errors.New("permission denied")
+	ErrRevokedToken         = errors.New("refresh token revoked")
+	ErrRefreshTokenReused   = errors.New("refresh token already used, session revoked")
+)
+
+const (
+	accessTokenDuration  = time.Minute * 15
+	refreshTokenDuration = time.Hour * (24 * 7)
 )
 
 type AccessToken string
@@ -30,26 +40,23 @@ type TokenPair struct {
 }
 
 type AuthService struct {
-	userRepository entities.UserInterface
-	secretKey      string
+	userRepository         entities.UserInterface
+	refreshTokenRepository entities.RefreshTokenInterface
+	keys                   KeySet
 }
 
-func NewAuthService(userRepository entities.UserInterface, secretKey string) AuthService {
+func NewAuthService(userRepository entities.UserInterface, refreshTokenRepository entities.RefreshTokenInterface, keys KeySet) AuthService {
 	return AuthService{
-		userRepository: userRepository,
-		secretKey:      secretKey,
+		userRepository:         userRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		keys:                   keys,
 	}
 }
 
 func (u AuthService) validateToken(tokenString string) (*jwt.Token, error) {
 	op := "AuthService.ValidateToken()"
 
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return []byte(u.secretKey), nil
-	})
+	token, err := jwt.Parse(tokenString, u.keys.Keyfunc, jwt.WithValidMethods(u.keys.ValidMethods()))
 	if err != nil {
 		return nil, entities.NewUnauthorizedError(err, err.Error(), op)
 	}
@@ -62,6 +69,14 @@ func (u AuthService) validateToken(tokenString string) (*jwt.Token, error) {
 
 func (u AuthService) Login(ctx context.Context, credentials entities.Credentials) (TokenPair, error) {
 	op := "AuthService.Login()"
+	validator := validation.New()
+	validation.Field(validator, "login", credentials.Login, validation.Required[string]())
+	validation.Field(validator, "password", credentials.Password, validation.Required[string]())
+	err := validator.Error(op)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
 	credentialsDatabase, err := u.userRepository.GetCredentialsByLogin(ctx, credentials.Login)
 	if err != nil {
 		return TokenPair{}, err
@@ -72,84 +87,149 @@ func (u AuthService) Login(ctx context.Context, credentials entities.Credentials
 		return TokenPair{}, entities.NewUnauthorizedError(ErrInvalidCredentials, ErrInvalidCredentials.Error(), op)
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":  credentials.Login,
-		"iss":  "ecomapi",
-		"exp":  time.Now().Add(time.Minute * 15).Unix(),
-		"iat":  time.Now().Unix(),
-		"type": "access_token",
-	})
+	return u.newTokenPair(ctx, credentialsDatabase.Login, credentialsDatabase.Role, uuid.New(), op)
+}
 
-	signedAccessToken, err := accessToken.SignedString([]byte(u.secretKey))
+// RefreshToken rotates the pair: the presented refresh token is marked as used
+// and a new one of the same family is issued. Presenting a used token again
+// means it leaked, so the whole family is revoked.
+func (u AuthService) RefreshToken(ctx context.Context, refreshToken RefreshToken) (TokenPair, error) {
+	op := "AuthService.RefreshToken()"
+
+	record, err := u.refreshTokenRecord(ctx, refreshToken, op)
 	if err != nil {
-		return TokenPair{}, entities.NewInternalServerErrorError(err, op)
+		return TokenPair{}, err
+	}
+	if record.RevokedAt != nil {
+		return TokenPair{}, entities.NewUnauthorizedError(ErrRevokedToken, ErrRevokedToken.Error(), op)
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":  credentials.Login,
-		"iss":  "ecomapi",
-		"exp":  time.Now().Add(time.Hour * (24 * 7)).Unix(),
-		"iat":  time.Now().Unix(),
-		"type": "refresh_token",
-	})
+	now := time.Now().UTC()
+	marked := false
+	if record.UsedAt == nil {
+		marked, err = u.refreshTokenRepository.MarkRefreshTokenUsed(ctx, record.Id, now)
+		if err != nil {
+			return TokenPair{}, entities.NewInternalServerErrorError(err, op)
+		}
+	}
+	if !marked {
+		err = u.refreshTokenRepository.RevokeRefreshTokenFamily(ctx, record.FamilyId, now)
+		if err != nil {
+			return TokenPair{}, entities.NewInternalServerErrorError(err, op)
+		}
+		log.Printf("refresh token reuse detected for %s, family %s revoked", record.Login, record.FamilyId)
+		return TokenPair{}, entities.NewUnauthorizedError(ErrRefreshTokenReused, ErrRefreshTokenReused.Error(), op)
+	}
 
-	signedRefreshToken, err := refreshToken.SignedString([]byte(u.secretKey))
+	// the role is read again so that role changes apply on the next refresh
+	credentials, err := u.userRepository.GetCredentialsByLogin(ctx, record.Login)
 	if err != nil {
-		return TokenPair{}, entities.NewInternalServerErrorError(err, op)
+		return TokenPair{}, err
+	}
+	if credentials.IsEmpty() {
+		return TokenPair{}, entities.NewUnauthorizedError(ErrInvalidToken, ErrInvalidToken.Error(), op)
 	}
 
-	return TokenPair{AccessToken(signedAccessToken), RefreshToken(signedRefreshToken)}, nil
+	return u.newTokenPair(ctx, credentials.Login, credentials.Role, record.FamilyId, op)
 }
 
-func (u AuthService) RefreshToken(refreshToken RefreshToken) (TokenPair, error) {
-	op := "AuthService.RefreshToken()"
+// Logout revokes the session (token family) of the given refresh token.
+func (u AuthService) Logout(ctx context.Context, refreshToken RefreshToken) error {
+	op := "AuthService.Logout()"
 
-	token, err := u.validateToken(string(refreshToken))
+	record, err := u.refreshTokenRecord(ctx, refreshToken, op)
 	if err != nil {
-		return TokenPair{}, entities.NewUnauthorizedError(err, err.Error(), op)
+		return err
 	}
+	err = u.refreshTokenRepository.RevokeRefreshTokenFamily(ctx, record.FamilyId, time.Now().UTC())
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	return nil
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return TokenPair{}, entities.NewUnauthorizedError(errors.New("error parse token claims"), "error parse token claims", op)
+// LogoutAll revokes every session of the login. Access tokens already issued
+// stay valid until they expire.
+func (u AuthService) LogoutAll(ctx context.Context, login string) error {
+	op := "AuthService.LogoutAll()"
+	err := u.refreshTokenRepository.RevokeUserRefreshTokens(ctx, login, time.Now().UTC())
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
 	}
+	return nil
+}
 
-	sub, ok := claims["sub"].(string)
+func (u AuthService) refreshTokenRecord(ctx context.Context, refreshToken RefreshToken, op string) (entities.RefreshTokenRecord, error) {
+	token, err := u.validateToken(string(refreshToken))
+	if err != nil {
+		return entities.RefreshTokenRecord{}, entities.NewUnauthorizedError(err, err.Error(), op)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return TokenPair{}, entities.NewUnauthorizedError(errors.New("subject not found on token"), "subject not found on token", op)
+		return entities.RefreshTokenRecord{}, entities.NewUnauthorizedError(errors.New("error parse token claims"), "error parse token claims", op)
 	}
 
 	typeToken, ok := claims["type"].(string)
 	if !ok {
-		return TokenPair{}, entities.NewInternalServerErrorError(errors.New("error parse token claims"), op)
+		return entities.RefreshTokenRecord{}, entities.NewInternalServerErrorError(errors.New("error parse token claims"), op)
 	}
 
 	if typeToken != "refresh_token" {
-		return TokenPair{}, entities.NewUnauthorizedError(ErrExpectedRefreshToken, ErrExpectedRefreshToken.Error(), op)
+		return entities.RefreshTokenRecord{}, entities.NewUnauthorizedError(ErrExpectedRefreshToken, ErrExpectedRefreshToken.Error(), op)
+	}
+
+	jti, _ := claims["jti"].(string)
+	id, err := uuid.Parse(jti)
+	if err != nil {
+		return entities.RefreshTokenRecord{}, entities.NewUnauthorizedError(ErrInvalidToken, ErrInvalidToken.Error(), op)
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":  sub,
+	record, err := u.refreshTokenRepository.GetRefreshTokenById(ctx, id)
+	if err != nil {
+		return entities.RefreshTokenRecord{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if record.IsEmpty() {
+		return entities.RefreshTokenRecord{}, entities.NewUnauthorizedError(ErrInvalidToken, ErrInvalidToken.Error(), op)
+	}
+	return record, nil
+}
+
+func (u AuthService) newTokenPair(ctx context.Context, login string, role entities.Role, familyId uuid.UUID, op string) (TokenPair, error) {
+	now := time.Now()
+	signedAccessToken, err := u.keys.Sign(jwt.MapClaims{
+		"sub":  login,
 		"iss":  "ecomapi",
-		"exp":  time.Now().Add(time.Minute * 15).Unix(),
-		"iat":  time.Now().Unix(),
+		"exp":  now.Add(accessTokenDuration).Unix(),
+		"iat":  now.Unix(),
 		"type": "access_token",
+		"role": string(role),
 	})
+	if err != nil {
+		return TokenPair{}, entities.NewInternalServerErrorError(err, op)
+	}
 
-	signedAccessToken, err := accessToken.SignedString([]byte(u.secretKey))
+	record := entities.RefreshTokenRecord{
+		Id:        uuid.New(),
+		FamilyId:  familyId,
+		Login:     login,
+		ExpiresAt: now.Add(refreshTokenDuration).UTC(),
+		CreatedAt: now.UTC(),
+	}
+	err = u.refreshTokenRepository.CreateRefreshToken(ctx, record)
 	if err != nil {
 		return TokenPair{}, entities.NewInternalServerErrorError(err, op)
 	}
 
-	newRefreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":  sub,
+	signedRefreshToken, err := u.keys.Sign(jwt.MapClaims{
+		"sub":  login,
 		"iss":  "ecomapi",
-		"exp":  time.Now().Add(time.Hour * (24 * 7)).Unix(),
-		"iat":  time.Now().Unix(),
+		"exp":  record.ExpiresAt.Unix(),
+		"iat":  now.Unix(),
 		"type": "refresh_token",
+		"jti":  record.Id.String(),
+		"fam":  familyId.String(),
 	})
-
-	signedRefreshToken, err := newRefreshToken.SignedString([]byte(u.secretKey))
 	if err != nil {
 		return TokenPair{}, entities.NewInternalServerErrorError(err, op)
 	}
@@ -162,34 +242,70 @@ func (u AuthService) AuthenticationMiddleware(next http.Handler) http.Handler {
 		op := "AuthService.AuthenticationMiddleware()"
 		tokenString, err := utils.GetBearerToken(r)
 		if err != nil {
-			utils.JSONError(w, err)
+			utils.JSONError(w, r, err)
 			return
 		}
 
 		token, err := u.validateToken(tokenString)
 		if err != nil {
-			utils.JSONError(w, err)
+			utils.JSONError(w, r, err)
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			utils.JSONError(w, entities.NewInternalServerErrorError(errors.New("error parse token claims"), op))
+			utils.JSONError(w, r, entities.NewInternalServerErrorError(errors.New("error parse token claims"), op))
 			return
 		}
 
 		typeToken, ok := claims["type"].(string)
 		if !ok {
-			utils.JSONError(w, entities.NewInternalServerErrorError(errors.New("error parse token claims"), op))
+			utils.JSONError(w, r, entities.NewInternalServerErrorError(errors.New("error parse token claims"), op))
 			return
 		}
 
 		if typeToken != "access_token" {
-			utils.JSONError(w, entities.NewUnauthorizedError(ErrExpectedAccessToken, ErrExpectedAccessToken.Error(), op))
+			utils.JSONError(w, r, entities.NewUnauthorizedError(ErrExpectedAccessToken, ErrExpectedAccessToken.Error(), op))
+			return
+		}
+
+		principal := Principal{Role: entities.RoleCustomer}
+		principal.Login, _ = claims["sub"].(string)
+		if role, ok := claims["role"].(string); ok && entities.Role(role).IsValid() {
+			principal.Role = entities.Role(role)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalContextKey, principal)))
+	})
+}
+
+// OptionalAuthenticationMiddleware authenticates the requests that send an
+// Authorization header and lets the others through without a principal.
+func (u AuthService) OptionalAuthenticationMiddleware(next http.Handler) http.Handler {
+	authenticated := u.AuthenticationMiddleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
 			return
 		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
 
-		log.Printf("Token claims %+v\\n", token.Claims)
+// RequirePermission must run after AuthenticationMiddleware; it rejects with
+// 403 the requests whose token role does not grant the permission.
+func (u AuthService) RequirePermission(permission entities.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op := "AuthService.RequirePermission()"
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			utils.JSONError(w, r, entities.NewUnauthorizedError(ErrInvalidToken, ErrInvalidToken.Error(), op))
+			return
+		}
+		if !principal.Role.HasPermission(permission) {
+			utils.JSONError(w, r, entities.NewForbiddenError(ErrPermissionDenied,
+				fmt.Sprintf("%s: %s", ErrPermissionDenied.Error(), permission), op))
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
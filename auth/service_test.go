@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"rest-api-example/config"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+	"rest-api-example/user"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestAuthService(t *testing.T) AuthService {
+	t.Helper()
+	store := memory.NewStore()
+	users := user.NewUserRepositoryMemory(store)
+	password, err := bcrypt.GenerateFromPassword([]byte("Senha@123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = users.InsertUser(context.Background(), entities.Credentials{Login: "ana", Password: string(password), Role: entities.RoleCustomer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeySet(config.JwtConfig{}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAuthService(users, NewRefreshTokenRepositoryMemory(store), keys)
+}
+
+// cause returns the error wrapped by the entities.Error, which does not
+// implement Unwrap.
+func cause(err error) error {
+	var apiError *entities.Error
+	if errors.As(err, &apiError) {
+		return apiError.Err
+	}
+	return err
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	credentials := entities.Credentials{Login: "ana", Password: "Senha@123"}
+
+	tests := []struct {
+		name string
+		// present returns the refresh token to present after the login
+		present func(t *testing.T, service AuthService, login TokenPair) RefreshToken
+		err     error
+	}{
+		{
+			name: "first use",
+			present: func(t *testing.T, service AuthService, login TokenPair) RefreshToken {
+				return login.RefreshToken
+			},
+		},
+		{
+			name: "rotated token",
+			present: func(t *testing.T, service AuthService, login TokenPair) RefreshToken {
+				rotated, err := service.RefreshToken(ctx, login.RefreshToken)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return rotated.RefreshToken
+			},
+		},
+		{
+			name: "reused token",
+			present: func(t *testing.T, service AuthService, login TokenPair) RefreshToken {
+				if _, err := service.RefreshToken(ctx, login.RefreshToken); err != nil {
+					t.Fatal(err)
+				}
+				return login.RefreshToken
+			},
+			err: ErrRefreshTokenReused,
+		},
+		{
+			name: "rotated token after a reuse",
+			present: func(t *testing.T, service AuthService, login TokenPair) RefreshToken {
+				rotated, err := service.RefreshToken(ctx, login.RefreshToken)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// the leaked token revokes the whole family, the rotated one too
+				service.RefreshToken(ctx, login.RefreshToken)
+				return rotated.RefreshToken
+			},
+			err: ErrRevokedToken,
+		},
+		{
+			name: "after the logout",
+			present: func(t *testing.T, service AuthService, login TokenPair) RefreshToken {
+				if err := service.Logout(ctx, login.RefreshToken); err != nil {
+					t.Fatal(err)
+				}
+				return login.RefreshToken
+			},
+			err: ErrRevokedToken,
+		},
+		{
+			name: "after the logout of every session",
+			present: func(t *testing.T, service AuthService, login TokenPair) RefreshToken {
+				if err := service.LogoutAll(ctx, "ana"); err != nil {
+					t.Fatal(err)
+				}
+				return login.RefreshToken
+			},
+			err: ErrRevokedToken,
+		},
+		{
+			name: "access token",
+			present: func(t *testing.T, service AuthService, login TokenPair) RefreshToken {
+				return RefreshToken(login.AccessToken)
+			},
+			err: ErrExpectedRefreshToken,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := newTestAuthService(t)
+			login, err := service.Login(ctx, credentials)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = service.RefreshToken(ctx, test.present(t, service, login))
+			if !errors.Is(cause(err), test.err) {
+				t.Errorf("RefreshToken() error = %v, want %v", cause(err), test.err)
+			}
+		})
+	}
+}
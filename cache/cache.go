@@ -0,0 +1,143 @@
+// Package cache keeps the results of the catalog reads between requests. The
+// Cache interface stores bytes under string keys, so the in-process LRU can be
+// replaced by an external cache shared by every instance of the service.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type Cache interface {
+	// Get returns false when the key is missing or expired.
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set stores value for ttl, evicting older entries when the cache is full.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Delete(ctx context.Context, keys ...string)
+	DeletePrefix(ctx context.Context, prefix string)
+	Stats() Stats
+}
+
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Bytes     int    `json:"bytes"`
+}
+
+// Layer is the Cache shared by the repository decorators. It counts the
+// invalidations, so a value loaded from the database while a write
+// invalidated the cache is not stored over the new state.
+type Layer struct {
+	cache Cache
+	ttl   time.Duration
+	// mutex orders the stores of Load and the start of the invalidations
+	mutex      sync.RWMutex
+	generation uint64
+}
+
+func NewLayer(c Cache, ttl time.Duration) *Layer {
+	return &Layer{
+		cache: c,
+		ttl:   ttl,
+	}
+}
+
+func (l *Layer) Stats() Stats {
+	return l.cache.Stats()
+}
+
+// Invalidate deletes the keys and every key starting with one of prefixes.
+// It must be called once the write succeeded.
+func (l *Layer) Invalidate(ctx context.Context, keys []string, prefixes ...string) {
+	l.mutex.Lock()
+	l.generation++
+	l.mutex.Unlock()
+	if len(keys) > 0 {
+		l.cache.Delete(ctx, keys...)
+	}
+	for _, prefix := range prefixes {
+		l.cache.DeletePrefix(ctx, prefix)
+	}
+}
+
+// Key builds the key of a read from its arguments, such as the filters of a
+// list, which are hashed so keys keep a bounded length.
+func Key(prefix string, parts ...any) string {
+	encoded, err := json.Marshal(parts)
+	if err != nil {
+		// only the reads that can be encoded are cached
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return prefix + hex.EncodeToString(sum[:])
+}
+
+// entry wraps the cached values, since gob cannot encode nil slices and maps
+// at the top level.
+type entry[T any] struct {
+	Value T
+}
+
+// Load returns the value cached under key or stores the one load returns.
+// Values are gob encoded, which keeps the fields hidden from JSON such as
+// the ids and versions of the entities. An empty key skips the cache.
+func Load[T any](ctx context.Context, l *Layer, key string, load func() (T, error)) (T, error) {
+	op := "cache.Load()"
+	if key == "" {
+		return load()
+	}
+	if value, found := l.cache.Get(ctx, key); found {
+		var cached entry[T]
+		err := gob.NewDecoder(bytes.NewReader(value)).Decode(&cached)
+		if err == nil {
+			return cached.Value, nil
+		}
+		log.WithError(err).WithField("operation", op).WithField("key", key).Warn("could not decode cached value")
+		l.cache.Delete(ctx, key)
+	}
+
+	l.mutex.RLock()
+	generation := l.generation
+	l.mutex.RUnlock()
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	var buffer bytes.Buffer
+	err = gob.NewEncoder(&buffer).Encode(entry[T]{Value: value})
+	if err != nil {
+		log.WithError(err).WithField("operation", op).WithField("key", key).Warn("could not encode value to cache")
+		return value, nil
+	}
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if l.generation == generation {
+		l.cache.Set(ctx, key, buffer.Bytes(), l.ttl)
+	}
+	return value, nil
+}
+
+// LogStats logs the counters of the cache every interval, for as long as the
+// service runs.
+func (l *Layer) LogStats(interval time.Duration) {
+	for range time.Tick(interval) {
+		stats := l.Stats()
+		log.WithFields(log.Fields{
+			"hits":      stats.Hits,
+			"misses":    stats.Misses,
+			"evictions": stats.Evictions,
+			"entries":   stats.Entries,
+			"bytes":     stats.Bytes,
+		}).Info("server cache stats")
+	}
+}
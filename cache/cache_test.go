@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	errLoad := errors.New("database unavailable")
+
+	tests := []struct {
+		name string
+		key  string
+		// between runs after the first load and before the second
+		between func(layer *Layer)
+		// during runs inside the first load, as a concurrent write would
+		during func(layer *Layer)
+		err    error
+		loads  int
+	}{
+		{name: "second read is cached", key: "products:1", loads: 1},
+		{name: "empty key skips the cache", key: "", loads: 2},
+		{name: "errors are not cached", key: "products:1", err: errLoad, loads: 2},
+		{
+			name:    "invalidated key",
+			key:     "products:1",
+			between: func(layer *Layer) { layer.Invalidate(ctx, []string{"products:1"}) },
+			loads:   2,
+		},
+		{
+			name:    "invalidated prefix",
+			key:     "products:list:abc",
+			between: func(layer *Layer) { layer.Invalidate(ctx, nil, "products:list:") },
+			loads:   2,
+		},
+		{
+			name:    "other prefix",
+			key:     "products:1",
+			between: func(layer *Layer) { layer.Invalidate(ctx, nil, "categories:") },
+			loads:   1,
+		},
+		{
+			// the value read before the write is not stored over the new state
+			name:   "invalidated while loading",
+			key:    "products:1",
+			during: func(layer *Layer) { layer.Invalidate(ctx, []string{"products:1"}) },
+			loads:  2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			layer := NewLayer(NewLRU(0, 0), time.Minute)
+			loads := 0
+			load := func() ([]string, error) {
+				loads++
+				if loads == 1 && test.during != nil {
+					test.during(layer)
+				}
+				return nil, test.err
+			}
+
+			for run := range 2 {
+				if run == 1 && test.between != nil {
+					test.between(layer)
+				}
+				value, err := Load(ctx, layer, test.key, load)
+				if !errors.Is(err, test.err) {
+					t.Fatalf("Load() error = %v, want %v", err, test.err)
+				}
+				if value != nil {
+					t.Errorf("Load() = %v, want the nil slice loaded", value)
+				}
+			}
+			if loads != test.loads {
+				t.Errorf("loaded %d times, want %d", loads, test.loads)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     []any
+		b     []any
+		equal bool
+	}{
+		{name: "same arguments", a: []any{"caneca", 1, 10}, b: []any{"caneca", 1, 10}, equal: true},
+		{name: "other page", a: []any{"caneca", 1, 10}, b: []any{"caneca", 2, 10}},
+		{name: "other currency", a: []any{"caneca", "BRL"}, b: []any{"caneca", "USD"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := Key("products:search:", test.a...), Key("products:search:", test.b...)
+			if (a == b) != test.equal {
+				t.Errorf("Key(%v) = %q and Key(%v) = %q, want equal %v", test.a, a, test.b, b, test.equal)
+			}
+		})
+	}
+
+	if key := Key("products:", func() {}); key != "" {
+		t.Errorf("Key() of an argument JSON can not encode = %q, want empty", key)
+	}
+}
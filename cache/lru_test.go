@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	type set struct {
+		key   string
+		value string
+		ttl   time.Duration
+	}
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int
+		sets       []set
+		// get, when set, is read between the first sets and the last one
+		get       string
+		last      set
+		present   []string
+		evictions uint64
+		bytes     int
+	}{
+		{
+			name:       "evicts the least recently set",
+			maxEntries: 2,
+			sets:       []set{{"a", "1", time.Minute}, {"b", "2", time.Minute}},
+			last:       set{"c", "3", time.Minute},
+			present:    []string{"b", "c"},
+			evictions:  1,
+			bytes:      2,
+		},
+		{
+			name:       "a read keeps the entry",
+			maxEntries: 2,
+			sets:       []set{{"a", "1", time.Minute}, {"b", "2", time.Minute}},
+			get:        "a",
+			last:       set{"c", "3", time.Minute},
+			present:    []string{"a", "c"},
+			evictions:  1,
+			bytes:      2,
+		},
+		{
+			name:      "evicts by size",
+			maxBytes:  6,
+			sets:      []set{{"a", "123", time.Minute}, {"b", "123", time.Minute}},
+			last:      set{"c", "12", time.Minute},
+			present:   []string{"b", "c"},
+			evictions: 1,
+			bytes:     5,
+		},
+		{
+			name:     "skips values larger than the cache",
+			maxBytes: 4,
+			sets:     []set{{"a", "1", time.Minute}},
+			last:     set{"b", "12345", time.Minute},
+			present:  []string{"a"},
+			bytes:    1,
+		},
+		{
+			name:    "replaces the value of a key",
+			sets:    []set{{"a", "123", time.Minute}},
+			last:    set{"a", "1", time.Minute},
+			present: []string{"a"},
+			bytes:   1,
+		},
+		{
+			name:    "expired entries are missing",
+			sets:    []set{{"a", "1", time.Minute}},
+			last:    set{"b", "2", -time.Second},
+			present: []string{"a"},
+			bytes:   1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := NewLRU(test.maxEntries, test.maxBytes)
+			for _, set := range test.sets {
+				cache.Set(ctx, set.key, []byte(set.value), set.ttl)
+			}
+			if test.get != "" {
+				cache.Get(ctx, test.get)
+			}
+			cache.Set(ctx, test.last.key, []byte(test.last.value), test.last.ttl)
+
+			var present []string
+			for _, key := range []string{"a", "b", "c"} {
+				if _, found := cache.Get(ctx, key); found {
+					present = append(present, key)
+				}
+			}
+			if !slices.Equal(present, test.present) {
+				t.Errorf("present keys = %v, want %v", present, test.present)
+			}
+			stats := cache.Stats()
+			if stats.Evictions != test.evictions || stats.Bytes != test.bytes || stats.Entries != len(test.present) {
+				t.Errorf("Stats() = %+v, want %d evictions, %d bytes and %d entries", stats, test.evictions, test.bytes, len(test.present))
+			}
+		})
+	}
+}
+
+func TestLRUDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRU(0, 0)
+	for _, key := range []string{"products:list:1", "products:list:2", "products:1", "categories:1"} {
+		cache.Set(ctx, key, []byte("x"), time.Minute)
+	}
+	cache.DeletePrefix(ctx, "products:list:")
+	cache.Delete(ctx, "categories:1", "missing")
+
+	for key, want := range map[string]bool{"products:list:1": false, "products:list:2": false, "products:1": true, "categories:1": false} {
+		if _, found := cache.Get(ctx, key); found != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, found, want)
+		}
+	}
+}
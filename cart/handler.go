@@ -0,0 +1,208 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"rest-api-example/auth"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CartIdHeader carries the anonymous cart to merge on POST /auth/login.
+const CartIdHeader = "X-Cart-Id"
+
+var (
+	ErrFormatoJsonInvalido = errors.New("verifique o formato do JSON e tente novamente")
+)
+
+type CartHandler struct {
+	cartService CartService
+}
+
+func NewCartHandler(s CartService) CartHandler {
+	return CartHandler{
+		cartService: s,
+	}
+}
+
+type addItemRequest struct {
+	ProductId uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+type updateItemRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+func (h CartHandler) CreateCart(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	cart, created, err := h.cartService.CreateCart(ctx, principalLogin(r))
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf(entities.CartGet, cart.Id.String()))
+	statusCode := http.StatusOK
+	if created {
+		statusCode = http.StatusCreated
+	}
+	utils.Respond(w, r, newCartResource(cart), cartLinks(cart), statusCode)
+}
+
+func (h CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	op := "CartHandler.GetCart()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+
+	cart, err := h.cartService.GetCart(ctx, id, principalLogin(r))
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, newCartResource(cart), cartLinks(cart), http.StatusOK)
+}
+
+func (h CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	op := "CartHandler.AddItem()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	var request addItemRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrFormatoJsonInvalido.Error(), op))
+		return
+	}
+
+	cart, err := h.cartService.AddItem(ctx, id, principalLogin(r), request.ProductId, request.Quantity)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, newCartResource(cart), cartLinks(cart), http.StatusOK)
+}
+
+func (h CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	op := "CartHandler.UpdateItem()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	id, productId, err := cartItemIds(r)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	var request updateItemRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrFormatoJsonInvalido.Error(), op))
+		return
+	}
+
+	cart, err := h.cartService.UpdateItemQuantity(ctx, id, principalLogin(r), productId, request.Quantity)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, newCartResource(cart), cartLinks(cart), http.StatusOK)
+}
+
+func (h CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	op := "CartHandler.RemoveItem()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	id, productId, err := cartItemIds(r)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+
+	cart, err := h.cartService.RemoveItem(ctx, id, principalLogin(r), productId)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, newCartResource(cart), cartLinks(cart), http.StatusOK)
+}
+
+// MergeOnLogin is an auth.LoginHook merging the anonymous cart sent in the
+// X-Cart-Id header into the cart of the user that logged in.
+func (h CartHandler) MergeOnLogin(ctx context.Context, login string, r *http.Request) error {
+	header := strings.TrimSpace(r.Header.Get(CartIdHeader))
+	if header == "" {
+		return nil
+	}
+	id, err := uuid.Parse(header)
+	if err != nil {
+		return err
+	}
+	_, err = h.cartService.MergeAnonymousCart(ctx, id, login)
+	return err
+}
+
+func principalLogin(r *http.Request) string {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	return principal.Login
+}
+
+func cartItemIds(r *http.Request) (uuid.UUID, uuid.UUID, error) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	productId, err := uuid.Parse(vars["productId"])
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return id, productId, nil
+}
+
+func newCartResource(cart entities.Cart) entities.CartResource {
+	id := cart.Id.String()
+	items := make([]entities.CartItemResource, len(cart.Items))
+	for index, item := range cart.Items {
+		productId := item.ProductId.String()
+		items[index] = entities.CartItemResource{
+			CartItem: item,
+			Links: entities.NewHateoasBuilder().
+				AddGet("product", fmt.Sprintf(entities.ProductGet, productId)).
+				AddPut("update", fmt.Sprintf(entities.CartItemUpdate, id, productId)).
+				AddDelete("remove", fmt.Sprintf(entities.CartItemRemove, id, productId)).
+				Build(),
+		}
+	}
+	return entities.CartResource{
+		Cart:  cart,
+		Items: items,
+	}
+}
+
+func cartLinks(cart entities.Cart) entities.Hateoas {
+	return entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf(entities.CartGet, cart.Id.String())).
+		AddPost("add_item", fmt.Sprintf(entities.CartItemAdd, cart.Id.String())).
+		Build()
+}
@@ -0,0 +1,230 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type CartRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewCartRepositoryPostgres(db *sql.DB) entities.CartInterface {
+	return CartRepositoryPostgres{
+		db: db,
+	}
+}
+
+func (r CartRepositoryPostgres) CreateCart(ctx context.Context, cart entities.Cart) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Insert("carts").
+		Columns("id", "owner", "created_at", "updated_at").
+		Values(cart.Id, sql.NullString{String: cart.Owner, Valid: cart.Owner != ""}, cart.CreatedAt, cart.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r CartRepositoryPostgres) GetCartById(ctx context.Context, id uuid.UUID) (entities.Cart, error) {
+	return r.getCart(ctx, sq.Eq{"id": id.String()})
+}
+
+func (r CartRepositoryPostgres) GetCartByOwner(ctx context.Context, owner string) (entities.Cart, error) {
+	return r.getCart(ctx, sq.Eq{"owner": owner})
+}
+
+func (r CartRepositoryPostgres) getCart(ctx context.Context, where sq.Eq) (entities.Cart, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Select("id", "owner", "created_at", "updated_at").
+		From("carts").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return entities.Cart{}, err
+	}
+
+	var cart entities.Cart
+	var owner sql.NullString
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&cart.Id, &owner, &cart.CreatedAt, &cart.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Cart{}, nil
+	}
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	cart.Owner = owner.String
+
+	query, args, err = psql.Select("product_id", "name", "unit_price", "quantity", "added_at").
+		From("cart_items").
+		Where("cart_id = ?", cart.Id).
+		OrderBy("added_at", "product_id").
+		ToSql()
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	defer rows.Close()
+
+	cart.Items = []entities.CartItem{}
+	for rows.Next() {
+		var item entities.CartItem
+		err = rows.Scan(&item.ProductId, &item.Name, &item.UnitPrice, &item.Quantity, &item.AddedAt)
+		if err != nil {
+			return entities.Cart{}, err
+		}
+		cart.Items = append(cart.Items, item)
+	}
+	return cart, rows.Err()
+}
+
+func (r CartRepositoryPostgres) AddCartItem(ctx context.Context, cartId uuid.UUID, item entities.CartItem, now time.Time) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, args, err := psql.Insert("cart_items").
+		Columns("cart_id", "product_id", "name", "unit_price", "quantity", "added_at").
+		Values(cartId, item.ProductId, item.Name, item.UnitPrice, item.Quantity, item.AddedAt).
+		Suffix("ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	err = touchCart(ctx, tx, psql, cartId, now)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r CartRepositoryPostgres) UpdateCartItemQuantity(ctx context.Context, cartId uuid.UUID, productId uuid.UUID, quantity int, now time.Time) (bool, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return r.changeItem(ctx, cartId, now, psql.Update("cart_items").
+		Set("quantity", quantity).
+		Where("cart_id = ? AND product_id = ?", cartId, productId))
+}
+
+func (r CartRepositoryPostgres) DeleteCartItem(ctx context.Context, cartId uuid.UUID, productId uuid.UUID, now time.Time) (bool, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return r.changeItem(ctx, cartId, now, psql.Delete("cart_items").
+		Where("cart_id = ? AND product_id = ?", cartId, productId))
+}
+
+// changeItem runs a statement on a single item and touches the cart when the
+// item existed.
+func (r CartRepositoryPostgres) changeItem(ctx context.Context, cartId uuid.UUID, now time.Time, statement sq.Sqlizer) (bool, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	err = touchCart(ctx, tx, psql, cartId, now)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r CartRepositoryPostgres) ClaimCart(ctx context.Context, id uuid.UUID, owner string, now time.Time) (bool, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Update("carts").
+		Set("owner", owner).
+		Set("updated_at", now).
+		Where("id = ? AND owner IS NULL", id).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r CartRepositoryPostgres) MergeCarts(ctx context.Context, sourceId uuid.UUID, targetId uuid.UUID, now time.Time) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, args, err := psql.Insert("cart_items").
+		Columns("cart_id", "product_id", "name", "unit_price", "quantity", "added_at").
+		Select(psql.Select().
+			Column("?::uuid", targetId).
+			Columns("product_id", "name", "unit_price", "quantity", "added_at").
+			From("cart_items").
+			Where("cart_id = ?", sourceId)).
+		Suffix("ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	// the items of the source cart go with it
+	query, args, err = psql.Delete("carts").Where("id = ?", sourceId).ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	err = touchCart(ctx, tx, psql, targetId, now)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func touchCart(ctx context.Context, tx *sql.Tx, builder sq.StatementBuilderType, cartId any, now time.Time) error {
+	query, args, err := builder.Update("carts").
+		Set("updated_at", now).
+		Where("id = ?", cartId).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
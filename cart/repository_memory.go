@@ -0,0 +1,141 @@
+package cart
+
+import (
+	"context"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CartRepositoryMemory struct {
+	store *memory.Store
+}
+
+func NewCartRepositoryMemory(store *memory.Store) entities.CartInterface {
+	return CartRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r CartRepositoryMemory) CreateCart(ctx context.Context, cart entities.Cart) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+	cart.Items = []entities.CartItem{}
+	r.store.Carts[cart.Id] = cart
+	return nil
+}
+
+func (r CartRepositoryMemory) GetCartById(ctx context.Context, id uuid.UUID) (entities.Cart, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	return copyCart(r.store.Carts[id]), nil
+}
+
+func (r CartRepositoryMemory) GetCartByOwner(ctx context.Context, owner string) (entities.Cart, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	for _, cart := range r.store.Carts {
+		if cart.Owner == owner {
+			return copyCart(cart), nil
+		}
+	}
+	return entities.Cart{}, nil
+}
+
+func (r CartRepositoryMemory) AddCartItem(ctx context.Context, cartId uuid.UUID, item entities.CartItem, now time.Time) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	cart := copyCart(r.store.Carts[cartId])
+	index := itemIndex(cart, item.ProductId)
+	if index >= 0 {
+		cart.Items[index].Quantity += item.Quantity
+	} else {
+		cart.Items = append(cart.Items, item)
+	}
+	cart.UpdatedAt = now
+	r.store.Carts[cartId] = cart
+	return nil
+}
+
+func (r CartRepositoryMemory) UpdateCartItemQuantity(ctx context.Context, cartId uuid.UUID, productId uuid.UUID, quantity int, now time.Time) (bool, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	cart := copyCart(r.store.Carts[cartId])
+	index := itemIndex(cart, productId)
+	if index < 0 {
+		return false, nil
+	}
+	cart.Items[index].Quantity = quantity
+	cart.UpdatedAt = now
+	r.store.Carts[cartId] = cart
+	return true, nil
+}
+
+func (r CartRepositoryMemory) DeleteCartItem(ctx context.Context, cartId uuid.UUID, productId uuid.UUID, now time.Time) (bool, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	cart := copyCart(r.store.Carts[cartId])
+	index := itemIndex(cart, productId)
+	if index < 0 {
+		return false, nil
+	}
+	cart.Items = slices.Delete(cart.Items, index, index+1)
+	cart.UpdatedAt = now
+	r.store.Carts[cartId] = cart
+	return true, nil
+}
+
+func (r CartRepositoryMemory) ClaimCart(ctx context.Context, id uuid.UUID, owner string, now time.Time) (bool, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	cart, exists := r.store.Carts[id]
+	if !exists || !cart.IsAnonymous() {
+		return false, nil
+	}
+	cart.Owner = owner
+	cart.UpdatedAt = now
+	r.store.Carts[id] = cart
+	return true, nil
+}
+
+func (r CartRepositoryMemory) MergeCarts(ctx context.Context, sourceId uuid.UUID, targetId uuid.UUID, now time.Time) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	source := r.store.Carts[sourceId]
+	target := copyCart(r.store.Carts[targetId])
+	for _, item := range source.Items {
+		index := itemIndex(target, item.ProductId)
+		if index >= 0 {
+			target.Items[index].Quantity += item.Quantity
+		} else {
+			target.Items = append(target.Items, item)
+		}
+	}
+	target.UpdatedAt = now
+	r.store.Carts[targetId] = target
+	delete(r.store.Carts, sourceId)
+	return nil
+}
+
+// copyCart keeps callers from sharing the items slice held by the store.
+func copyCart(cart entities.Cart) entities.Cart {
+	if cart.IsEmpty() {
+		return cart
+	}
+	cart.Items = append([]entities.CartItem{}, cart.Items...)
+	return cart
+}
+
+func itemIndex(cart entities.Cart, productId uuid.UUID) int {
+	return slices.IndexFunc(cart.Items, func(item entities.CartItem) bool {
+		return item.ProductId == productId
+	})
+}
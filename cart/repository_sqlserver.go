@@ -0,0 +1,209 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/google/uuid"
+)
+
+type CartRepositorySqlServer struct {
+	db *sql.DB
+}
+
+func NewCartRepositorySqlServer(db *sql.DB) entities.CartInterface {
+	return CartRepositorySqlServer{
+		db: db,
+	}
+}
+
+func (r CartRepositorySqlServer) CreateCart(ctx context.Context, cart entities.Cart) error {
+	query, args, err := sq.Insert("carts").
+		Columns("id", "owner", "created_at", "updated_at").
+		Values(cart.Id.String(), sql.NullString{String: cart.Owner, Valid: cart.Owner != ""}, cart.CreatedAt, cart.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r CartRepositorySqlServer) GetCartById(ctx context.Context, id uuid.UUID) (entities.Cart, error) {
+	return r.getCart(ctx, sq.Eq{"id": id.String()})
+}
+
+func (r CartRepositorySqlServer) GetCartByOwner(ctx context.Context, owner string) (entities.Cart, error) {
+	return r.getCart(ctx, sq.Eq{"owner": owner})
+}
+
+func (r CartRepositorySqlServer) getCart(ctx context.Context, where sq.Eq) (entities.Cart, error) {
+	query, args, err := sq.Select("id", "owner", "created_at", "updated_at").
+		From("carts").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return entities.Cart{}, err
+	}
+
+	var cart entities.Cart
+	var id mssql.UniqueIdentifier
+	var owner sql.NullString
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&id, &owner, &cart.CreatedAt, &cart.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Cart{}, nil
+	}
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	cart.Id = uuid.UUID(id)
+	cart.Owner = owner.String
+
+	query, args, err = sq.Select("product_id", "name", "unit_price", "quantity", "added_at").
+		From("cart_items").
+		Where("cart_id = ?", cart.Id.String()).
+		OrderBy("added_at", "product_id").
+		ToSql()
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	defer rows.Close()
+
+	cart.Items = []entities.CartItem{}
+	for rows.Next() {
+		var productId mssql.UniqueIdentifier
+		var item entities.CartItem
+		err = rows.Scan(&productId, &item.Name, &item.UnitPrice, &item.Quantity, &item.AddedAt)
+		if err != nil {
+			return entities.Cart{}, err
+		}
+		item.ProductId = uuid.UUID(productId)
+		cart.Items = append(cart.Items, item)
+	}
+	return cart, rows.Err()
+}
+
+func (r CartRepositorySqlServer) AddCartItem(ctx context.Context, cartId uuid.UUID, item entities.CartItem, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// SQL Server has no ON CONFLICT, HOLDLOCK keeps concurrent adds of the
+	// same product from both inserting
+	_, err = tx.ExecContext(ctx, `MERGE cart_items WITH (HOLDLOCK) AS target
+		USING (SELECT ? AS cart_id, ? AS product_id, ? AS name, ? AS unit_price, ? AS quantity, ? AS added_at) AS source
+		ON target.cart_id = source.cart_id AND target.product_id = source.product_id
+		WHEN MATCHED THEN UPDATE SET quantity = target.quantity + source.quantity
+		WHEN NOT MATCHED THEN INSERT (cart_id, product_id, name, unit_price, quantity, added_at)
+			VALUES (source.cart_id, source.product_id, source.name, source.unit_price, source.quantity, source.added_at);`,
+		cartId.String(), item.ProductId.String(), item.Name, item.UnitPrice, item.Quantity, item.AddedAt)
+	if err != nil {
+		return err
+	}
+	err = touchCart(ctx, tx, sq.StatementBuilder, cartId.String(), now)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r CartRepositorySqlServer) UpdateCartItemQuantity(ctx context.Context, cartId uuid.UUID, productId uuid.UUID, quantity int, now time.Time) (bool, error) {
+	return r.changeItem(ctx, cartId, now, sq.Update("cart_items").
+		Set("quantity", quantity).
+		Where("cart_id = ? AND product_id = ?", cartId.String(), productId.String()))
+}
+
+func (r CartRepositorySqlServer) DeleteCartItem(ctx context.Context, cartId uuid.UUID, productId uuid.UUID, now time.Time) (bool, error) {
+	return r.changeItem(ctx, cartId, now, sq.Delete("cart_items").
+		Where("cart_id = ? AND product_id = ?", cartId.String(), productId.String()))
+}
+
+// changeItem runs a statement on a single item and touches the cart when the
+// item existed.
+func (r CartRepositorySqlServer) changeItem(ctx context.Context, cartId uuid.UUID, now time.Time, statement sq.Sqlizer) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	err = touchCart(ctx, tx, sq.StatementBuilder, cartId.String(), now)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r CartRepositorySqlServer) ClaimCart(ctx context.Context, id uuid.UUID, owner string, now time.Time) (bool, error) {
+	query, args, err := sq.Update("carts").
+		Set("owner", owner).
+		Set("updated_at", now).
+		Where("id = ? AND owner IS NULL", id.String()).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r CartRepositorySqlServer) MergeCarts(ctx context.Context, sourceId uuid.UUID, targetId uuid.UUID, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `MERGE cart_items WITH (HOLDLOCK) AS target
+		USING (SELECT ? AS cart_id, product_id, name, unit_price, quantity, added_at FROM cart_items WHERE cart_id = ?) AS source
+		ON target.cart_id = source.cart_id AND target.product_id = source.product_id
+		WHEN MATCHED THEN UPDATE SET quantity = target.quantity + source.quantity
+		WHEN NOT MATCHED THEN INSERT (cart_id, product_id, name, unit_price, quantity, added_at)
+			VALUES (source.cart_id, source.product_id, source.name, source.unit_price, source.quantity, source.added_at);`,
+		targetId.String(), sourceId.String())
+	if err != nil {
+		return err
+	}
+
+	// the items of the source cart go with it
+	query, args, err := sq.Delete("carts").Where("id = ?", sourceId.String()).ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	err = touchCart(ctx, tx, sq.StatementBuilder, targetId.String(), now)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,36 @@
+package cart
+
+import (
+	"net/http"
+	"rest-api-example/auth"
+	"rest-api-example/middlewares"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/cors"
+)
+
+// SetupCartRoutes serves anonymous and authenticated carts alike: a token is
+// only checked when one is sent.
+func SetupCartRoutes(mux *mux.Router, h CartHandler, authService auth.AuthService) {
+	r := mux.PathPrefix("/carts").Subrouter()
+	r.Use(cors.New(cors.Options{
+		AllowedOrigins: []string{"http://127.0.0.1:5500"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+	}).Handler)
+	r.Use(authService.OptionalAuthenticationMiddleware)
+	r.HandleFunc("", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+		h.CreateCart)).Methods(http.MethodOptions, http.MethodPost)
+	r.HandleFunc("/{id}", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+		h.GetCart)).Methods(http.MethodOptions, http.MethodGet)
+	r.HandleFunc("/{id}/items",
+		middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
+			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.AddItem))).Methods(http.MethodOptions,
+		http.MethodPost)
+	r.HandleFunc("/{id}/items/{productId}",
+		middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
+			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.UpdateItem))).Methods(http.MethodOptions,
+		http.MethodPut)
+	r.HandleFunc("/{id}/items/{productId}", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+		h.RemoveItem)).Methods(http.MethodOptions, http.MethodDelete)
+}
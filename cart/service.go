@@ -0,0 +1,197 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"rest-api-example/entities"
+	"rest-api-example/product"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MaxItemQuantity = 999
+
+var (
+	ErrCarrinhoNaoEncontrado  = errors.New("carrinho não encontrado")
+	ErrItemNaoEncontrado      = errors.New("produto não está no carrinho")
+	ErrQuantidadeInvalida     = errors.New("a quantidade deve estar entre 1 e 999")
+	ErrProdutoInexistente     = errors.New("produto não cadastrado")
+	ErrProdutoInativo         = errors.New("produto inativo não pode ser adicionado ao carrinho")
+	ErrCarrinhoDeOutroUsuario = errors.New("carrinho pertence a outro usuário")
+)
+
+type CartService struct {
+	cartRepository entities.CartInterface
+	productService product.ProductService
+}
+
+func NewCartService(c entities.CartInterface, p product.ProductService) CartService {
+	return CartService{
+		cartRepository: c,
+		productService: p,
+	}
+}
+
+// CreateCart creates an anonymous cart, or returns the cart of login when it
+// already has one. The second return tells whether a cart was created.
+func (s CartService) CreateCart(ctx context.Context, login string) (entities.Cart, bool, error) {
+	op := "CartService.CreateCart()"
+	if login != "" {
+		cart, err := s.cartRepository.GetCartByOwner(ctx, login)
+		if err != nil {
+			return entities.Cart{}, false, entities.NewInternalServerErrorError(err, op)
+		}
+		if !cart.IsEmpty() {
+			return cart.WithTotals(), false, nil
+		}
+	}
+
+	now := time.Now().UTC()
+	cart := entities.Cart{
+		Id:        uuid.New(),
+		Owner:     login,
+		Items:     []entities.CartItem{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := s.cartRepository.CreateCart(ctx, cart)
+	if err != nil {
+		return entities.Cart{}, false, entities.NewInternalServerErrorError(err, op)
+	}
+	return cart, true, nil
+}
+
+// GetCart returns the cart when it is anonymous or belongs to login; carts of
+// other users are reported as not found.
+func (s CartService) GetCart(ctx context.Context, id uuid.UUID, login string) (entities.Cart, error) {
+	op := "CartService.GetCart()"
+	cart, err := s.cartRepository.GetCartById(ctx, id)
+	if err != nil {
+		return entities.Cart{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if cart.IsEmpty() || (!cart.IsAnonymous() && cart.Owner != login) {
+		return entities.Cart{}, entities.NewNotFoundError(ErrCarrinhoNaoEncontrado, ErrCarrinhoNaoEncontrado.Error(), op)
+	}
+	return cart.WithTotals(), nil
+}
+
+// AddItem adds quantity units of an active product, keeping the price the
+// product has now. Adding a product already in the cart sums the quantities.
+func (s CartService) AddItem(ctx context.Context, id uuid.UUID, login string, productId uuid.UUID, quantity int) (entities.Cart, error) {
+	op := "CartService.AddItem()"
+	if quantity < 1 || quantity > MaxItemQuantity {
+		return entities.Cart{}, entities.NewUnprocessableEntityError(ErrQuantidadeInvalida, ErrQuantidadeInvalida.Error(), op,
+			entities.FieldError{Field: "quantity", Message: ErrQuantidadeInvalida.Error()})
+	}
+	cart, err := s.GetCart(ctx, id, login)
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	for _, item := range cart.Items {
+		if item.ProductId == productId && item.Quantity+quantity > MaxItemQuantity {
+			return entities.Cart{}, entities.NewUnprocessableEntityError(ErrQuantidadeInvalida, ErrQuantidadeInvalida.Error(), op,
+				entities.FieldError{Field: "quantity", Message: ErrQuantidadeInvalida.Error()})
+		}
+	}
+
+	productDatabase, err := s.productService.GetProductById(ctx, productId)
+	var apiError *entities.Error
+	if errors.As(err, &apiError) && apiError.Code == entities.NOT_FOUND {
+		return entities.Cart{}, entities.NewUnprocessableEntityError(err, ErrProdutoInexistente.Error(), op,
+			entities.FieldError{Field: "product_id", Message: ErrProdutoInexistente.Error()})
+	}
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	if !productDatabase.Active {
+		return entities.Cart{}, entities.NewUnprocessableEntityError(ErrProdutoInativo, ErrProdutoInativo.Error(), op,
+			entities.FieldError{Field: "product_id", Message: ErrProdutoInativo.Error()})
+	}
+
+	now := time.Now().UTC()
+	item := entities.CartItem{
+		ProductId: productDatabase.Id,
+		Name:      productDatabase.Name,
+		UnitPrice: productDatabase.Price,
+		Quantity:  quantity,
+		AddedAt:   now,
+	}
+	err = s.cartRepository.AddCartItem(ctx, id, item, now)
+	if err != nil {
+		return entities.Cart{}, entities.NewInternalServerErrorError(err, op)
+	}
+	return s.GetCart(ctx, id, login)
+}
+
+func (s CartService) UpdateItemQuantity(ctx context.Context, id uuid.UUID, login string, productId uuid.UUID, quantity int) (entities.Cart, error) {
+	op := "CartService.UpdateItemQuantity()"
+	if quantity < 1 || quantity > MaxItemQuantity {
+		return entities.Cart{}, entities.NewUnprocessableEntityError(ErrQuantidadeInvalida, ErrQuantidadeInvalida.Error(), op,
+			entities.FieldError{Field: "quantity", Message: ErrQuantidadeInvalida.Error()})
+	}
+	_, err := s.GetCart(ctx, id, login)
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	updated, err := s.cartRepository.UpdateCartItemQuantity(ctx, id, productId, quantity, time.Now().UTC())
+	if err != nil {
+		return entities.Cart{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if !updated {
+		return entities.Cart{}, entities.NewNotFoundError(ErrItemNaoEncontrado, ErrItemNaoEncontrado.Error(), op)
+	}
+	return s.GetCart(ctx, id, login)
+}
+
+func (s CartService) RemoveItem(ctx context.Context, id uuid.UUID, login string, productId uuid.UUID) (entities.Cart, error) {
+	op := "CartService.RemoveItem()"
+	_, err := s.GetCart(ctx, id, login)
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	deleted, err := s.cartRepository.DeleteCartItem(ctx, id, productId, time.Now().UTC())
+	if err != nil {
+		return entities.Cart{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if !deleted {
+		return entities.Cart{}, entities.NewNotFoundError(ErrItemNaoEncontrado, ErrItemNaoEncontrado.Error(), op)
+	}
+	return s.GetCart(ctx, id, login)
+}
+
+// MergeAnonymousCart hands the anonymous cart over to login: it becomes the
+// cart of the user when there is none, otherwise its items are moved into the
+// cart the user already has.
+func (s CartService) MergeAnonymousCart(ctx context.Context, id uuid.UUID, login string) (entities.Cart, error) {
+	op := "CartService.MergeAnonymousCart()"
+	anonymous, err := s.GetCart(ctx, id, login)
+	if err != nil {
+		return entities.Cart{}, err
+	}
+	if !anonymous.IsAnonymous() {
+		return anonymous, nil
+	}
+
+	now := time.Now().UTC()
+	userCart, err := s.cartRepository.GetCartByOwner(ctx, login)
+	if err != nil {
+		return entities.Cart{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if userCart.IsEmpty() {
+		claimed, err := s.cartRepository.ClaimCart(ctx, id, login, now)
+		if err != nil {
+			return entities.Cart{}, entities.NewInternalServerErrorError(err, op)
+		}
+		if !claimed {
+			return entities.Cart{}, entities.NewConflictError(ErrCarrinhoDeOutroUsuario, ErrCarrinhoDeOutroUsuario.Error(), op)
+		}
+		return s.GetCart(ctx, id, login)
+	}
+
+	err = s.cartRepository.MergeCarts(ctx, id, userCart.Id, now)
+	if err != nil {
+		return entities.Cart{}, entities.NewInternalServerErrorError(err, op)
+	}
+	return s.GetCart(ctx, userCart.Id, login)
+}
@@ -0,0 +1,49 @@
+package category
+
+import (
+	"rest-api-example/entities"
+	"rest-api-example/listing"
+	"rest-api-example/utils"
+)
+
+// categorySchema lists the fields GET /categories may be filtered and sorted
+// by.
+var categorySchema = listing.Schema{
+	Fields: []listing.Field{
+		{Name: "name", Column: "name", Kind: listing.Text, Operators: listing.TextOperators, Sortable: true},
+		{Name: "description", Column: "description", Kind: listing.Text, Operators: []listing.Operator{listing.Contains}},
+		{Name: "active", Column: "active", Kind: listing.Bool, Operators: []listing.Operator{listing.Eq}},
+		{Name: "parent_id", Column: "parent_id", Kind: listing.UUID, Operators: listing.EqualityOperators},
+		{Name: "created_at", Column: "created_at", Kind: listing.Time, Operators: listing.RangeOperators, Sortable: true},
+		{Name: "updated_at", Column: "updated_at", Kind: listing.Time, Operators: listing.RangeOperators, Sortable: true},
+	},
+	Reserved:    []string{"page", "limit", listing.CursorParam, listing.CountParam, utils.FieldsParam, utils.EmbedParam},
+	DefaultSort: []listing.Sort{{Field: "created_at"}},
+	IdColumn:    "id",
+}
+
+// categoryValues gives the fields of categorySchema for the memory repository.
+func categoryValues(category entities.Category) listing.Values {
+	return func(field string) any {
+		switch field {
+		case "id":
+			return category.Id
+		case "name":
+			return category.Name
+		case "description":
+			return category.Description
+		case "active":
+			return category.Active
+		case "parent_id":
+			if category.ParentId == nil {
+				return nil
+			}
+			return *category.ParentId
+		case "created_at":
+			return category.CreatedAt
+		case "updated_at":
+			return category.UpdatedAt
+		}
+		return nil
+	}
+}
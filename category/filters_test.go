@@ -0,0 +1,44 @@
+package category
+
+import (
+	"rest-api-example/entities"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCategorySchemaParentFilter(t *testing.T) {
+	root := entities.Category{Id: uuid.New(), Name: "Casa"}
+	other := entities.Category{Id: uuid.New(), Name: "Moda"}
+	child := entities.Category{Id: uuid.New(), Name: "Cozinha", ParentId: &root.Id}
+	categories := []entities.Category{root, other, child}
+
+	tests := []struct {
+		name     string
+		params   map[string][]string
+		expected []string
+	}{
+		{name: "children of a category", params: map[string][]string{"parent_id": {root.Id.String()}}, expected: []string{"Cozinha"}},
+		{name: "children of a leaf", params: map[string][]string{"parent_id": {child.Id.String()}}},
+		// the roots have a NULL parent_id, which SQL does not compare as different
+		{name: "other parents", params: map[string][]string{"parent_id[ne]": {other.Id.String()}}, expected: []string{"Cozinha"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := categorySchema.Parse(test.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var matched []string
+			for _, category := range categories {
+				if query.Matches(categoryValues(category)) {
+					matched = append(matched, category.Name)
+				}
+			}
+			if !slices.Equal(matched, test.expected) {
+				t.Errorf("matched %v, want %v", matched, test.expected)
+			}
+		})
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
 	"rest-api-example/entities"
+	"rest-api-example/listing"
+	"rest-api-example/patch"
 	"rest-api-example/utils"
 	"strconv"
 	"strings"
@@ -16,13 +19,23 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// embedProducts is the relation ?embed= may ask for.
+const embedProducts = "products"
+
 type CategoryHandler struct {
 	categoryService CategoryService
+	requireIfMatch  bool
+	cursors         listing.CursorCodec
 }
 
-func NewCategoryHandler(s CategoryService) CategoryHandler {
+// NewCategoryHandler builds the handler; with requireIfMatch set, PATCH and
+// DELETE without If-Match are rejected with 428. Cursors signs the cursors of
+// the list.
+func NewCategoryHandler(s CategoryService, requireIfMatch bool, cursors listing.CursorCodec) CategoryHandler {
 	return CategoryHandler{
 		categoryService: s,
+		requireIfMatch:  requireIfMatch,
+		cursors:         cursors,
 	}
 }
 
@@ -35,7 +48,6 @@ func getBaseURL(r *http.Request) string {
 }
 
 func (h CategoryHandler) GetPaginateCategories(w http.ResponseWriter, r *http.Request) {
-	op := "CategoryHandler.GetAllCategories()"
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
 	defer cancel()
 
@@ -43,52 +55,49 @@ func (h CategoryHandler) GetPaginateCategories(w http.ResponseWriter, r *http.Re
 	page := utils.GetQueryInt(queryParams, "page", 1)
 	limit := utils.GetQueryInt(queryParams, "limit", 10)
 
-	categories, totalCount, err := h.categoryService.GetAllCategories(ctx, page, limit, queryParams)
+	list, err := categorySchema.Parse(queryParams)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
-
-	resources := make([]entities.CategoryResource, len(categories))
-	for index, category := range categories {
-		links := entities.NewHateoasBuilder().
-			AddBaseUrl(getBaseURL(r)).
-			AddGet("self", fmt.Sprintf(entities.CategoryGet, category.Id.String())).
-			AddDelete("delete", fmt.Sprintf(entities.CategoryDelete, category.Id.String())).
-			AddPatch("update", fmt.Sprintf(entities.CategoryUpdate, category.Id.String())).
-			Build()
-
-		resource := entities.CategoryResource{
-			Category: category,
-			Links:    links,
-		}
-		resources[index] = resource
+	filtersUrl := list.Encode()
+	representation, err := utils.ParseRepresentation(queryParams, entities.CategoryResource{}, embedProducts)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	if queryParams.Has(listing.CursorParam) {
+		h.getCategoriesByCursor(ctx, w, r, queryParams, list, filtersUrl, representation)
+		return
 	}
 
-	var filtersUrl string
-
-	var isActive int
-	if value, exists := queryParams["active"]; exists {
-		isActive, err = strconv.Atoi(value[0])
-		if err != nil {
-			utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
-		}
-		filtersUrl += fmt.Sprintf("&active=%d", isActive)
+	categories, totalCount, err := h.categoryService.GetAllCategories(ctx, page, limit, queryParams)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	resources, err := h.representCategories(ctx, categories, representation, func(category entities.Category) any {
+		return categoryResource(r, category)
+	})
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
 	}
 
 	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
 
+	linksUrl := filtersUrl + representation.Encode()
 	paginationLinksBuilder := entities.NewHateoasBuilder().
 		AddBaseUrl(getBaseURL(r)).
-		AddGet("self", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, page, limit, filtersUrl))
+		AddGet("self", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, page, limit, linksUrl))
 	if page < totalPages {
-		paginationLinksBuilder.AddGet("last", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, totalPages, limit, filtersUrl))
+		paginationLinksBuilder.AddGet("last", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, totalPages, limit, linksUrl))
 	}
 	if page+1 <= totalPages {
-		paginationLinksBuilder.AddGet("next", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, page+1, limit, filtersUrl))
+		paginationLinksBuilder.AddGet("next", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, page+1, limit, linksUrl))
 	}
 	if page-1 > 0 {
-		paginationLinksBuilder.AddGet("prev", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, page-1, limit, filtersUrl))
+		paginationLinksBuilder.AddGet("prev", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, page-1, limit, linksUrl))
 	}
 	links := paginationLinksBuilder.Build()
 
@@ -100,36 +109,93 @@ func (h CategoryHandler) GetPaginateCategories(w http.ResponseWriter, r *http.Re
 		Hateoas:    links,
 	}
 
-	response := utils.Response{
-		Data: resources,
-		Meta: meta,
-	}
+	utils.Respond(w, r, resources, meta, http.StatusOK)
+}
 
-	//generate response with eTag
-	payload, err := json.Marshal(response)
+// getCategoriesByCursor answers GET /categories?cursor=, the list paged by the
+// signed cursors of the next and prev links instead of page numbers.
+func (h CategoryHandler) getCategoriesByCursor(ctx context.Context, w http.ResponseWriter, r *http.Request, queryParams url.Values, list listing.Query, filtersUrl string, representation utils.Representation) {
+	cursor, err := h.cursors.Parse(queryParams, filtersUrl)
 	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
+		utils.JSONError(w, r, err)
 		return
 	}
-
-	eTag := utils.GenerateETag(payload)
-
-	ifNoneMatch := strings.TrimPrefix(strings.Trim(r.Header.Get("If-None-Match"), "\""), "W/")
-	actualEtag := strings.TrimPrefix(eTag, "W/")
-	if ifNoneMatch == strings.Trim(actualEtag, "\"") {
-		w.Header().Set("ETag", eTag)
-		w.WriteHeader(http.StatusNotModified)
+	categories, totalCount, err := h.categoryService.GetCategoriesByCursor(ctx, queryParams, cursor)
+	if err != nil {
+		utils.JSONError(w, r, err)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("ETag", eTag)
-	w.WriteHeader(http.StatusOK)
-	_, err = fmt.Fprint(w, string(payload))
+	categories, next, prev := listing.Paginate(h.cursors, list, categories, cursor, filtersUrl, categoryValues)
+	resources, err := h.representCategories(ctx, categories, representation, func(category entities.Category) any {
+		return categoryResource(r, category)
+	})
 	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
+		utils.JSONError(w, r, err)
 		return
 	}
+
+	meta := utils.CursorMeta{
+		Limit:   cursor.Limit,
+		Results: len(categories),
+		Hateoas: utils.CursorLinks(entities.NewHateoasBuilder().AddBaseUrl(getBaseURL(r)), entities.CategoryList,
+			queryParams.Get(listing.CursorParam), next, prev, cursor.Limit, filtersUrl+representation.Encode()),
+	}
+	if totalCount >= 0 {
+		meta.TotalCount = &totalCount
+	}
+	utils.Respond(w, r, resources, meta, http.StatusOK)
+}
+
+// representCategories trims the resources of the categories to the fields
+// asked for and embeds their products, loaded for all the categories at once.
+func (h CategoryHandler) representCategories(ctx context.Context, categories []entities.Category, representation utils.Representation, resource func(entities.Category) any) ([]any, error) {
+	op := "CategoryHandler.representCategories()"
+	var products map[uuid.UUID][]entities.Product
+	if representation.Embed[embedProducts] {
+		ids := make([]uuid.UUID, len(categories))
+		for index, category := range categories {
+			ids[index] = category.Id
+		}
+		var err error
+		products, err = h.categoryService.GetProductsByCategories(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resources := make([]any, len(categories))
+	for index, category := range categories {
+		var embedded map[string]any
+		if products != nil {
+			embedded = map[string]any{embedProducts: embeddedProducts(products[category.Id])}
+		}
+		shaped, err := representation.Shape(resource(category), embedded)
+		if err != nil {
+			return nil, entities.NewInternalServerErrorError(err, op)
+		}
+		resources[index] = shaped
+	}
+	return resources, nil
+}
+
+func categoryResource(r *http.Request, category entities.Category) any {
+	return entities.CategoryResource{
+		Category: category,
+		Links:    categoryLinks(r, category),
+	}
+}
+
+// embeddedProducts links each product to itself, the full set of links being
+// in GET /products/{id}.
+func embeddedProducts(products []entities.Product) []entities.ProductResource {
+	resources := make([]entities.ProductResource, len(products))
+	for index, product := range products {
+		resources[index] = entities.ProductResource{
+			Product: product,
+			Links:   entities.NewHateoasBuilder().AddGet("self", fmt.Sprintf(entities.ProductGet, product.Id.String())).Build(),
+		}
+	}
+	return resources
 }
 
 func (h CategoryHandler) GetCategoryById(w http.ResponseWriter, r *http.Request) {
@@ -141,53 +207,39 @@ func (h CategoryHandler) GetCategoryById(w http.ResponseWriter, r *http.Request)
 	idString := vars["id"]
 	id, err := uuid.Parse(idString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 		return
 	}
 
-	category, err := h.categoryService.GetCategoryById(ctx, id)
+	representation, err := utils.ParseRepresentation(r.URL.Query(), entities.Category{}, embedProducts)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 
-	links := entities.NewHateoasBuilder().
-		AddBaseUrl(getBaseURL(r)).
-		AddGet("self", fmt.Sprintf(entities.CategoryGet, category.Id.String())).
-		AddDelete("delete", fmt.Sprintf(entities.CategoryDelete, category.Id.String())).
-		AddPatch("update", fmt.Sprintf(entities.CategoryUpdate, category.Id.String())).
-		Build()
-
-	response := utils.Response{
-		Data: category,
-		Meta: links,
-	}
-
-	//generate response with eTag
-	payload, err := json.Marshal(response)
+	category, err := h.categoryService.GetCategoryById(ctx, id)
 	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
+		utils.JSONError(w, r, err)
 		return
 	}
-
-	eTag := utils.GenerateETag(payload)
-
-	ifNoneMatch := strings.TrimPrefix(strings.Trim(r.Header.Get("If-None-Match"), "\""), "W/")
-	actualEtag := strings.TrimPrefix(eTag, "W/")
-	if ifNoneMatch == strings.Trim(actualEtag, "\"") {
-		w.Header().Set("ETag", eTag)
-		w.WriteHeader(http.StatusNotModified)
+	data, err := h.representCategories(ctx, []entities.Category{category}, representation, func(category entities.Category) any {
+		return category
+	})
+	if err != nil {
+		utils.JSONError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("ETag", eTag)
-	w.WriteHeader(http.StatusOK)
-	_, err = fmt.Fprint(w, string(payload))
-	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
-		return
+	links := categoryLinks(r, category)
+
+	// the version ETag is the one PATCH and DELETE accept in If-Match; other
+	// representations, whose embedded resources change on their own, get
+	// the digest of their content from the ConditionalGet middleware
+	if representation.IsEmpty() {
+		w.Header().Set("ETag", utils.VersionETag(category.Version))
+		utils.SetLastModified(w, category.CreatedAt, category.UpdatedAt)
 	}
+	utils.Respond(w, r, data[0], links, http.StatusOK)
 }
 
 func (h CategoryHandler) GetCategoriesByIds(w http.ResponseWriter, r *http.Request) {
@@ -198,7 +250,7 @@ func (h CategoryHandler) GetCategoriesByIds(w http.ResponseWriter, r *http.Reque
 	var idsString []string
 	err := json.NewDecoder(r.Body).Decode(&idsString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
 		return
 	}
 
@@ -206,7 +258,7 @@ func (h CategoryHandler) GetCategoriesByIds(w http.ResponseWriter, r *http.Reque
 	for _, idString := range idsString {
 		id, err := uuid.Parse(idString)
 		if err != nil {
-			utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+			utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 			return
 		}
 		ids = append(ids, id)
@@ -214,18 +266,13 @@ func (h CategoryHandler) GetCategoriesByIds(w http.ResponseWriter, r *http.Reque
 
 	categories, err := h.categoryService.GetCategoriesByIds(ctx, ids)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 
 	resources := make([]entities.CategoryResource, len(categories))
 	for index, category := range categories {
-		links := entities.NewHateoasBuilder().
-			AddBaseUrl(getBaseURL(r)).
-			AddGet("self", fmt.Sprintf(entities.CategoryGet, category.Id.String())).
-			AddDelete("delete", fmt.Sprintf(entities.CategoryDelete, category.Id.String())).
-			AddPatch("update", fmt.Sprintf(entities.CategoryUpdate, category.Id.String())).
-			Build()
+		links := categoryLinks(r, category)
 		resource := entities.CategoryResource{
 			Category: category,
 			Links:    links,
@@ -233,35 +280,12 @@ func (h CategoryHandler) GetCategoriesByIds(w http.ResponseWriter, r *http.Reque
 		resources[index] = resource
 	}
 
-	response := utils.Response{
-		Data: resources,
-	}
-
-	//generate response with eTag
-	payload, err := json.Marshal(response)
-	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
-		return
-	}
-
-	eTag := utils.GenerateETag(payload)
-
-	ifNoneMatch := strings.TrimPrefix(strings.Trim(r.Header.Get("If-None-Match"), "\""), "W/")
-	actualEtag := strings.TrimPrefix(eTag, "W/")
-	if ifNoneMatch == strings.Trim(actualEtag, "\"") {
-		w.Header().Set("ETag", eTag)
-		w.WriteHeader(http.StatusNotModified)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("ETag", eTag)
-	w.WriteHeader(http.StatusOK)
-	_, err = fmt.Fprint(w, string(payload))
-	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
-		return
-	}
+	links := entities.NewHateoasBuilder().
+		AddBaseUrl(getBaseURL(r)).
+		AddPost("self", entities.CategoryList+"/_get").
+		AddGet("list", entities.CategoryList).
+		Build()
+	utils.Respond(w, r, resources, links, http.StatusOK)
 }
 
 func (h CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
@@ -272,24 +296,19 @@ func (h CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 	var category entities.Category
 	err := json.NewDecoder(r.Body).Decode(&category)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
 		return
 	}
 
 	category, err = h.categoryService.CreateCategory(ctx, category)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 
-	links := entities.NewHateoasBuilder().
-		AddBaseUrl(getBaseURL(r)).
-		AddGet("self", fmt.Sprintf(entities.CategoryGet, category.Id.String())).
-		AddDelete("delete", fmt.Sprintf(entities.CategoryDelete, category.Id.String())).
-		AddPatch("update", fmt.Sprintf(entities.CategoryUpdate, category.Id.String())).
-		Build()
+	links := categoryLinks(r, category)
 
-	utils.JSONResponse(w, category, links, http.StatusCreated)
+	utils.Respond(w, r, category, links, http.StatusCreated)
 }
 
 func (h CategoryHandler) DeleteCategoryById(w http.ResponseWriter, r *http.Request) {
@@ -301,13 +320,19 @@ func (h CategoryHandler) DeleteCategoryById(w http.ResponseWriter, r *http.Reque
 	idString := vars["id"]
 	id, err := uuid.Parse(idString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+
+	versions, err := utils.IfMatchVersions(r, h.requireIfMatch)
+	if err != nil {
+		utils.JSONError(w, r, err)
 		return
 	}
 
-	err = h.categoryService.DeleteCategoryById(ctx, id)
+	err = h.categoryService.DeleteCategoryById(ctx, id, versions)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -321,7 +346,7 @@ func (h CategoryHandler) DeleteCategories(w http.ResponseWriter, r *http.Request
 	var idsString []string
 	err := json.NewDecoder(r.Body).Decode(&idsString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
 		return
 	}
 
@@ -329,7 +354,7 @@ func (h CategoryHandler) DeleteCategories(w http.ResponseWriter, r *http.Request
 	for _, idString := range idsString {
 		id, err := uuid.Parse(idString)
 		if err != nil {
-			utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+			utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 			return
 		}
 		ids = append(ids, id)
@@ -337,7 +362,7 @@ func (h CategoryHandler) DeleteCategories(w http.ResponseWriter, r *http.Request
 
 	err = h.categoryService.DeleteCategories(ctx, ids)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		utils.JSONError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -352,31 +377,53 @@ func (h CategoryHandler) UpdateCategoryFields(w http.ResponseWriter, r *http.Req
 	idString := vars["id"]
 	id, err := uuid.Parse(idString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 		return
 	}
 
-	var jsonBody map[string]any
-	err = json.NewDecoder(r.Body).Decode(&jsonBody)
+	versions, err := utils.IfMatchVersions(r, h.requireIfMatch)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+		utils.JSONError(w, r, err)
 		return
 	}
 
-	category, err := h.categoryService.UpdateCategoryFields(ctx, id, jsonBody)
-	if err != nil {
-		utils.JSONError(w, err)
-		return
+	var category entities.Category
+	// application/json-patch+json also starts with application/json
+	if strings.HasPrefix(r.Header.Get("Content-Type"), patch.MediaTypeJSONPatch) {
+		operations, err := patch.ReadJSONPatch(r.Body)
+		if err != nil {
+			utils.JSONError(w, r, entities.NewBadRequestError(err, err.Error(), op))
+			return
+		}
+		category, err = h.categoryService.PatchCategoryFields(ctx, id, operations, versions)
+		if err != nil {
+			utils.JSONError(w, r, err)
+			return
+		}
+	} else {
+		document, err := patch.ReadMergePatch(r.Body)
+		if err != nil {
+			utils.JSONError(w, r, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+			return
+		}
+
+		update, err := ParseCategoryMergePatch(document)
+		if err != nil {
+			utils.JSONError(w, r, err)
+			return
+		}
+
+		category, err = h.categoryService.UpdateCategoryFields(ctx, id, update, versions)
+		if err != nil {
+			utils.JSONError(w, r, err)
+			return
+		}
 	}
 
-	links := entities.NewHateoasBuilder().
-		AddBaseUrl(getBaseURL(r)).
-		AddGet("self", fmt.Sprintf(entities.CategoryGet, category.Id.String())).
-		AddDelete("delete", fmt.Sprintf(entities.CategoryDelete, category.Id.String())).
-		AddPatch("update", fmt.Sprintf(entities.CategoryUpdate, category.Id.String())).
-		Build()
+	links := categoryLinks(r, category)
 
-	utils.JSONResponse(w, category, links, http.StatusOK)
+	w.Header().Set("ETag", utils.VersionETag(category.Version))
+	utils.Respond(w, r, category, links, http.StatusOK)
 }
 
 func (h CategoryHandler) GetAllProductsByCategory(w http.ResponseWriter, r *http.Request) {
@@ -388,19 +435,29 @@ func (h CategoryHandler) GetAllProductsByCategory(w http.ResponseWriter, r *http
 	idString := vars["id"]
 	id, err := uuid.Parse(idString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 		return
 	}
 
+	descendants := false
+	if value := r.URL.Query().Get("descendants"); value != "" {
+		descendants, err = strconv.ParseBool(value)
+		if err != nil {
+			utils.JSONError(w, r, entities.NewBadRequestError(err, "o parâmetro descendants deve ser true ou false", op,
+				entities.FieldError{Field: "descendants", Message: "o parâmetro descendants deve ser true ou false"}))
+			return
+		}
+	}
+
 	_, err = h.categoryService.GetCategoryById(ctx, id)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 
-	products, err := h.categoryService.GetAllProductsByCategory(ctx, id)
+	products, err := h.categoryService.GetAllProductsByCategory(ctx, id, descendants)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 
@@ -419,33 +476,90 @@ func (h CategoryHandler) GetAllProductsByCategory(w http.ResponseWriter, r *http
 		resources[index] = resource
 	}
 
-	response := utils.Response{
-		Data: resources,
+	self := fmt.Sprintf(entities.CategoryProducts, id.String())
+	if descendants {
+		self += "?descendants=true"
 	}
+	links := entities.NewHateoasBuilder().
+		AddBaseUrl(getBaseURL(r)).
+		AddGet("self", self).
+		AddGet("category", fmt.Sprintf(entities.CategoryGet, id.String())).
+		Build()
+	utils.Respond(w, r, resources, links, http.StatusOK)
+}
 
-	//generate response with eTag
-	payload, err := json.Marshal(response)
+// GetCategoryTree returns every category nested under its parent, the root
+// categories first.
+func (h CategoryHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	tree, err := h.categoryService.GetCategoryTree(ctx)
 	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
+		utils.JSONError(w, r, err)
 		return
 	}
+	links := entities.NewHateoasBuilder().
+		AddBaseUrl(getBaseURL(r)).
+		AddGet("self", entities.CategoryTree).
+		Build()
+	utils.Respond(w, r, tree, links, http.StatusOK)
+}
+
+func (h CategoryHandler) GetCategoryChildren(w http.ResponseWriter, r *http.Request) {
+	h.getRelatedCategories(w, r, "CategoryHandler.GetCategoryChildren()", entities.CategoryChildren, h.categoryService.GetCategoryChildren)
+}
+
+func (h CategoryHandler) GetCategoryAncestors(w http.ResponseWriter, r *http.Request) {
+	h.getRelatedCategories(w, r, "CategoryHandler.GetCategoryAncestors()", entities.CategoryAncestors, h.categoryService.GetCategoryAncestors)
+}
 
-	eTag := utils.GenerateETag(payload)
+// getRelatedCategories answers with the categories get returns for the
+// category in the path, each with its own links.
+func (h CategoryHandler) getRelatedCategories(w http.ResponseWriter, r *http.Request, op string, route string, get func(ctx context.Context, id uuid.UUID) ([]entities.Category, error)) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
 
-	ifNoneMatch := strings.TrimPrefix(strings.Trim(r.Header.Get("If-None-Match"), "\""), "W/")
-	actualEtag := strings.TrimPrefix(eTag, "W/")
-	if ifNoneMatch == strings.Trim(actualEtag, "\"") {
-		w.Header().Set("ETag", eTag)
-		w.WriteHeader(http.StatusNotModified)
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("ETag", eTag)
-	w.WriteHeader(http.StatusOK)
-	_, err = fmt.Fprint(w, string(payload))
+	categories, err := get(ctx, id)
 	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
+		utils.JSONError(w, r, err)
 		return
 	}
+	resources := make([]entities.CategoryResource, len(categories))
+	for index, category := range categories {
+		resources[index] = entities.CategoryResource{
+			Category: category,
+			Links:    categoryLinks(r, category),
+		}
+	}
+	links := entities.NewHateoasBuilder().
+		AddBaseUrl(getBaseURL(r)).
+		AddGet("self", fmt.Sprintf(route, id.String())).
+		AddGet("category", fmt.Sprintf(entities.CategoryGet, id.String())).
+		Build()
+	utils.Respond(w, r, resources, links, http.StatusOK)
+}
+
+// categoryLinks links the category to its operations and to its place in the
+// tree.
+func categoryLinks(r *http.Request, category entities.Category) entities.Hateoas {
+	id := category.Id.String()
+	builder := entities.NewHateoasBuilder().
+		AddBaseUrl(getBaseURL(r)).
+		AddGet("self", fmt.Sprintf(entities.CategoryGet, id)).
+		AddDelete("delete", fmt.Sprintf(entities.CategoryDelete, id)).
+		AddPatch("update", fmt.Sprintf(entities.CategoryUpdate, id)).
+		AddGet("children", fmt.Sprintf(entities.CategoryChildren, id)).
+		AddGet("ancestors", fmt.Sprintf(entities.CategoryAncestors, id)).
+		AddGet("products", fmt.Sprintf(entities.CategoryProducts, id))
+	if category.ParentId != nil {
+		builder.AddGet("parent", fmt.Sprintf(entities.CategoryGet, category.ParentId.String()))
+	}
+	return builder.Build()
 }
@@ -0,0 +1,101 @@
+package category
+
+import (
+	"net/http"
+	"rest-api-example/entities"
+	"rest-api-example/openapi"
+	"rest-api-example/patch"
+	"rest-api-example/utils"
+)
+
+// Operations documents the routes of SetupCategoriesRoutes.
+func Operations() []openapi.Operation {
+	const tag = "Categorias"
+	categoryMeta := []any{entities.Hateoas{}}
+
+	listParameters := openapi.ListParameters(categorySchema)
+	listParameters = append(listParameters, openapi.RepresentationParameters(embedProducts)...)
+
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/categories", Tag: tag, Summary: "Lista as categorias",
+			Parameters:    listParameters,
+			Response:      []entities.CategoryResource{},
+			Meta:          []any{utils.PaginationMeta{}, utils.CursorMeta{}},
+			ResponseTypes: utils.ListMediaTypes,
+		},
+		{
+			Method: http.MethodGet, Path: "/categories/tree", Tag: tag, Summary: "Obtém a árvore de categorias",
+			Response:      []entities.CategoryNode{},
+			Meta:          categoryMeta,
+			ResponseTypes: utils.ItemMediaTypes,
+		},
+		{
+			Method: http.MethodGet, Path: "/categories/{id}", Tag: tag, Summary: "Obtém uma categoria",
+			Parameters:    append(openapi.RepresentationParameters(embedProducts), openapi.IfNoneMatch),
+			Response:      entities.Category{},
+			Meta:          categoryMeta,
+			ResponseTypes: utils.ItemMediaTypes,
+		},
+		{
+			Method: http.MethodGet, Path: "/categories/{id}/children", Tag: tag, Summary: "Lista as subcategorias diretas",
+			Response:      []entities.CategoryResource{},
+			Meta:          categoryMeta,
+			ResponseTypes: utils.ListMediaTypes,
+		},
+		{
+			Method: http.MethodGet, Path: "/categories/{id}/ancestors", Tag: tag, Summary: "Lista os ancestrais, da raiz até a categoria mãe",
+			Response:      []entities.CategoryResource{},
+			Meta:          categoryMeta,
+			ResponseTypes: utils.ListMediaTypes,
+		},
+		{
+			Method: http.MethodGet, Path: "/categories/{id}/products", Tag: tag, Summary: "Lista os produtos da categoria",
+			Parameters: []openapi.Parameter{
+				{Name: "descendants", Value: false, Description: "true inclui os produtos das subcategorias, em qualquer nível"},
+			},
+			Response:      []entities.ProductResource{},
+			Meta:          categoryMeta,
+			ResponseTypes: utils.ListMediaTypes,
+		},
+		{
+			Method: http.MethodPost, Path: "/categories/_get", Tag: tag, Summary: "Obtém as categorias dos ids informados",
+			Request:       []string{},
+			Response:      []entities.CategoryResource{},
+			Meta:          categoryMeta,
+			ResponseTypes: utils.ListMediaTypes,
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/categories", Tag: tag, Summary: "Cria uma categoria",
+			Permission: entities.PermissionCategoryCreate,
+			Request:    entities.Category{},
+			Status:     http.StatusCreated,
+			Response:   entities.Category{},
+			Meta:       categoryMeta,
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/categories/_delete", Tag: tag, Summary: "Exclui as categorias dos ids informados",
+			Permission: entities.PermissionCategoryDelete,
+			Request:    []string{},
+			Status:     http.StatusNoContent,
+		},
+		{
+			Method: http.MethodPatch, Path: "/admin/categories/{id}", Tag: tag, Summary: "Altera os campos informados da categoria",
+			Permission: entities.PermissionCategoryUpdate,
+			Parameters: []openapi.Parameter{openapi.IfMatch},
+			Request: openapi.Bodies{
+				utils.MediaTypeJSON:       entities.Category{},
+				patch.MediaTypeMergePatch: entities.Category{},
+				patch.MediaTypeJSONPatch:  []patch.Operation{},
+			},
+			Response: entities.Category{},
+			Meta:     categoryMeta,
+		},
+		{
+			Method: http.MethodDelete, Path: "/admin/categories/{id}", Tag: tag, Summary: "Exclui uma categoria",
+			Permission: entities.PermissionCategoryDelete,
+			Parameters: []openapi.Parameter{openapi.IfMatch},
+			Status:     http.StatusNoContent,
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package category
+
+import (
+	"encoding/json"
+	"rest-api-example/entities"
+	"rest-api-example/patch"
+)
+
+// categoryPatchFields is the allowlist of what a PATCH may change on a category.
+func categoryPatchFields(update *entities.CategoryFieldsUpdate) []patch.Field {
+	return []patch.Field{
+		{Name: "name", Apply: patch.String(&update.Name, 1, maxNameLength)},
+		{Name: "description", Apply: patch.String(&update.Description, 1, maxDescriptionLength)},
+		{Name: "active", Apply: patch.Bool(&update.Active)},
+		{Name: "parent_id", Nullable: true, Apply: patch.NullableUUID(&update.ParentId)},
+		{Name: "id", ReadOnly: true},
+		{Name: "created_at", ReadOnly: true},
+		{Name: "updated_at", ReadOnly: true},
+	}
+}
+
+func ParseCategoryMergePatch(document map[string]json.RawMessage) (entities.CategoryFieldsUpdate, error) {
+	op := "category.ParseCategoryMergePatch()"
+	var update entities.CategoryFieldsUpdate
+	fieldErrors := patch.ApplyMergePatch(document, categoryPatchFields(&update))
+	if len(fieldErrors) > 0 {
+		return entities.CategoryFieldsUpdate{}, entities.NewUnprocessableEntityError(patch.ErrInvalidFields, patch.ErrInvalidFields.Error(), op, fieldErrors...)
+	}
+	return update, nil
+}
+
+// categoryDocument is the JSON document JSON Patch operations are applied to.
+func categoryDocument(category entities.Category) (map[string]json.RawMessage, error) {
+	content, err := json.Marshal(category)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]json.RawMessage
+	err = json.Unmarshal(content, &document)
+	return document, err
+}
+
+func ParseCategoryJSONPatch(current entities.Category, operations []patch.Operation) (entities.CategoryFieldsUpdate, error) {
+	op := "category.ParseCategoryJSONPatch()"
+	document, err := categoryDocument(current)
+	if err != nil {
+		return entities.CategoryFieldsUpdate{}, entities.NewInternalServerErrorError(err, op)
+	}
+	changes, err := patch.ApplyJSONPatch(document, operations)
+	if err != nil {
+		return entities.CategoryFieldsUpdate{}, patch.ApplyError(err, op)
+	}
+	return ParseCategoryMergePatch(changes)
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"rest-api-example/entities"
-	"strconv"
+	"rest-api-example/listing"
+	"rest-api-example/utils"
+	"slices"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
@@ -24,20 +27,10 @@ func NewCategoryRepositoryPostgres(db *sql.DB) entities.CategoryInterface {
 func (r CategoryRepositoryPostgres) GetPaginateCategories(ctx context.Context, page int, limit int, params map[string][]string) ([]entities.Category, int, error) {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
-	countSql := psql.Select("COUNT(*)").
-		FromSelect(
-			psql.Select("id", "active").
-				From("categories"), "subquery",
-		)
-
-	if value, exists := params["active"]; exists {
-		isActive, err := strconv.Atoi(value[0])
-		if err != nil {
-			return nil, 0, err
-		}
-		countSql = countSql.Where("subquery.active = ?", isActive)
+	countSql, categoriesSql, list, err := categoryListSql(psql, params)
+	if err != nil {
+		return nil, 0, err
 	}
-
 	countQuery, countArgs, err := countSql.ToSql()
 	if err != nil {
 		return nil, 0, err
@@ -50,46 +43,58 @@ func (r CategoryRepositoryPostgres) GetPaginateCategories(ctx context.Context, p
 		return nil, 0, err
 	}
 
-	categoriesSql := psql.Select("id", "name", "description", "active", "created_at", "updated_at").From("categories")
-	if value, exists := params["active"]; exists {
-		isActive, err := strconv.Atoi(value[0])
-		if err != nil {
-			return nil, 0, err
-		}
-		categoriesSql = categoriesSql.Where("active = ?", isActive)
-	}
 	offset := (page - 1) * limit
-	categoriesSql = categoriesSql.Limit(uint64(limit)).Offset(uint64(offset))
-	query, args, err := categoriesSql.ToSql()
+	categoriesSql = categoriesSql.OrderBy(list.OrderBy()...).
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+	categories, err := r.getCategories(ctx, categoriesSql)
 	if err != nil {
 		return nil, 0, err
 	}
-	stmt, err := r.db.PrepareContext(ctx, query)
+	return categories, totalCount, nil
+}
+
+func (r CategoryRepositoryPostgres) GetCategoriesByCursor(ctx context.Context, params map[string][]string, cursor entities.Cursor) ([]entities.Category, int, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+	countSql, categoriesSql, list, err := categoryListSql(psql, params)
 	if err != nil {
 		return nil, 0, err
 	}
-	defer stmt.Close()
-	rows, err := stmt.QueryContext(ctx, args...)
+	seek, err := list.Seek(cursor)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	var categories []entities.Category
-	for rows.Next() {
-		var category = entities.Category{}
-		err = rows.Scan(&category.Id, &category.Name, &category.Description, &category.Active, &category.CreatedAt, &category.UpdatedAt)
+	totalCount := -1
+	if !cursor.SkipCount {
+		countQuery, countArgs, err := countSql.ToSql()
+		if err != nil {
+			return nil, 0, err
+		}
+		err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
 		if err != nil {
 			return nil, 0, err
 		}
-		categories = append(categories, category)
 	}
 
+	// one more row than the limit tells whether there is a next page
+	categoriesSql = categoriesSql.Where(seek).
+		OrderBy(list.CursorOrderBy(cursor)...).
+		Limit(uint64(cursor.Limit + 1))
+	categories, err := r.getCategories(ctx, categoriesSql)
+	if err != nil {
+		return nil, 0, err
+	}
+	if cursor.Backward {
+		slices.Reverse(categories)
+	}
 	return categories, totalCount, nil
 }
 
 func (r CategoryRepositoryPostgres) GetCategoryById(ctx context.Context, id uuid.UUID) (entities.Category, error) {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	categorySql := psql.Select("id", "name", "description", "active", "created_at", "updated_at").From("categories")
+	categorySql := psql.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").From("categories")
 	categorySql = categorySql.Where("id = ?", id)
 
 	query, args, err := categorySql.ToSql()
@@ -106,13 +111,13 @@ func (r CategoryRepositoryPostgres) GetCategoryById(ctx context.Context, id uuid
 		return entities.Category{}, nil
 	}
 	category := entities.Category{}
-	row.Scan(&category.Id, &category.Name, &category.Description, &category.Active, &category.CreatedAt, &category.UpdatedAt)
+	row.Scan(&category.Id, &category.Name, &category.Description, &category.Active, &category.CreatedAt, &category.UpdatedAt, &category.Version, &category.ParentId)
 	return category, err
 }
 
 func (r CategoryRepositoryPostgres) GetCategoriesByIds(ctx context.Context, ids []uuid.UUID) ([]entities.Category, error) {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	categorySql := psql.Select("id", "name", "description", "active", "created_at", "updated_at").From("categories")
+	categorySql := psql.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").From("categories")
 	categorySql = categorySql.Where(sq.Eq{"id": ids})
 
 	query, args, err := categorySql.ToSql()
@@ -131,7 +136,7 @@ func (r CategoryRepositoryPostgres) GetCategoriesByIds(ctx context.Context, ids
 	var categories []entities.Category
 	for rows.Next() {
 		var category entities.Category
-		rows.Scan(&category.Id, &category.Name, &category.Description, &category.Active, &category.CreatedAt, &category.UpdatedAt)
+		rows.Scan(&category.Id, &category.Name, &category.Description, &category.Active, &category.CreatedAt, &category.UpdatedAt, &category.Version, &category.ParentId)
 		categories = append(categories, category)
 	}
 	return categories, err
@@ -139,8 +144,8 @@ func (r CategoryRepositoryPostgres) GetCategoriesByIds(ctx context.Context, ids
 
 func (r CategoryRepositoryPostgres) CreateCategory(ctx context.Context, category entities.Category) (entities.Category, error) {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	categorySql := psql.Insert("categories").Columns("id", "name", "description", "active", "created_at", "updated_at")
-	categorySql = categorySql.Values(category.Id, category.Name, category.Description, category.Active, category.CreatedAt, category.UpdatedAt)
+	categorySql := psql.Insert("categories").Columns("id", "name", "description", "active", "parent_id", "created_at", "updated_at")
+	categorySql = categorySql.Values(category.Id, category.Name, category.Description, category.Active, category.ParentId, category.CreatedAt, category.UpdatedAt)
 
 	query, args, err := categorySql.ToSql()
 	if err != nil {
@@ -153,18 +158,21 @@ func (r CategoryRepositoryPostgres) CreateCategory(ctx context.Context, category
 	return category, nil
 }
 
-func (r CategoryRepositoryPostgres) DeleteCategoryById(ctx context.Context, id uuid.UUID) error {
+func (r CategoryRepositoryPostgres) DeleteCategoryById(ctx context.Context, id uuid.UUID, versions []int64) error {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 	deleteSql := psql.Delete("categories").Where("id = ?", id)
+	if len(versions) > 0 {
+		deleteSql = deleteSql.Where(sq.Eq{"version": versions})
+	}
 	query, args, err := deleteSql.ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = r.db.ExecContext(ctx, query, args...)
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
-	return nil
+	return utils.CheckVersionedWrite(result, versions)
 }
 
 func (r CategoryRepositoryPostgres) DeleteCategories(ctx context.Context, ids []uuid.UUID) error {
@@ -181,55 +189,303 @@ func (r CategoryRepositoryPostgres) DeleteCategories(ctx context.Context, ids []
 	return nil
 }
 
-func (r CategoryRepositoryPostgres) UpdateCategoryFields(ctx context.Context, id uuid.UUID, fields map[string]interface{}) (entities.Category, error) {
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	updateSql := psql.Update("categories")
-	for key, value := range fields {
-		updateSql = updateSql.Set(key, value)
+func (r CategoryRepositoryPostgres) UpdateCategoryFields(ctx context.Context, id uuid.UUID, update entities.CategoryFieldsUpdate, versions []int64) (entities.Category, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Category{}, err
 	}
-	updateSql = updateSql.Where("id = ?", id)
-	query, args, err := updateSql.ToSql()
+	defer tx.Rollback()
+
+	err = r.lockParent(ctx, tx, id, update.ParentId)
 	if err != nil {
 		return entities.Category{}, err
 	}
-	_, err = r.db.ExecContext(ctx, query, args...)
+	query, args, err := updateCategorySql(sq.StatementBuilder.PlaceholderFormat(sq.Dollar), id, update, versions)
 	if err != nil {
 		return entities.Category{}, err
 	}
-	category, err := r.GetCategoryById(ctx, id)
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
-		return entities.Category{}, nil
+		return entities.Category{}, err
 	}
-	return category, nil
+	err = utils.CheckVersionedWrite(result, versions)
+	if err != nil {
+		return entities.Category{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entities.Category{}, err
+	}
+	return r.GetCategoryById(ctx, id)
 }
 
-func (r CategoryRepositoryPostgres) GetAllProductsByCategory(ctx context.Context, id uuid.UUID) ([]entities.Product, error) {
+// PatchCategoryFields locks the row so that the update built from the current
+// state is applied before anyone else changes it.
+func (r CategoryRepositoryPostgres) PatchCategoryFields(ctx context.Context, id uuid.UUID, build func(current entities.Category) (entities.CategoryFieldsUpdate, error)) (entities.Category, error) {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	categorySql := psql.Select("p.id", "p.name", "p.description", "p.price", "p.active", "p.created_at", "p.updated_at").From("products_categories")
-	categorySql = categorySql.InnerJoin("products p on p.id = products_categories.product_id")
-	categorySql = categorySql.Where("category_id = ?", id)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	defer tx.Rollback()
 
-	query, args, err := categorySql.ToSql()
+	query, args, err := psql.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		From("categories").
+		Where("id = ?", id).
+		Suffix("FOR UPDATE").
+		ToSql()
 	if err != nil {
-		return nil, err
+		return entities.Category{}, err
 	}
-	stmt, err := r.db.PrepareContext(ctx, query)
+	current := entities.Category{}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&current.Id, &current.Name, &current.Description, &current.Active, &current.CreatedAt, &current.UpdatedAt, &current.Version, &current.ParentId)
+	if err == sql.ErrNoRows {
+		return entities.Category{}, nil
+	}
+	if err != nil {
+		return entities.Category{}, err
+	}
+
+	update, err := build(current)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	if !update.IsEmpty() {
+		err = r.lockParent(ctx, tx, id, update.ParentId)
+		if err != nil {
+			return entities.Category{}, err
+		}
+		query, args, err = updateCategorySql(psql, id, update, nil)
+		if err != nil {
+			return entities.Category{}, err
+		}
+		_, err = tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return entities.Category{}, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entities.Category{}, err
+	}
+	return r.GetCategoryById(ctx, id)
+}
+
+// lockParent walks up from the new parent of the category id and locks the
+// rows found, so no concurrent move can change the lineage it checks until the
+// transaction ends; two moves into each other's subtrees deadlock instead of
+// both passing. UNION ends the walk on the cycles of older data.
+func (r CategoryRepositoryPostgres) lockParent(ctx context.Context, tx *sql.Tx, id uuid.UUID, parentId *uuid.NullUUID) error {
+	if parentId == nil || !parentId.Valid {
+		return nil
+	}
+	rows, err := tx.QueryContext(ctx, `WITH RECURSIVE lineage AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id FROM categories c INNER JOIN lineage l ON c.id = l.parent_id
+		)
+		SELECT id FROM categories WHERE id IN (SELECT id FROM lineage) FOR UPDATE`, parentId.UUID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var lineage []uuid.UUID
+	for rows.Next() {
+		var ancestorId uuid.UUID
+		err = rows.Scan(&ancestorId)
+		if err != nil {
+			return err
+		}
+		lineage = append(lineage, ancestorId)
+	}
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+	return checkParent(id, lineage)
+}
+
+func (r CategoryRepositoryPostgres) GetAllProductsByCategory(ctx context.Context, id uuid.UUID, descendants bool) ([]entities.Product, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	productsSql := psql.Select("p.id", "p.name", "p.description", "p.price", "p.active", "p.created_at", "p.updated_at", "p.version").
+		From("products p").
+		OrderBy("p.created_at", "p.id")
+	if descendants {
+		productsSql = productsSql.
+			Prefix(`WITH RECURSIVE descendants AS (
+				SELECT id FROM categories WHERE id = ?
+				UNION
+				SELECT c.id FROM categories c INNER JOIN descendants d ON c.parent_id = d.id
+			)`, id).
+			Where("p.id IN (SELECT product_id FROM products_categories WHERE category_id IN (SELECT id FROM descendants))")
+	} else {
+		productsSql = productsSql.Where("p.id IN (SELECT product_id FROM products_categories WHERE category_id = ?)", id)
+	}
+
+	query, args, err := productsSql.ToSql()
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
-	rows, err := stmt.QueryContext(ctx, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
 	var products []entities.Product
 	for rows.Next() {
 		var product = entities.Product{}
-		err = rows.Scan(&product.Id, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt)
+		err = rows.Scan(&product.Id, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt, &product.Version)
 		if err != nil {
 			return nil, err
 		}
 		products = append(products, product)
 	}
-	return products, nil
+	return products, rows.Err()
+}
+
+func (r CategoryRepositoryPostgres) GetProductsByCategories(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]entities.Product, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Select("pc.category_id", "p.id", "p.name", "p.description", "p.price", "p.active", "p.created_at", "p.updated_at", "p.version").
+		From("products p").
+		Join("products_categories pc ON p.id = pc.product_id").
+		Where(sq.Eq{"pc.category_id": ids}).
+		OrderBy("p.created_at", "p.id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make(map[uuid.UUID][]entities.Product)
+	for rows.Next() {
+		var categoryId uuid.UUID
+		var product = entities.Product{}
+		err = rows.Scan(&categoryId, &product.Id, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt, &product.Version)
+		if err != nil {
+			return nil, err
+		}
+		products[categoryId] = append(products[categoryId], product)
+	}
+	return products, rows.Err()
+}
+
+func (r CategoryRepositoryPostgres) GetAllCategories(ctx context.Context) ([]entities.Category, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return r.getCategories(ctx, psql.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		From("categories").
+		OrderBy("created_at", "id"))
+}
+
+func (r CategoryRepositoryPostgres) GetCategoryChildren(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return r.getCategories(ctx, psql.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		From("categories").
+		Where("parent_id = ?", id).
+		OrderBy("created_at", "id"))
+}
+
+// GetCategoryAncestors walks up the parents with a recursive query; UNION
+// discards the rows already found, so a cycle ends the recursion.
+func (r CategoryRepositoryPostgres) GetCategoryAncestors(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	categories, err := r.getCategories(ctx, psql.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		Prefix(`WITH RECURSIVE ancestors AS (
+			SELECT id, name, description, active, created_at, updated_at, version, parent_id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id, c.name, c.description, c.active, c.created_at, c.updated_at, c.version, c.parent_id
+			FROM categories c INNER JOIN ancestors a ON c.id = a.parent_id
+		)`, id).
+		From("ancestors"))
+	if err != nil {
+		return nil, err
+	}
+	return entities.AncestorsOf(id, categories), nil
+}
+
+func (r CategoryRepositoryPostgres) getCategories(ctx context.Context, builder sq.SelectBuilder) ([]entities.Category, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []entities.Category
+	for rows.Next() {
+		var category entities.Category
+		err = rows.Scan(&category.Id, &category.Name, &category.Description, &category.Active, &category.CreatedAt, &category.UpdatedAt, &category.Version, &category.ParentId)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+// categoryListSql builds the count and the select of the list of categories
+// with the filters applied, neither ordered nor paged. It serves both
+// databases, through the placeholders of builder.
+func categoryListSql(builder sq.StatementBuilderType, params map[string][]string) (sq.SelectBuilder, sq.SelectBuilder, listing.Query, error) {
+	list, err := categorySchema.Parse(params)
+	if err != nil {
+		return sq.SelectBuilder{}, sq.SelectBuilder{}, listing.Query{}, err
+	}
+	countSql := builder.Select("COUNT(*)").From("categories").Where(list.Where())
+	categoriesSql := builder.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		From("categories").
+		Where(list.Where())
+	return countSql, categoriesSql, list, nil
+}
+
+// updateCategorySql bumps the version on every update and, when versions are
+// given, only matches the row at one of them.
+// checkParent tells whether the category id may move under the parent whose
+// lineage, the parent and its ancestors, was read within the update.
+func checkParent(id uuid.UUID, lineage []uuid.UUID) error {
+	if len(lineage) == 0 {
+		return entities.ErrParentNotFound
+	}
+	if slices.Contains(lineage, id) {
+		return entities.ErrParentCycle
+	}
+	return nil
+}
+
+func updateCategorySql(builder sq.StatementBuilderType, id any, update entities.CategoryFieldsUpdate, versions []int64) (string, []any, error) {
+	updateSql := builder.Update("categories").
+		Set("updated_at", time.Now().UTC()).
+		Set("version", sq.Expr("version + 1"))
+	if update.Name != nil {
+		updateSql = updateSql.Set("name", *update.Name)
+	}
+	if update.Description != nil {
+		updateSql = updateSql.Set("description", *update.Description)
+	}
+	if update.Active != nil {
+		updateSql = updateSql.Set("active", *update.Active)
+	}
+	if update.ParentId != nil {
+		// the text form is accepted by the UUID and UNIQUEIDENTIFIER columns alike
+		var parentId any
+		if update.ParentId.Valid {
+			parentId = update.ParentId.UUID.String()
+		}
+		updateSql = updateSql.Set("parent_id", parentId)
+	}
+	updateSql = updateSql.Where("id = ?", id)
+	if len(versions) > 0 {
+		updateSql = updateSql.Where(sq.Eq{"version": versions})
+	}
+	return updateSql.ToSql()
 }
@@ -0,0 +1,147 @@
+package category
+
+import (
+	"context"
+	"rest-api-example/cache"
+	"rest-api-example/entities"
+
+	"github.com/google/uuid"
+)
+
+// The keys of the reads are grouped by prefix, so a write invalidates the
+// lists it may change without knowing their parameters. cacheProduct holds
+// every read of the products repository decorator.
+const (
+	cacheItem     = "categories:item:"
+	cacheList     = "categories:list:"
+	cacheProducts = "categories:products:"
+	cacheProduct  = "products:"
+)
+
+type CategoryRepositoryCache struct {
+	repository entities.CategoryInterface
+	cache      *cache.Layer
+}
+
+// categoriesPage holds the results of the reads returning a total as well.
+type categoriesPage struct {
+	Categories []entities.Category
+	Total      int
+}
+
+// NewCategoryRepositoryCache serves the reads of repository from c until a
+// write through it succeeds.
+func NewCategoryRepositoryCache(repository entities.CategoryInterface, c *cache.Layer) entities.CategoryInterface {
+	return CategoryRepositoryCache{
+		repository: repository,
+		cache:      c,
+	}
+}
+
+func (r CategoryRepositoryCache) GetPaginateCategories(ctx context.Context, page int, limit int, params map[string][]string) ([]entities.Category, int, error) {
+	categories, err := cache.Load(ctx, r.cache, cache.Key(cacheList+"page:", page, limit, params), func() (categoriesPage, error) {
+		categories, total, err := r.repository.GetPaginateCategories(ctx, page, limit, params)
+		return categoriesPage{Categories: categories, Total: total}, err
+	})
+	return categories.Categories, categories.Total, err
+}
+
+func (r CategoryRepositoryCache) GetCategoriesByCursor(ctx context.Context, params map[string][]string, cursor entities.Cursor) ([]entities.Category, int, error) {
+	categories, err := cache.Load(ctx, r.cache, cache.Key(cacheList+"cursor:", params, cursor), func() (categoriesPage, error) {
+		categories, total, err := r.repository.GetCategoriesByCursor(ctx, params, cursor)
+		return categoriesPage{Categories: categories, Total: total}, err
+	})
+	return categories.Categories, categories.Total, err
+}
+
+func (r CategoryRepositoryCache) GetCategoryById(ctx context.Context, id uuid.UUID) (entities.Category, error) {
+	return cache.Load(ctx, r.cache, cacheItem+id.String(), func() (entities.Category, error) {
+		return r.repository.GetCategoryById(ctx, id)
+	})
+}
+
+func (r CategoryRepositoryCache) GetCategoriesByIds(ctx context.Context, ids []uuid.UUID) ([]entities.Category, error) {
+	return cache.Load(ctx, r.cache, cache.Key(cacheList+"ids:", ids), func() ([]entities.Category, error) {
+		return r.repository.GetCategoriesByIds(ctx, ids)
+	})
+}
+
+func (r CategoryRepositoryCache) GetAllProductsByCategory(ctx context.Context, id uuid.UUID, descendants bool) ([]entities.Product, error) {
+	return cache.Load(ctx, r.cache, cache.Key(cacheProducts+"category:", id, descendants), func() ([]entities.Product, error) {
+		return r.repository.GetAllProductsByCategory(ctx, id, descendants)
+	})
+}
+
+func (r CategoryRepositoryCache) GetProductsByCategories(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]entities.Product, error) {
+	return cache.Load(ctx, r.cache, cache.Key(cacheProducts+"categories:", ids), func() (map[uuid.UUID][]entities.Product, error) {
+		return r.repository.GetProductsByCategories(ctx, ids)
+	})
+}
+
+func (r CategoryRepositoryCache) GetAllCategories(ctx context.Context) ([]entities.Category, error) {
+	return cache.Load(ctx, r.cache, cacheList+"all", func() ([]entities.Category, error) {
+		return r.repository.GetAllCategories(ctx)
+	})
+}
+
+func (r CategoryRepositoryCache) GetCategoryChildren(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	return cache.Load(ctx, r.cache, cacheList+"children:"+id.String(), func() ([]entities.Category, error) {
+		return r.repository.GetCategoryChildren(ctx, id)
+	})
+}
+
+func (r CategoryRepositoryCache) GetCategoryAncestors(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	return cache.Load(ctx, r.cache, cacheList+"ancestors:"+id.String(), func() ([]entities.Category, error) {
+		return r.repository.GetCategoryAncestors(ctx, id)
+	})
+}
+
+func (r CategoryRepositoryCache) CreateCategory(ctx context.Context, category entities.Category) (entities.Category, error) {
+	created, err := r.repository.CreateCategory(ctx, category)
+	if err == nil {
+		r.cache.Invalidate(ctx, []string{cacheItem + created.Id.String()}, cacheList, cacheProducts)
+	}
+	return created, err
+}
+
+// UpdateCategoryFields, as PatchCategoryFields, leaves the product reads
+// alone: an update changes the fields of the category, never which products
+// belong to it.
+func (r CategoryRepositoryCache) UpdateCategoryFields(ctx context.Context, id uuid.UUID, update entities.CategoryFieldsUpdate, versions []int64) (entities.Category, error) {
+	category, err := r.repository.UpdateCategoryFields(ctx, id, update, versions)
+	if err == nil {
+		r.cache.Invalidate(ctx, []string{cacheItem + id.String()}, cacheList, cacheProducts)
+	}
+	return category, err
+}
+
+func (r CategoryRepositoryCache) PatchCategoryFields(ctx context.Context, id uuid.UUID, build func(current entities.Category) (entities.CategoryFieldsUpdate, error)) (entities.Category, error) {
+	category, err := r.repository.PatchCategoryFields(ctx, id, build)
+	if err == nil {
+		r.cache.Invalidate(ctx, []string{cacheItem + id.String()}, cacheList, cacheProducts)
+	}
+	return category, err
+}
+
+func (r CategoryRepositoryCache) DeleteCategoryById(ctx context.Context, id uuid.UUID, versions []int64) error {
+	err := r.repository.DeleteCategoryById(ctx, id, versions)
+	if err == nil {
+		r.invalidateDeleted(ctx)
+	}
+	return err
+}
+
+func (r CategoryRepositoryCache) DeleteCategories(ctx context.Context, ids []uuid.UUID) error {
+	err := r.repository.DeleteCategories(ctx, ids)
+	if err == nil {
+		r.invalidateDeleted(ctx)
+	}
+	return err
+}
+
+// invalidateDeleted drops every category, since the subcategories of the
+// deleted ones change too, and every product read, which lists the ids of
+// the categories.
+func (r CategoryRepositoryCache) invalidateDeleted(ctx context.Context) {
+	r.cache.Invalidate(ctx, nil, cacheItem, cacheList, cacheProducts, cacheProduct)
+}
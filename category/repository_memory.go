@@ -0,0 +1,349 @@
+package category
+
+import (
+	"context"
+	"fmt"
+	"rest-api-example/entities"
+	"rest-api-example/listing"
+	"rest-api-example/memory"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type CategoryRepositoryMemory struct {
+	store *memory.Store
+}
+
+func NewCategoryRepositoryMemory(store *memory.Store) entities.CategoryInterface {
+	return CategoryRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r CategoryRepositoryMemory) GetPaginateCategories(ctx context.Context, page int, limit int, params map[string][]string) ([]entities.Category, int, error) {
+	list, err := categorySchema.Parse(params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	categories := r.listCategories(list)
+	sort.Slice(categories, func(i, j int) bool {
+		return list.Compare(categoryValues(categories[i]), categoryValues(categories[j])) < 0
+	})
+	return memory.Page(categories, page, limit), len(categories), nil
+}
+
+func (r CategoryRepositoryMemory) GetCategoriesByCursor(ctx context.Context, params map[string][]string, cursor entities.Cursor) ([]entities.Category, int, error) {
+	list, err := categorySchema.Parse(params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	categories := r.listCategories(list)
+	window, err := listing.Window(list, categories, categoryValues, cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+	totalCount := len(categories)
+	if cursor.SkipCount {
+		totalCount = -1
+	}
+	return window, totalCount, nil
+}
+
+// listCategories returns the categories passing the filters, in no order.
+func (r CategoryRepositoryMemory) listCategories(list listing.Query) []entities.Category {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	var categories []entities.Category
+	for _, category := range r.store.Categories {
+		if list.Matches(categoryValues(category)) {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+func (r CategoryRepositoryMemory) GetCategoryById(ctx context.Context, id uuid.UUID) (entities.Category, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	return r.store.Categories[id], nil
+}
+
+func (r CategoryRepositoryMemory) GetCategoriesByIds(ctx context.Context, ids []uuid.UUID) ([]entities.Category, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	var categories []entities.Category
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		category, exists := r.store.Categories[id]
+		if !exists || seen[id] {
+			continue
+		}
+		seen[id] = true
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+func (r CategoryRepositoryMemory) CreateCategory(ctx context.Context, category entities.Category) (entities.Category, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	if _, exists := r.store.Categories[category.Id]; exists {
+		return entities.Category{}, fmt.Errorf("duplicate key value violates unique constraint: %s", category.Id)
+	}
+	// the DEFAULT now() of the tables
+	if category.CreatedAt == "" {
+		category.CreatedAt = memory.Now()
+		category.UpdatedAt = category.CreatedAt
+	}
+	r.store.Categories[category.Id] = category
+	return category, nil
+}
+
+func (r CategoryRepositoryMemory) DeleteCategoryById(ctx context.Context, id uuid.UUID, versions []int64) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	category, exists := r.store.Categories[id]
+	if exists && !entities.VersionMatches(versions, category.Version) {
+		return entities.ErrVersionMismatch
+	}
+	r.deleteCategories([]uuid.UUID{id})
+	return nil
+}
+
+func (r CategoryRepositoryMemory) DeleteCategories(ctx context.Context, ids []uuid.UUID) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	r.deleteCategories(ids)
+	return nil
+}
+
+// deleteCategories must be called with the store locked.
+func (r CategoryRepositoryMemory) deleteCategories(ids []uuid.UUID) {
+	deleted := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		delete(r.store.Categories, id)
+		deleted[id] = true
+	}
+	// same effect as the ON DELETE CASCADE of products_categories
+	for productId, categoriesId := range r.store.ProductsCategories {
+		var remaining []uuid.UUID
+		for _, categoryId := range categoriesId {
+			if !deleted[categoryId] {
+				remaining = append(remaining, categoryId)
+			}
+		}
+		r.store.ProductsCategories[productId] = remaining
+	}
+}
+
+func (r CategoryRepositoryMemory) UpdateCategoryFields(ctx context.Context, id uuid.UUID, update entities.CategoryFieldsUpdate, versions []int64) (entities.Category, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	category, exists := r.store.Categories[id]
+	if !exists {
+		return entities.Category{}, nil
+	}
+	if !entities.VersionMatches(versions, category.Version) {
+		return entities.Category{}, entities.ErrVersionMismatch
+	}
+	err := r.checkParent(id, update.ParentId)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	return r.updateCategory(category, update), nil
+}
+
+func (r CategoryRepositoryMemory) PatchCategoryFields(ctx context.Context, id uuid.UUID, build func(current entities.Category) (entities.CategoryFieldsUpdate, error)) (entities.Category, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	category, exists := r.store.Categories[id]
+	if !exists {
+		return entities.Category{}, nil
+	}
+	update, err := build(category)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	if update.IsEmpty() {
+		return category, nil
+	}
+	err = r.checkParent(id, update.ParentId)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	return r.updateCategory(category, update), nil
+}
+
+// checkParent must be called with the store locked, which keeps the lineage
+// of the new parent as checked until the update is stored.
+func (r CategoryRepositoryMemory) checkParent(id uuid.UUID, parentId *uuid.NullUUID) error {
+	if parentId == nil || !parentId.Valid {
+		return nil
+	}
+	var lineage []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for current, exists := r.store.Categories[parentId.UUID]; exists && !seen[current.Id]; {
+		seen[current.Id] = true
+		lineage = append(lineage, current.Id)
+		if current.ParentId == nil {
+			break
+		}
+		current, exists = r.store.Categories[*current.ParentId]
+	}
+	return checkParent(id, lineage)
+}
+
+// updateCategory must be called with the store locked.
+func (r CategoryRepositoryMemory) updateCategory(category entities.Category, update entities.CategoryFieldsUpdate) entities.Category {
+	if update.Name != nil {
+		category.Name = *update.Name
+	}
+	if update.Description != nil {
+		category.Description = *update.Description
+	}
+	if update.Active != nil {
+		category.Active = *update.Active
+	}
+	if update.ParentId != nil {
+		category.ParentId = nil
+		if update.ParentId.Valid {
+			parentId := update.ParentId.UUID
+			category.ParentId = &parentId
+		}
+	}
+	category.UpdatedAt = memory.Now()
+	category.Version++
+	r.store.Categories[category.Id] = category
+	return category
+}
+
+func (r CategoryRepositoryMemory) GetAllProductsByCategory(ctx context.Context, id uuid.UUID, descendants bool) ([]entities.Product, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	categoriesId := map[uuid.UUID]bool{id: true}
+	if descendants {
+		categoriesId = r.descendantsOf(id)
+	}
+
+	var products []entities.Product
+	for productId, productCategoriesId := range r.store.ProductsCategories {
+		for _, categoryId := range productCategoriesId {
+			if categoriesId[categoryId] {
+				products = append(products, r.store.Products[productId])
+				break
+			}
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].CreatedAt != products[j].CreatedAt {
+			return products[i].CreatedAt < products[j].CreatedAt
+		}
+		return products[i].Id.String() < products[j].Id.String()
+	})
+	return products, nil
+}
+
+func (r CategoryRepositoryMemory) GetProductsByCategories(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]entities.Product, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	wanted := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	products := make(map[uuid.UUID][]entities.Product)
+	for productId, productCategoriesId := range r.store.ProductsCategories {
+		for _, categoryId := range productCategoriesId {
+			if wanted[categoryId] {
+				products[categoryId] = append(products[categoryId], r.store.Products[productId])
+			}
+		}
+	}
+	for _, categoryProducts := range products {
+		sort.Slice(categoryProducts, func(i, j int) bool {
+			if categoryProducts[i].CreatedAt != categoryProducts[j].CreatedAt {
+				return categoryProducts[i].CreatedAt < categoryProducts[j].CreatedAt
+			}
+			return categoryProducts[i].Id.String() < categoryProducts[j].Id.String()
+		})
+	}
+	return products, nil
+}
+
+func (r CategoryRepositoryMemory) GetAllCategories(ctx context.Context) ([]entities.Category, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	var categories []entities.Category
+	for _, category := range r.store.Categories {
+		categories = append(categories, category)
+	}
+	sortCategories(categories)
+	return categories, nil
+}
+
+func (r CategoryRepositoryMemory) GetCategoryChildren(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	var categories []entities.Category
+	for _, category := range r.store.Categories {
+		if category.ParentId != nil && *category.ParentId == id {
+			categories = append(categories, category)
+		}
+	}
+	sortCategories(categories)
+	return categories, nil
+}
+
+func (r CategoryRepositoryMemory) GetCategoryAncestors(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	categories, err := r.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return entities.AncestorsOf(id, categories), nil
+}
+
+// descendantsOf returns id and the ids of all its subcategories; it must be
+// called with the store locked.
+func (r CategoryRepositoryMemory) descendantsOf(id uuid.UUID) map[uuid.UUID]bool {
+	children := make(map[uuid.UUID][]uuid.UUID)
+	for _, category := range r.store.Categories {
+		if category.ParentId != nil {
+			children[*category.ParentId] = append(children[*category.ParentId], category.Id)
+		}
+	}
+	descendants := map[uuid.UUID]bool{id: true}
+	pending := []uuid.UUID{id}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, child := range children[current] {
+			if !descendants[child] {
+				descendants[child] = true
+				pending = append(pending, child)
+			}
+		}
+	}
+	return descendants
+}
+
+func sortCategories(categories []entities.Category) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].CreatedAt != categories[j].CreatedAt {
+			return categories[i].CreatedAt < categories[j].CreatedAt
+		}
+		return categories[i].Id.String() < categories[j].Id.String()
+	})
+}
@@ -0,0 +1,444 @@
+package category
+
+import (
+	"context"
+	"database/sql"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+	"slices"
+
+	sq "github.com/Masterminds/squirrel"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/google/uuid"
+)
+
+type CategoryRepositorySqlServer struct {
+	db *sql.DB
+}
+
+func NewCategoryRepositorySqlServer(db *sql.DB) entities.CategoryInterface {
+	return CategoryRepositorySqlServer{
+		db: db,
+	}
+}
+
+func (r CategoryRepositorySqlServer) GetPaginateCategories(ctx context.Context, page int, limit int, params map[string][]string) ([]entities.Category, int, error) {
+	countSql, categoriesSql, list, err := categoryListSql(sq.StatementBuilder, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	countQuery, countArgs, err := countSql.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// SQL Server only pages with OFFSET/FETCH, which requires an ORDER BY
+	offset := (page - 1) * limit
+	categoriesSql = categoriesSql.OrderBy(list.OrderBy()...).
+		Suffix("OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", offset, limit)
+	categories, err := r.getCategories(ctx, categoriesSql)
+	if err != nil {
+		return nil, 0, err
+	}
+	return categories, totalCount, nil
+}
+
+func (r CategoryRepositorySqlServer) GetCategoriesByCursor(ctx context.Context, params map[string][]string, cursor entities.Cursor) ([]entities.Category, int, error) {
+	countSql, categoriesSql, list, err := categoryListSql(sq.StatementBuilder, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	seek, err := list.Seek(cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	totalCount := -1
+	if !cursor.SkipCount {
+		countQuery, countArgs, err := countSql.ToSql()
+		if err != nil {
+			return nil, 0, err
+		}
+		err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// one more row than the limit tells whether there is a next page
+	categoriesSql = categoriesSql.Where(seek).
+		OrderBy(list.CursorOrderBy(cursor)...).
+		Suffix("OFFSET 0 ROWS FETCH NEXT ? ROWS ONLY", cursor.Limit+1)
+	categories, err := r.getCategories(ctx, categoriesSql)
+	if err != nil {
+		return nil, 0, err
+	}
+	if cursor.Backward {
+		slices.Reverse(categories)
+	}
+	return categories, totalCount, nil
+}
+
+func (r CategoryRepositorySqlServer) GetCategoryById(ctx context.Context, id uuid.UUID) (entities.Category, error) {
+	categorySql := sq.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		From("categories").
+		Where("id = ?", id.String())
+	query, args, err := categorySql.ToSql()
+	if err != nil {
+		return entities.Category{}, err
+	}
+
+	category, err := scanCategorySqlServer(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return entities.Category{}, nil
+	}
+	return category, err
+}
+
+func (r CategoryRepositorySqlServer) GetCategoriesByIds(ctx context.Context, ids []uuid.UUID) ([]entities.Category, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	categorySql := sq.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		From("categories").
+		Where(sq.Eq{"id": utils.UUIDsToStrings(ids)})
+	query, args, err := categorySql.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []entities.Category
+	for rows.Next() {
+		category, err := scanCategorySqlServer(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (r CategoryRepositorySqlServer) CreateCategory(ctx context.Context, category entities.Category) (entities.Category, error) {
+	categorySql := sq.Insert("categories").
+		Columns("id", "name", "description", "active", "parent_id", "created_at", "updated_at").
+		Values(category.Id.String(), category.Name, category.Description, category.Active, nullableId(category.ParentId), category.CreatedAt, category.UpdatedAt)
+	query, args, err := categorySql.ToSql()
+	if err != nil {
+		return entities.Category{}, err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	return category, nil
+}
+
+func (r CategoryRepositorySqlServer) DeleteCategoryById(ctx context.Context, id uuid.UUID, versions []int64) error {
+	deleteSql := sq.Delete("categories").Where("id = ?", id.String())
+	if len(versions) > 0 {
+		deleteSql = deleteSql.Where(sq.Eq{"version": versions})
+	}
+	query, args, err := deleteSql.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return utils.CheckVersionedWrite(result, versions)
+}
+
+func (r CategoryRepositorySqlServer) DeleteCategories(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query, args, err := sq.Delete("categories").Where(sq.Eq{"id": utils.UUIDsToStrings(ids)}).ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r CategoryRepositorySqlServer) UpdateCategoryFields(ctx context.Context, id uuid.UUID, update entities.CategoryFieldsUpdate, versions []int64) (entities.Category, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	defer tx.Rollback()
+
+	err = r.lockParent(ctx, tx, id, update.ParentId)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	query, args, err := updateCategorySql(sq.StatementBuilder, id.String(), update, versions)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	err = utils.CheckVersionedWrite(result, versions)
+	if err != nil {
+		return entities.Category{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entities.Category{}, err
+	}
+	return r.GetCategoryById(ctx, id)
+}
+
+// PatchCategoryFields holds an update lock on the row so that the update built
+// from the current state is applied before anyone else changes it.
+func (r CategoryRepositorySqlServer) PatchCategoryFields(ctx context.Context, id uuid.UUID, build func(current entities.Category) (entities.CategoryFieldsUpdate, error)) (entities.Category, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := sq.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		From("categories WITH (UPDLOCK, ROWLOCK)").
+		Where("id = ?", id.String()).
+		ToSql()
+	if err != nil {
+		return entities.Category{}, err
+	}
+	current, err := scanCategorySqlServer(tx.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return entities.Category{}, nil
+	}
+	if err != nil {
+		return entities.Category{}, err
+	}
+
+	update, err := build(current)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	if !update.IsEmpty() {
+		err = r.lockParent(ctx, tx, id, update.ParentId)
+		if err != nil {
+			return entities.Category{}, err
+		}
+		query, args, err = updateCategorySql(sq.StatementBuilder, id.String(), update, nil)
+		if err != nil {
+			return entities.Category{}, err
+		}
+		_, err = tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return entities.Category{}, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entities.Category{}, err
+	}
+	return r.GetCategoryById(ctx, id)
+}
+
+// lockParent walks up from the new parent of the category id under update
+// locks, held until the transaction ends, so no concurrent move can change the
+// lineage it checks; two moves into each other's subtrees deadlock instead of
+// both passing. The category itself stops the walk, as UNION ALL would loop on
+// the cycle it is about to report.
+func (r CategoryRepositorySqlServer) lockParent(ctx context.Context, tx *sql.Tx, id uuid.UUID, parentId *uuid.NullUUID) error {
+	if parentId == nil || !parentId.Valid {
+		return nil
+	}
+	rows, err := tx.QueryContext(ctx, `WITH lineage AS (
+			SELECT id, parent_id FROM categories WITH (UPDLOCK, ROWLOCK) WHERE id = ?
+			UNION ALL
+			SELECT c.id, c.parent_id FROM categories c WITH (UPDLOCK, ROWLOCK)
+			INNER JOIN lineage l ON c.id = l.parent_id
+			WHERE l.id <> ?
+		)
+		SELECT id FROM lineage`, parentId.UUID.String(), id.String())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var lineage []uuid.UUID
+	for rows.Next() {
+		var ancestorId mssql.UniqueIdentifier
+		err = rows.Scan(&ancestorId)
+		if err != nil {
+			return err
+		}
+		lineage = append(lineage, uuid.UUID(ancestorId))
+	}
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+	return checkParent(id, lineage)
+}
+
+func (r CategoryRepositorySqlServer) GetAllProductsByCategory(ctx context.Context, id uuid.UUID, descendants bool) ([]entities.Product, error) {
+	productsSql := sq.Select("p.id", "p.name", "p.description", "p.price", "p.active", "p.created_at", "p.updated_at", "p.version").
+		From("products p").
+		OrderBy("p.created_at", "p.id")
+	if descendants {
+		productsSql = productsSql.
+			Prefix(`WITH descendants AS (
+				SELECT id FROM categories WHERE id = ?
+				UNION ALL
+				SELECT c.id FROM categories c INNER JOIN descendants d ON c.parent_id = d.id
+			)`, id.String()).
+			Where("p.id IN (SELECT product_id FROM products_categories WHERE category_id IN (SELECT id FROM descendants))")
+	} else {
+		productsSql = productsSql.Where("p.id IN (SELECT product_id FROM products_categories WHERE category_id = ?)", id.String())
+	}
+
+	query, args, err := productsSql.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []entities.Product
+	for rows.Next() {
+		var productId mssql.UniqueIdentifier
+		var product = entities.Product{}
+		err = rows.Scan(&productId, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt, &product.Version)
+		if err != nil {
+			return nil, err
+		}
+		product.Id = uuid.UUID(productId)
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+func (r CategoryRepositorySqlServer) GetProductsByCategories(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]entities.Product, error) {
+	categoriesId := make([]string, len(ids))
+	for index, id := range ids {
+		categoriesId[index] = id.String()
+	}
+	query, args, err := sq.Select("pc.category_id", "p.id", "p.name", "p.description", "p.price", "p.active", "p.created_at", "p.updated_at", "p.version").
+		From("products p").
+		Join("products_categories pc ON p.id = pc.product_id").
+		Where(sq.Eq{"pc.category_id": categoriesId}).
+		OrderBy("p.created_at", "p.id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make(map[uuid.UUID][]entities.Product)
+	for rows.Next() {
+		var categoryId, productId mssql.UniqueIdentifier
+		var product = entities.Product{}
+		err = rows.Scan(&categoryId, &productId, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt, &product.Version)
+		if err != nil {
+			return nil, err
+		}
+		product.Id = uuid.UUID(productId)
+		products[uuid.UUID(categoryId)] = append(products[uuid.UUID(categoryId)], product)
+	}
+	return products, rows.Err()
+}
+
+func (r CategoryRepositorySqlServer) GetAllCategories(ctx context.Context) ([]entities.Category, error) {
+	return r.getCategories(ctx, sq.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		From("categories").
+		OrderBy("created_at", "id"))
+}
+
+func (r CategoryRepositorySqlServer) GetCategoryChildren(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	return r.getCategories(ctx, sq.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		From("categories").
+		Where("parent_id = ?", id.String()).
+		OrderBy("created_at", "id"))
+}
+
+// GetCategoryAncestors walks up the parents with a recursive query. SQL Server
+// only allows UNION ALL there, so a cycle fails at the default MAXRECURSION
+// of 100 instead of looping.
+func (r CategoryRepositorySqlServer) GetCategoryAncestors(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	categories, err := r.getCategories(ctx, sq.Select("id", "name", "description", "active", "created_at", "updated_at", "version", "parent_id").
+		Prefix(`WITH ancestors AS (
+			SELECT id, name, description, active, created_at, updated_at, version, parent_id FROM categories WHERE id = ?
+			UNION ALL
+			SELECT c.id, c.name, c.description, c.active, c.created_at, c.updated_at, c.version, c.parent_id
+			FROM categories c INNER JOIN ancestors a ON c.id = a.parent_id
+		)`, id.String()).
+		From("ancestors"))
+	if err != nil {
+		return nil, err
+	}
+	return entities.AncestorsOf(id, categories), nil
+}
+
+func (r CategoryRepositorySqlServer) getCategories(ctx context.Context, builder sq.SelectBuilder) ([]entities.Category, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []entities.Category
+	for rows.Next() {
+		category, err := scanCategorySqlServer(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func nullableId(id *uuid.UUID) any {
+	if id == nil {
+		return nil
+	}
+	return id.String()
+}
+
+// scanCategorySqlServer reads the UNIQUEIDENTIFIER through the driver type,
+// since SQL Server stores it with a different byte order than uuid.UUID.
+func scanCategorySqlServer(row utils.RowScanner) (entities.Category, error) {
+	var id mssql.UniqueIdentifier
+	var parentId *mssql.UniqueIdentifier
+	category := entities.Category{}
+	err := row.Scan(&id, &category.Name, &category.Description, &category.Active, &category.CreatedAt, &category.UpdatedAt, &category.Version, &parentId)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	category.Id = uuid.UUID(id)
+	if parentId != nil {
+		parent := uuid.UUID(*parentId)
+		category.ParentId = &parent
+	}
+	return category, nil
+}
@@ -3,7 +3,10 @@ package category
 import (
 	"net/http"
 	"rest-api-example/auth"
+	"rest-api-example/entities"
 	"rest-api-example/middlewares"
+	"rest-api-example/patch"
+	"rest-api-example/utils"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -18,17 +21,22 @@ func SetupCategoriesRoutes(mux *mux.Router, h CategoryHandler, authService auth.
 	}).Handler)
 	admin.Use(authService.AuthenticationMiddleware)
 	admin.HandleFunc("",
-		middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
-			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.CreateCategory))).Methods(http.MethodOptions,
+		authService.RequirePermission(entities.PermissionCategoryCreate,
+			middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
+				middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.CreateCategory)))).Methods(http.MethodOptions,
 		http.MethodPost)
 	admin.HandleFunc("/_delete",
-		middlewares.ValidateSupportedMediaTypes([]string{"application/json"}, h.DeleteCategories)).Methods(http.MethodOptions,
+		authService.RequirePermission(entities.PermissionCategoryDelete,
+			middlewares.ValidateSupportedMediaTypes([]string{"application/json"}, h.DeleteCategories))).Methods(http.MethodOptions,
 		http.MethodPost)
 	admin.HandleFunc("/{id}",
-		middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
-			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.UpdateCategoryFields))).Methods(http.MethodOptions,
+		authService.RequirePermission(entities.PermissionCategoryUpdate,
+			middlewares.ValidateSupportedMediaTypes([]string{"application/json", patch.MediaTypeMergePatch, patch.MediaTypeJSONPatch},
+				middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.UpdateCategoryFields)))).Methods(http.MethodOptions,
 		http.MethodPatch)
-	admin.HandleFunc("/{id}", h.DeleteCategoryById).Methods(http.MethodOptions, http.MethodDelete)
+	admin.HandleFunc("/{id}",
+		authService.RequirePermission(entities.PermissionCategoryDelete, h.DeleteCategoryById)).Methods(http.MethodOptions,
+		http.MethodDelete)
 
 	r := mux.PathPrefix("/categories").Subrouter()
 	r.Use(cors.New(cors.Options{
@@ -36,17 +44,27 @@ func SetupCategoriesRoutes(mux *mux.Router, h CategoryHandler, authService auth.
 		AllowedMethods: []string{"POST", "GET", "OPTIONS"},
 		AllowedHeaders: []string{"Authorization", "Content-Type"},
 	}).Handler)
-	r.HandleFunc("", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+	r.HandleFunc("", middlewares.ValidadeAcceptHeader(utils.ListMediaTypes,
 		h.GetPaginateCategories)).Methods(http.MethodOptions, http.MethodGet)
 
-	r.HandleFunc("/{id}", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+	// registered before /{id}, which would match it too
+	r.HandleFunc("/tree", middlewares.ValidadeAcceptHeader(utils.ItemMediaTypes,
+		h.GetCategoryTree)).Methods(http.MethodOptions, http.MethodGet)
+
+	r.HandleFunc("/{id}", middlewares.ValidadeAcceptHeader(utils.ItemMediaTypes,
 		h.GetCategoryById)).Methods(http.MethodOptions, http.MethodGet)
 
-	r.HandleFunc("/{id}/products", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+	r.HandleFunc("/{id}/children", middlewares.ValidadeAcceptHeader(utils.ListMediaTypes,
+		h.GetCategoryChildren)).Methods(http.MethodOptions, http.MethodGet)
+
+	r.HandleFunc("/{id}/ancestors", middlewares.ValidadeAcceptHeader(utils.ListMediaTypes,
+		h.GetCategoryAncestors)).Methods(http.MethodOptions, http.MethodGet)
+
+	r.HandleFunc("/{id}/products", middlewares.ValidadeAcceptHeader(utils.ListMediaTypes,
 		h.GetAllProductsByCategory)).Methods(http.MethodOptions, http.MethodGet)
 
 	r.HandleFunc("/_get",
 		middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
-			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.GetCategoriesByIds))).Methods(http.MethodOptions,
+			middlewares.ValidadeAcceptHeader(utils.ListMediaTypes, h.GetCategoriesByIds))).Methods(http.MethodOptions,
 		http.MethodPost)
 }
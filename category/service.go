@@ -5,15 +5,18 @@ import (
 	"errors"
 	"log"
 	"rest-api-example/entities"
+	"rest-api-example/patch"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrCategoriaJaCadastrada         = errors.New("categoria já cadastrada")
-	ErrCategoriaNaoCadastrada        = errors.New("categoria não cadastrada")
-	ErrNomeCategoriaObrigatorio      = errors.New("nome da categoria deve ser informado")
-	ErrDescricaoCategoriaObrigatorio = errors.New("descrição da categoria deve ser informada")
+	ErrCategoriaJaCadastrada        = errors.New("categoria já cadastrada")
+	ErrCategoriaNaoCadastrada       = errors.New("categoria não cadastrada")
+	ErrCategoriaAlterada            = errors.New("categoria alterada por outra requisição, obtenha o ETag atual e tente novamente")
+	ErrCategoriaPaiNaoCadastrada    = errors.New("categoria pai não cadastrada")
+	ErrCategoriaCiclica             = errors.New("a categoria não pode ser subcategoria dela mesma ou de uma de suas subcategorias")
+	ErrCategoriaPossuiSubcategorias = errors.New("a categoria possui subcategorias, mova ou remova as subcategorias antes")
 )
 
 type CategoryService struct {
@@ -35,6 +38,28 @@ func (s CategoryService) GetAllCategories(ctx context.Context, page int, limit i
 	return categories, totalCount, nil
 }
 
+// GetCategoriesByCursor returns the page of categories of the cursor, with
+// the extra one GetCategoriesByCursor of the repository reads past the limit.
+func (s CategoryService) GetCategoriesByCursor(ctx context.Context, params map[string][]string, cursor entities.Cursor) ([]entities.Category, int, error) {
+	op := "CategoryService.GetCategoriesByCursor()"
+	categories, totalCount, err := s.categoryRepository.GetCategoriesByCursor(ctx, params, cursor)
+	if err != nil {
+		return nil, 0, entities.NewInternalServerErrorError(err, op)
+	}
+	return categories, totalCount, nil
+}
+
+// GetProductsByCategories returns the products of each of the categories
+// with a single query.
+func (s CategoryService) GetProductsByCategories(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]entities.Product, error) {
+	op := "CategoryService.GetProductsByCategories()"
+	products, err := s.categoryRepository.GetProductsByCategories(ctx, ids)
+	if err != nil {
+		return nil, entities.NewInternalServerErrorError(err, op)
+	}
+	return products, nil
+}
+
 func (s CategoryService) GetCategoryById(ctx context.Context, id uuid.UUID) (entities.Category, error) {
 	op := "CategoryService.GetCategoryById()"
 	category, err := s.categoryRepository.GetCategoryById(ctx, id)
@@ -48,59 +73,201 @@ func (s CategoryService) GetCategoryById(ctx context.Context, id uuid.UUID) (ent
 }
 
 func (s CategoryService) GetCategoriesByIds(ctx context.Context, ids []uuid.UUID) ([]entities.Category, error) {
+	op := "CategoryService.GetCategoriesByIds()"
 	categories, err := s.categoryRepository.GetCategoriesByIds(ctx, ids)
 	if err != nil {
-		return nil, err
+		return nil, entities.NewInternalServerErrorError(err, op)
 	}
 	return categories, nil
 }
 
 func (s CategoryService) CreateCategory(ctx context.Context, category entities.Category) (entities.Category, error) {
-
-	if category.Name == "" {
-		return entities.Category{}, ErrNomeCategoriaObrigatorio
+	op := "CategoryService.CreateCategory()"
+	validator := validateCategory(category)
+	if category.ParentId != nil && !validator.Failed("parent_id") {
+		parent, err := s.categoryRepository.GetCategoryById(ctx, *category.ParentId)
+		if err != nil {
+			return entities.Category{}, entities.NewInternalServerErrorError(err, op)
+		}
+		if parent.IsEmpty() {
+			validator.Add("parent_id", ErrCategoriaPaiNaoCadastrada.Error())
+		}
 	}
-	if category.Description == "" {
-		return entities.Category{}, ErrDescricaoCategoriaObrigatorio
+	err := validator.Error(op)
+	if err != nil {
+		return entities.Category{}, err
 	}
 	category.Id = uuid.New()
-	category, err := s.categoryRepository.CreateCategory(ctx, category)
+	category.Version = 1
+	category, err = s.categoryRepository.CreateCategory(ctx, category)
 	if err != nil {
-		return entities.Category{}, err
+		return entities.Category{}, entities.NewInternalServerErrorError(err, op)
 	}
 	return category, nil
 }
 
-func (s CategoryService) DeleteCategoryById(ctx context.Context, id uuid.UUID) error {
-	category, err := s.categoryRepository.GetCategoryById(ctx, id)
+// DeleteCategoryById only deletes the category at one of the given versions,
+// when there are any.
+func (s CategoryService) DeleteCategoryById(ctx context.Context, id uuid.UUID, versions []int64) error {
+	op := "CategoryService.DeleteCategoryById()"
+	_, err := s.GetCategoryById(ctx, id)
 	if err != nil {
 		return err
 	}
-	if category.IsEmpty() {
-		return ErrCategoriaNaoCadastrada
+	children, err := s.categoryRepository.GetCategoryChildren(ctx, id)
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	if len(children) > 0 {
+		return entities.NewConflictError(ErrCategoriaPossuiSubcategorias, ErrCategoriaPossuiSubcategorias.Error(), op)
 	}
-	err = s.categoryRepository.DeleteCategoryById(ctx, id)
-	return err
+	err = s.categoryRepository.DeleteCategoryById(ctx, id, versions)
+	if errors.Is(err, entities.ErrVersionMismatch) {
+		return entities.NewPreconditionFailedError(err, ErrCategoriaAlterada.Error(), op)
+	}
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	return nil
 }
 
+// DeleteCategories refuses to delete a category whose subcategories are not
+// deleted along with it.
 func (s CategoryService) DeleteCategories(ctx context.Context, ids []uuid.UUID) error {
-	err := s.categoryRepository.DeleteCategories(ctx, ids)
-	return err
+	op := "CategoryService.DeleteCategories()"
+	categories, err := s.categoryRepository.GetAllCategories(ctx)
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	deleted := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	for _, category := range categories {
+		if category.ParentId != nil && deleted[*category.ParentId] && !deleted[category.Id] {
+			return entities.NewConflictError(ErrCategoriaPossuiSubcategorias, ErrCategoriaPossuiSubcategorias.Error(), op)
+		}
+	}
+	err = s.categoryRepository.DeleteCategories(ctx, ids)
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	return nil
 }
 
-func (s CategoryService) UpdateCategoryFields(ctx context.Context, id uuid.UUID, fields map[string]interface{}) (entities.Category, error) {
-	category, err := s.categoryRepository.UpdateCategoryFields(ctx, id, fields)
+// UpdateCategoryFields only updates the category at one of the given
+// versions, when there are any; the check is part of the UPDATE itself.
+func (s CategoryService) UpdateCategoryFields(ctx context.Context, id uuid.UUID, update entities.CategoryFieldsUpdate, versions []int64) (entities.Category, error) {
+	op := "CategoryService.UpdateCategoryFields()"
+	category, err := s.GetCategoryById(ctx, id)
 	if err != nil {
-		log.Println(err)
 		return entities.Category{}, err
 	}
+	if !entities.VersionMatches(versions, category.Version) {
+		return entities.Category{}, entities.NewPreconditionFailedError(entities.ErrVersionMismatch, ErrCategoriaAlterada.Error(), op)
+	}
+	if update.IsEmpty() {
+		return category, nil
+	}
+	category, err = s.categoryRepository.UpdateCategoryFields(ctx, id, update, versions)
+	if errors.Is(err, entities.ErrVersionMismatch) {
+		return entities.Category{}, entities.NewPreconditionFailedError(err, ErrCategoriaAlterada.Error(), op)
+	}
+	if parentErr := parentError(err, op); parentErr != nil {
+		return entities.Category{}, parentErr
+	}
+	if err != nil {
+		log.Println(err)
+		return entities.Category{}, entities.NewInternalServerErrorError(err, op)
+	}
 	return category, nil
 }
 
-func (s CategoryService) GetAllProductsByCategory(ctx context.Context, id uuid.UUID) ([]entities.Product, error) {
-	products, err := s.categoryRepository.GetAllProductsByCategory(ctx, id)
+// PatchCategoryFields applies a JSON Patch to the current state of the
+// category, within the same transaction that stores the result.
+func (s CategoryService) PatchCategoryFields(ctx context.Context, id uuid.UUID, operations []patch.Operation, versions []int64) (entities.Category, error) {
+	op := "CategoryService.PatchCategoryFields()"
+	category, err := s.categoryRepository.PatchCategoryFields(ctx, id, func(current entities.Category) (entities.CategoryFieldsUpdate, error) {
+		if !entities.VersionMatches(versions, current.Version) {
+			return entities.CategoryFieldsUpdate{}, entities.NewPreconditionFailedError(entities.ErrVersionMismatch, ErrCategoriaAlterada.Error(), op)
+		}
+		return ParseCategoryJSONPatch(current, operations)
+	})
+	if parentErr := parentError(err, op); parentErr != nil {
+		return entities.Category{}, parentErr
+	}
+	if err != nil {
+		var apiError *entities.Error
+		if errors.As(err, &apiError) {
+			return entities.Category{}, err
+		}
+		log.Println(err)
+		return entities.Category{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if category.IsEmpty() {
+		return entities.Category{}, entities.NewNotFoundError(ErrCategoriaNaoCadastrada, ErrCategoriaNaoCadastrada.Error(), op)
+	}
+	return category, nil
+}
+
+func (s CategoryService) GetAllProductsByCategory(ctx context.Context, id uuid.UUID, descendants bool) ([]entities.Product, error) {
+	products, err := s.categoryRepository.GetAllProductsByCategory(ctx, id, descendants)
 	if err != nil {
 		return nil, err
 	}
 	return products, nil
 }
+
+func (s CategoryService) GetCategoryTree(ctx context.Context) ([]entities.CategoryNode, error) {
+	op := "CategoryService.GetCategoryTree()"
+	categories, err := s.categoryRepository.GetAllCategories(ctx)
+	if err != nil {
+		return nil, entities.NewInternalServerErrorError(err, op)
+	}
+	return entities.NewCategoryTree(categories), nil
+}
+
+func (s CategoryService) GetCategoryChildren(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	op := "CategoryService.GetCategoryChildren()"
+	_, err := s.GetCategoryById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	children, err := s.categoryRepository.GetCategoryChildren(ctx, id)
+	if err != nil {
+		return nil, entities.NewInternalServerErrorError(err, op)
+	}
+	return children, nil
+}
+
+// GetCategoryAncestors lists the ancestors of the category from the root down
+// to its parent, empty for a root category.
+func (s CategoryService) GetCategoryAncestors(ctx context.Context, id uuid.UUID) ([]entities.Category, error) {
+	op := "CategoryService.GetCategoryAncestors()"
+	_, err := s.GetCategoryById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := s.categoryRepository.GetCategoryAncestors(ctx, id)
+	if err != nil {
+		return nil, entities.NewInternalServerErrorError(err, op)
+	}
+	return ancestors, nil
+}
+
+// parentError turns the errors of the parent check, which the repositories
+// run within the transaction of the update, into the answers for the
+// parent_id field; it is nil for any other error.
+func parentError(err error, op string) error {
+	var message error
+	switch {
+	case errors.Is(err, entities.ErrParentNotFound):
+		message = ErrCategoriaPaiNaoCadastrada
+	case errors.Is(err, entities.ErrParentCycle):
+		message = ErrCategoriaCiclica
+	default:
+		return nil
+	}
+	return entities.NewUnprocessableEntityError(err, message.Error(), op,
+		entities.FieldError{Field: "parent_id", Message: message.Error()})
+}
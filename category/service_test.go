@@ -0,0 +1,69 @@
+package category
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+	"rest-api-example/patch"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCategoryServiceMoveParent(t *testing.T) {
+	ctx := context.Background()
+	service := NewCategoryService(NewCategoryRepositoryMemory(memory.NewStore()))
+	root, err := service.CreateCategory(ctx, entities.Category{Name: "Casa", Description: "Casa", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := service.CreateCategory(ctx, entities.Category{Name: "Cozinha", Description: "Cozinha", Active: true, ParentId: &root.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grandchild, err := service.CreateCategory(ctx, entities.Category{Name: "Panelas", Description: "Panelas", Active: true, ParentId: &child.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		id     uuid.UUID
+		parent uuid.UUID
+		// err is nil for the moves that are accepted
+		err error
+	}{
+		{name: "under itself", id: root.Id, parent: root.Id, err: ErrCategoriaCiclica},
+		{name: "under a descendant", id: root.Id, parent: grandchild.Id, err: ErrCategoriaCiclica},
+		{name: "under a missing category", id: child.Id, parent: uuid.New(), err: ErrCategoriaPaiNaoCadastrada},
+		{name: "under an ancestor", id: grandchild.Id, parent: root.Id},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parent := &uuid.NullUUID{UUID: test.parent, Valid: true}
+			_, err := service.UpdateCategoryFields(ctx, test.id, entities.CategoryFieldsUpdate{ParentId: parent}, nil)
+			checkParentError(t, "UpdateCategoryFields()", err, test.err)
+
+			value, _ := json.Marshal(test.parent)
+			operations := []patch.Operation{{Op: "replace", Path: "/parent_id", Value: value}}
+			_, err = service.PatchCategoryFields(ctx, test.id, operations, nil)
+			checkParentError(t, "PatchCategoryFields()", err, test.err)
+		})
+	}
+}
+
+func checkParentError(t *testing.T, method string, err error, expected error) {
+	t.Helper()
+	if expected == nil {
+		if err != nil {
+			t.Errorf("%s error = %v, want nil", method, err)
+		}
+		return
+	}
+	var apiError *entities.Error
+	if !errors.As(err, &apiError) || apiError.Code != entities.UNPROCESSABLE_ENTITY || apiError.Message != expected.Error() {
+		t.Errorf("%s error = %v, want a 422 %q", method, err, expected)
+	}
+}
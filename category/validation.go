@@ -0,0 +1,21 @@
+package category
+
+import (
+	"rest-api-example/entities"
+	"rest-api-example/validation"
+)
+
+// the limits of the fields, shared by validateCategory and categoryPatchFields
+const (
+	maxNameLength        = 255
+	maxDescriptionLength = 5000
+)
+
+// validateCategory checks the fields of a new category.
+func validateCategory(category entities.Category) *validation.Validator {
+	validator := validation.New()
+	validation.Field(validator, "name", category.Name, validation.Required[string](), validation.Length(1, maxNameLength))
+	validation.Field(validator, "description", category.Description, validation.Required[string](), validation.Length(1, maxDescriptionLength))
+	validation.Field(validator, "parent_id", category.ParentId, validation.Optional(validation.UUID()))
+	return validator
+}
@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+// CacheConfig sets the caching headers of the GET responses. CacheControl
+// defaults to "private, no-cache", which lets clients keep the responses as
+// long as they revalidate them with the ETag before every use. WeakETags
+// marks the ETags computed from the body as weak.
+type CacheConfig struct {
+	CacheControl string            `toml:"cacheControl"`
+	WeakETags    bool              `toml:"weakETags"`
+	Server       ServerCacheConfig `toml:"server"`
+}
+
+// ServerCacheConfig sizes the cache of the category and product reads kept by
+// the service itself, enabled unless Disabled is set. Entries live for TTL at
+// most, 30 seconds by default, and the least recently used are evicted beyond
+// MaxEntries (1000) or MaxBytes (32 MiB).
+type ServerCacheConfig struct {
+	Disabled   bool          `toml:"disabled"`
+	TTL        time.Duration `toml:"ttl"`
+	MaxEntries int           `toml:"maxEntries"`
+	MaxBytes   int           `toml:"maxBytes"`
+}
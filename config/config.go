@@ -3,6 +3,7 @@ package config
 import (
 	"io"
 	"os"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -11,9 +12,14 @@ type Config struct {
 	Port                   int    `toml:"Port"`
 	Logs                   string `toml:"logs"`
 	BaseUrl                string `toml:"baseUrl"`
+	Driver                 string `toml:"driver"`
+	RequireIfMatch         bool   `toml:"requireIfMatch"`
 	SqlServerDatabase      SqlServerDBConfig
 	PostgresServerDatabase PostgresSqlDBConfig
 	ServiceSettings        ServiceSettings
+	Jwt                    JwtConfig
+	Payment                PaymentConfig
+	Cache                  CacheConfig
 }
 
 func ReadConfigFile(path string) (*Config, error) {
@@ -30,5 +36,23 @@ func ReadConfigFile(path string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.Driver == "" {
+		config.Driver = DriverPostgres
+	}
+	if config.Payment.Provider == "" {
+		config.Payment.Provider = PaymentProviderFake
+	}
+	if config.Cache.CacheControl == "" {
+		config.Cache.CacheControl = "private, no-cache"
+	}
+	if config.Cache.Server.TTL == 0 {
+		config.Cache.Server.TTL = 30 * time.Second
+	}
+	if config.Cache.Server.MaxEntries == 0 {
+		config.Cache.Server.MaxEntries = 1000
+	}
+	if config.Cache.Server.MaxBytes == 0 {
+		config.Cache.Server.MaxBytes = 32 << 20
+	}
 	return &config, nil
 }
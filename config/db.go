@@ -9,6 +9,12 @@ import (
 	_ "github.com/lib/pq"
 )
 
+const (
+	DriverPostgres  = "postgres"
+	DriverSqlServer = "sqlserver"
+	DriverMemory    = "memory"
+)
+
 type SqlServerDBConfig struct {
 	User     string `toml:"user"`
 	Pass     string `toml:"pass"`
@@ -26,7 +32,7 @@ type PostgresSqlDBConfig struct {
 }
 
 func NewDatabaseConnectionSqlServer(cfg SqlServerDBConfig) (*sql.DB, error) {
-	connString := fmt.Sprintf("server=%s;user id=%s;password=%s;port=%s", strings.ReplaceAll(cfg.DbServer, "/", "\\"), cfg.User, cfg.Pass, cfg.Port)
+	connString := fmt.Sprintf("server=%s;user id=%s;password=%s;port=%s;database=%s", strings.ReplaceAll(cfg.DbServer, "/", "\\"), cfg.User, cfg.Pass, cfg.Port, cfg.Database)
 	conn, err := sql.Open("mssql", connString)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+// JwtConfig lists the asymmetric keys used to sign the tokens. When no key is
+// configured the tokens keep being signed with HS256 and the SECRET_KEY env var.
+type JwtConfig struct {
+	Keys []JwtKeyConfig `toml:"keys"`
+}
+
+// JwtKeyConfig describes one signing key. The key with the most recent
+// activeFrom not in the future signs new tokens; older keys are still accepted
+// until retireAt (by default the next key activation plus the refresh token
+// lifetime), so tokens issued before a rotation keep working.
+type JwtKeyConfig struct {
+	Kid            string    `toml:"kid"`
+	PrivateKeyFile string    `toml:"privateKeyFile"`
+	ActiveFrom     time.Time `toml:"activeFrom"`
+	RetireAt       time.Time `toml:"retireAt"`
+}
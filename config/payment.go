@@ -0,0 +1,13 @@
+package config
+
+const (
+	PaymentProviderFake = "fake"
+)
+
+// PaymentConfig selects the payment gateway. The fake provider answers every
+// request locally with the outcome set in fakeMode: succeed (the default),
+// decline or timeout. Webhooks are signed with the PAYMENT_WEBHOOK_SECRET env var.
+type PaymentConfig struct {
+	Provider string `toml:"provider"`
+	FakeMode string `toml:"fakeMode"`
+}
@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CartCreate     = "/carts"
+	CartGet        = "/carts/%s"
+	CartItemAdd    = "/carts/%s/items"
+	CartItemUpdate = "/carts/%s/items/%s"
+	CartItemRemove = "/carts/%s/items/%s"
+)
+
+type CartInterface interface {
+	CreateCart(ctx context.Context, cart Cart) error
+	// GetCartById and GetCartByOwner return an empty cart when there is none.
+	GetCartById(ctx context.Context, id uuid.UUID) (Cart, error)
+	GetCartByOwner(ctx context.Context, owner string) (Cart, error)
+	// AddCartItem inserts the item or, when the product is already in the
+	// cart, adds item.Quantity to the current quantity keeping its price.
+	AddCartItem(ctx context.Context, cartId uuid.UUID, item CartItem, now time.Time) error
+	// UpdateCartItemQuantity and DeleteCartItem return false when the product
+	// is not in the cart.
+	UpdateCartItemQuantity(ctx context.Context, cartId uuid.UUID, productId uuid.UUID, quantity int, now time.Time) (bool, error)
+	DeleteCartItem(ctx context.Context, cartId uuid.UUID, productId uuid.UUID, now time.Time) (bool, error)
+	// ClaimCart sets the owner of an anonymous cart, returning false when the
+	// cart already has one.
+	ClaimCart(ctx context.Context, id uuid.UUID, owner string, now time.Time) (bool, error)
+	// MergeCarts moves the items of source into target, summing the quantities
+	// of products in both, and deletes source.
+	MergeCarts(ctx context.Context, sourceId uuid.UUID, targetId uuid.UUID, now time.Time) error
+}
+
+// Cart belongs to Owner, or to whoever knows its id while Owner is empty.
+type Cart struct {
+	Id        uuid.UUID  `json:"id"`
+	Owner     string     `json:"owner,omitempty"`
+	Items     []CartItem `json:"items"`
+	Total     Money      `json:"total"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CartItem keeps the name and price of the product from when it was added.
+type CartItem struct {
+	ProductId uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	UnitPrice Money     `json:"unit_price"`
+	Quantity  int       `json:"quantity"`
+	Subtotal  Money     `json:"subtotal"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// CartResource replaces the items of the cart by their resources.
+type CartResource struct {
+	Cart
+	Items []CartItemResource `json:"items"`
+}
+
+type CartItemResource struct {
+	CartItem
+	Links Hateoas `json:"_meta"`
+}
+
+func (c Cart) IsEmpty() bool {
+	return c.Id == uuid.Nil
+}
+
+func (c Cart) IsAnonymous() bool {
+	return c.Owner == ""
+}
+
+// WithTotals fills the item subtotals and the cart total.
+func (c Cart) WithTotals() Cart {
+	items := make([]CartItem, len(c.Items))
+	total := NewMoney(0, DefaultCurrency)
+	for index, item := range c.Items {
+		item.Subtotal = item.UnitPrice.Multiply(item.Quantity)
+		total = total.Add(item.Subtotal)
+		items[index] = item
+	}
+	c.Items = items
+	c.Total = total
+	return c
+}
@@ -2,19 +2,47 @@ package entities
 
 import (
 	"context"
+	"errors"
+	"slices"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrParentNotFound and ErrParentCycle are returned by the updates of the
+	// repositories when the new parent of a category does not exist or is the
+	// category itself or one of its descendants.
+	ErrParentNotFound = errors.New("parent category not found")
+	ErrParentCycle    = errors.New("parent category is the category or one of its descendants")
+)
+
 type CategoryInterface interface {
 	GetPaginateCategories(ctx context.Context, page int, limit int, params map[string][]string) ([]Category, int, error)
+	// GetCategoriesByCursor returns up to cursor.Limit+1 categories, the extra
+	// one telling that the page is not the last in its direction, in the order
+	// of the list even when the cursor goes backward.
+	GetCategoriesByCursor(ctx context.Context, params map[string][]string, cursor Cursor) ([]Category, int, error)
 	GetCategoryById(ctx context.Context, id uuid.UUID) (Category, error)
 	GetCategoriesByIds(ctx context.Context, ids []uuid.UUID) ([]Category, error)
 	CreateCategory(ctx context.Context, category Category) (Category, error)
-	DeleteCategoryById(ctx context.Context, id uuid.UUID) error
+	DeleteCategoryById(ctx context.Context, id uuid.UUID, versions []int64) error
 	DeleteCategories(ctx context.Context, ids []uuid.UUID) error
-	GetAllProductsByCategory(ctx context.Context, id uuid.UUID) ([]Product, error)
-	UpdateCategoryFields(ctx context.Context, id uuid.UUID, fields map[string]any) (Category, error)
+	// GetAllProductsByCategory also lists the products of the subcategories,
+	// at any depth, when descendants is set.
+	GetAllProductsByCategory(ctx context.Context, id uuid.UUID, descendants bool) ([]Product, error)
+	// GetProductsByCategories returns the products of each of the categories
+	// in one query, leaving out the categories without products.
+	GetProductsByCategories(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]Product, error)
+	GetAllCategories(ctx context.Context) ([]Category, error)
+	GetCategoryChildren(ctx context.Context, id uuid.UUID) ([]Category, error)
+	// GetCategoryAncestors lists the ancestors of the category from the root
+	// down to its parent.
+	GetCategoryAncestors(ctx context.Context, id uuid.UUID) ([]Category, error)
+	// UpdateCategoryFields and PatchCategoryFields check a new parent within
+	// the transaction of the update, returning ErrParentNotFound or
+	// ErrParentCycle, so concurrent moves cannot turn the tree into a cycle.
+	UpdateCategoryFields(ctx context.Context, id uuid.UUID, update CategoryFieldsUpdate, versions []int64) (Category, error)
+	PatchCategoryFields(ctx context.Context, id uuid.UUID, build func(current Category) (CategoryFieldsUpdate, error)) (Category, error)
 }
 
 const (
@@ -23,15 +51,38 @@ const (
 	CategoryCreate = "/admin/categories"
 	CategoryUpdate = "/admin/categories/%s"
 	CategoryDelete = "/admin/categories/%s"
+
+	CategoryTree      = "/categories/tree"
+	CategoryChildren  = "/categories/%s/children"
+	CategoryAncestors = "/categories/%s/ancestors"
+	CategoryProducts  = "/categories/%s/products"
 )
 
+// Category is a subcategory of ParentId, or a root category when it is nil.
 type Category struct {
-	Id          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	Active      bool      `json:"active"`
-	CreatedAt   string    `json:"created_at"`
-	UpdatedAt   string    `json:"updated_at,omitempty"`
+	Id          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Active      bool       `json:"active"`
+	ParentId    *uuid.UUID `json:"parent_id"`
+	CreatedAt   string     `json:"created_at"`
+	UpdatedAt   string     `json:"updated_at,omitempty"`
+	Version     int64      `json:"-"`
+}
+
+// CategoryFieldsUpdate holds the fields a partial update may change, nil meaning
+// "keep the current value". An invalid ParentId moves the category to the root.
+type CategoryFieldsUpdate struct {
+	Name        *string
+	Description *string
+	Active      *bool
+	ParentId    *uuid.NullUUID
+}
+
+// CategoryNode is a category of the tree along with its subcategories.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
 }
 
 type CategoryResource struct {
@@ -42,3 +93,60 @@ type CategoryResource struct {
 func (c Category) IsEmpty() bool {
 	return c.Id == uuid.Nil
 }
+
+func (u CategoryFieldsUpdate) IsEmpty() bool {
+	return u.Name == nil && u.Description == nil && u.Active == nil && u.ParentId == nil
+}
+
+// NewCategoryTree nests the categories under their parents, keeping their
+// order among siblings. Categories whose parent is not in the list are left
+// out, and so are cycles, which have no root.
+func NewCategoryTree(categories []Category) []CategoryNode {
+	children := make(map[uuid.UUID][]Category)
+	var roots []Category
+	for _, category := range categories {
+		if category.ParentId == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentId] = append(children[*category.ParentId], category)
+	}
+
+	var nest func(categories []Category) []CategoryNode
+	nest = func(categories []Category) []CategoryNode {
+		nodes := make([]CategoryNode, len(categories))
+		for index, category := range categories {
+			nodes[index] = CategoryNode{
+				Category: category,
+				Children: nest(children[category.Id]),
+			}
+		}
+		return nodes
+	}
+	return nest(roots)
+}
+
+// AncestorsOf follows the parents of the category id through categories,
+// which must contain it, and returns them from the root down. It stops at a
+// category seen before, so a cycle cannot loop forever.
+func AncestorsOf(id uuid.UUID, categories []Category) []Category {
+	byId := make(map[uuid.UUID]Category, len(categories))
+	for _, category := range categories {
+		byId[category.Id] = category
+	}
+
+	var ancestors []Category
+	seen := map[uuid.UUID]bool{id: true}
+	current := byId[id]
+	for current.ParentId != nil && !seen[*current.ParentId] {
+		parent, exists := byId[*current.ParentId]
+		if !exists {
+			break
+		}
+		seen[parent.Id] = true
+		ancestors = append(ancestors, parent)
+		current = parent
+	}
+	slices.Reverse(ancestors)
+	return ancestors
+}
@@ -0,0 +1,12 @@
+package entities
+
+// Cursor asks a repository for the page of a list that follows the item whose
+// sort values are Keys, the id being the last of them, or that precedes it
+// when Backward. No keys start at the beginning of the list.
+type Cursor struct {
+	Keys     []string
+	Backward bool
+	Limit    int
+	// SkipCount leaves the COUNT(*) query out, the total being returned as -1.
+	SkipCount bool
+}
@@ -11,60 +11,96 @@ var (
 	NO_CONTENT             = "No Content"
 	UNSUPPORTED_MEDIA_TYPE = "Unsupported media type"
 	NOT_ACCEPTABLE         = "Not acceptable"
+	UNPROCESSABLE_ENTITY   = "Unprocessable Entity"
+	PRECONDITION_FAILED    = "Precondition Failed"
+	PRECONDITION_REQUIRED  = "Precondition Required"
+	PAYMENT_REQUIRED       = "Payment Required"
+	GATEWAY_TIMEOUT        = "Gateway Timeout"
 )
 
+// Error is rendered by utils.JSONError as an RFC 9457 problem details
+// document: Code becomes the status and title, Message the detail and Fields
+// the errors member.
 type Error struct {
-	Code      string `json:"code"`
-	Message   string `json:"message"`
-	Err       error  `json:"-"`
-	Operation string `json:"-"`
+	Code      string
+	Message   string
+	Fields    []FieldError
+	Err       error
+	Operation string
+}
+
+// FieldError points which field of the request body was rejected and why.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 func (e *Error) Error() string {
 	return e.Message
 }
 
-func newError(code string, message string, err error, operation string) *Error {
+func newError(code string, message string, err error, operation string, fields []FieldError) *Error {
 	return &Error{
 		Code:      code,
 		Message:   message,
+		Fields:    fields,
 		Err:       err,
 		Operation: operation,
 	}
 }
 
-func NewBadRequestError(err error, message string, operation string) *Error {
-	return newError(BAD_REQUEST, message, err, operation)
+func NewBadRequestError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(BAD_REQUEST, message, err, operation, fields)
 }
 
 func NewInternalServerErrorError(err error, operation string) *Error {
-	return newError(INTERNAL_SERVER_ERROR, "Um erro inesperado aconteceu, tente novamente mais tarde", err, operation)
+	return newError(INTERNAL_SERVER_ERROR, "Um erro inesperado aconteceu, tente novamente mais tarde", err, operation, nil)
+}
+
+func NewNotFoundError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(NOT_FOUND, message, err, operation, fields)
+}
+
+func NewConflictError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(CONFLICT, message, err, operation, fields)
+}
+
+func NewUnauthorizedError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(UNAUTHORIZED, message, err, operation, fields)
+}
+
+func NewForbiddenError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(FORBIDDEN, message, err, operation, fields)
+}
+
+func NewNotImplementedError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(NOT_IMPLEMENTED, message, err, operation, fields)
 }
 
-func NewNotFoundError(err error, message string, operation string) *Error {
-	return newError(NOT_FOUND, message, err, operation)
+func NewUnsupportedMediaType(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(UNSUPPORTED_MEDIA_TYPE, message, err, operation, fields)
 }
 
-func NewConflictError(err error, message string, operation string) *Error {
-	return newError(CONFLICT, message, err, operation)
+func NewNotAcceptable(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(NOT_ACCEPTABLE, message, err, operation, fields)
 }
 
-func NewUnauthorizedError(err error, message string, operation string) *Error {
-	return newError(UNAUTHORIZED, message, err, operation)
+func NewUnprocessableEntityError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(UNPROCESSABLE_ENTITY, message, err, operation, fields)
 }
 
-func NewForbiddenError(err error, message string, operation string) *Error {
-	return newError(FORBIDDEN, message, err, operation)
+func NewPreconditionFailedError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(PRECONDITION_FAILED, message, err, operation, fields)
 }
 
-func NewNotImplementedError(err error, message string, operation string) *Error {
-	return newError(NOT_IMPLEMENTED, message, err, operation)
+func NewPreconditionRequiredError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(PRECONDITION_REQUIRED, message, err, operation, fields)
 }
 
-func NewUnsupportedMediaType(err error, message string, operation string) *Error {
-	return newError(UNSUPPORTED_MEDIA_TYPE, message, err, operation)
+func NewPaymentRequiredError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(PAYMENT_REQUIRED, message, err, operation, fields)
 }
 
-func NewNotAcceptable(err error, message string, operation string) *Error {
-	return newError(NOT_ACCEPTABLE, message, err, operation)
+func NewGatewayTimeoutError(err error, message string, operation string, fields ...FieldError) *Error {
+	return newError(GATEWAY_TIMEOUT, message, err, operation, fields)
 }
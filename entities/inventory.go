@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StockGet          = "/admin/products/%s/stock"
+	StockAdjustments  = "/admin/products/%s/stock/adjustments"
+	StockReservations = "/admin/products/%s/stock/reservations"
+	StockRelease      = "/admin/products/%s/stock/reservations/%s"
+)
+
+var (
+	// ErrInsufficientStock is returned by the repositories when an adjustment
+	// would leave negative stock or a reservation exceeds the available
+	// quantity.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrReservedStock is returned by the repositories when a removal would
+	// leave less stock on hand than the valid reservations hold.
+	ErrReservedStock = errors.New("stock on hand below the reserved quantity")
+)
+
+type InventoryInterface interface {
+	// GetStock returns a zeroed stock for products that were never adjusted.
+	GetStock(ctx context.Context, productId uuid.UUID, now time.Time) (Stock, error)
+	AdjustStock(ctx context.Context, adjustment StockAdjustment, now time.Time) (Stock, error)
+	GetStockAdjustments(ctx context.Context, productId uuid.UUID, page int, limit int) ([]StockAdjustment, int, error)
+	GetActiveReservations(ctx context.Context, productId uuid.UUID, now time.Time) ([]StockReservation, error)
+	ReserveStock(ctx context.Context, reservation StockReservation, now time.Time) error
+	// ReleaseReservation returns false when there was no such reservation.
+	ReleaseReservation(ctx context.Context, productId uuid.UUID, id uuid.UUID) (bool, error)
+}
+
+// Stock is the quantity on hand of a product. Reserved only counts the
+// reservations that have not expired yet.
+type Stock struct {
+	ProductId uuid.UUID  `json:"product_id"`
+	OnHand    int        `json:"on_hand"`
+	Reserved  int        `json:"reserved"`
+	Available int        `json:"available"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// StockAdjustment is an entry of the append only ledger of stock changes.
+type StockAdjustment struct {
+	Id          uuid.UUID `json:"id"`
+	ProductId   uuid.UUID `json:"product_id"`
+	Delta       int       `json:"delta"`
+	OnHandAfter int       `json:"on_hand_after"`
+	Reason      string    `json:"reason"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StockReservation holds a quantity of a product until ExpiresAt, after which
+// it no longer counts against the available stock.
+type StockReservation struct {
+	Id        uuid.UUID `json:"id"`
+	ProductId uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	Reference string    `json:"reference,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
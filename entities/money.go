@@ -0,0 +1,202 @@
+package entities
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of the base price of products and so of
+// carts and orders; other currencies come from the price lists.
+const DefaultCurrency = "BRL"
+
+// MaxMoneyAmount is the largest amount, in minor units, the NUMERIC(12, 2)
+// columns hold.
+const MaxMoneyAmount int64 = 999999999999
+
+var (
+	ErrInvalidCurrency = errors.New("currency must be a supported ISO 4217 code")
+	ErrInvalidAmount   = errors.New("amount must be a decimal number within the minor units of its currency")
+)
+
+// currencyMinorUnits maps the supported ISO 4217 codes to their number of
+// decimal places. Amounts are stored with two, so currencies with more, such
+// as KWD, are left out.
+var currencyMinorUnits = map[string]int{
+	"ARS": 2,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CLP": 0,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+	"MXN": 2,
+	"PYG": 0,
+	"USD": 2,
+	"UYU": 2,
+}
+
+var decimalPattern = regexp.MustCompile(`^-?[0-9]{1,15}(\.[0-9]+)?$`)
+
+// Money is an exact amount, in the minor units of Currency (cents for BRL),
+// so totals never carry the rounding errors of floats. It is encoded in JSON
+// as {"amount": "10.50", "currency": "BRL"}.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// IsCurrency tells whether code is a supported ISO 4217 currency.
+func IsCurrency(code string) bool {
+	_, exists := currencyMinorUnits[code]
+	return exists
+}
+
+// ParseMoney reads a decimal amount such as "10.5" in currency, rejecting
+// more decimal places than the currency has.
+func ParseMoney(amount string, currency string) (Money, error) {
+	minorUnits, exists := currencyMinorUnits[currency]
+	if !exists {
+		return Money{}, ErrInvalidCurrency
+	}
+	if !decimalPattern.MatchString(amount) {
+		return Money{}, ErrInvalidAmount
+	}
+	negative := strings.HasPrefix(amount, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(amount, "-"), ".")
+	// the database returns NUMERIC(12, 2) with trailing zeros, even for JPY
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > minorUnits {
+		return Money{}, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", minorUnits-len(fraction))
+
+	var value int64
+	for _, digit := range whole + fraction {
+		value = value*10 + int64(digit-'0')
+	}
+	if value > MaxMoneyAmount {
+		return Money{}, ErrInvalidAmount
+	}
+	if negative {
+		value = -value
+	}
+	return Money{Amount: value, Currency: currency}, nil
+}
+
+// String formats the amount with the decimal places of the currency, the way
+// it is written to the database: 1050 BRL is "10.50".
+func (m Money) String() string {
+	minorUnits := currencyMinorUnits[m.Currency]
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if minorUnits == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+	scale := int64(1)
+	for range minorUnits {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, minorUnits, amount%scale)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add sums amounts of the same currency; the result keeps the currency of m.
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+func (m Money) Multiply(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Scan reads a NUMERIC column in the currency already set on m, or else in
+// DefaultCurrency, since the currency is kept in a column of its own.
+func (m *Money) Scan(src any) error {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	var text string
+	switch value := src.(type) {
+	case []byte:
+		text = string(value)
+	case string:
+		text = value
+	case int64:
+		text = strconv.FormatInt(value, 10)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	parsed, err := ParseMoney(text, currency)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into Money: %w", text, err)
+	}
+	*m = parsed
+	return nil
+}
+
+// Value writes the decimal text, which both databases convert to NUMERIC
+// without going through a float.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.String(), m.Currency})
+}
+
+// UnmarshalJSON accepts the object form, with the amount as a string or a
+// number, and, as older clients send prices, a bare number or string in
+// DefaultCurrency. Numbers are read from their text, never through a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	value := moneyJSON{Amount: data, Currency: DefaultCurrency}
+	if bytes.HasPrefix(data, []byte("{")) {
+		value = moneyJSON{}
+		err := json.Unmarshal(data, &value)
+		if err != nil {
+			return err
+		}
+		if value.Currency == "" {
+			value.Currency = DefaultCurrency
+		}
+	}
+
+	amount := string(value.Amount)
+	var text string
+	if json.Unmarshal(value.Amount, &text) == nil {
+		amount = text
+	}
+	parsed, err := ParseMoney(amount, value.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		minor    int64
+		err      error
+	}{
+		{amount: "10.5", currency: "BRL", minor: 1050},
+		{amount: "10.50", currency: "BRL", minor: 1050},
+		{amount: "0.01", currency: "USD", minor: 1},
+		{amount: "-3.25", currency: "EUR", minor: -325},
+		{amount: "1500", currency: "JPY", minor: 1500},
+		// the database returns the NUMERIC(12, 2) scale for every currency
+		{amount: "1500.00", currency: "JPY", minor: 1500},
+		{amount: "9999999999.99", currency: "BRL", minor: MaxMoneyAmount},
+		{amount: "10000000000.00", currency: "BRL", err: ErrInvalidAmount},
+		{amount: "10.505", currency: "BRL", err: ErrInvalidAmount},
+		{amount: "1500.5", currency: "JPY", err: ErrInvalidAmount},
+		{amount: "1e3", currency: "BRL", err: ErrInvalidAmount},
+		{amount: ".5", currency: "BRL", err: ErrInvalidAmount},
+		{amount: "", currency: "BRL", err: ErrInvalidAmount},
+		{amount: "10", currency: "KWD", err: ErrInvalidCurrency},
+		{amount: "10", currency: "brl", err: ErrInvalidCurrency},
+	}
+	for _, test := range tests {
+		t.Run(test.amount+" "+test.currency, func(t *testing.T) {
+			money, err := ParseMoney(test.amount, test.currency)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("ParseMoney() error = %v, want %v", err, test.err)
+			}
+			if err == nil && money != NewMoney(test.minor, test.currency) {
+				t.Errorf("ParseMoney() = %+v, want %d %s", money, test.minor, test.currency)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		text  string
+	}{
+		{money: NewMoney(1050, "BRL"), text: "10.50"},
+		{money: NewMoney(7, "USD"), text: "0.07"},
+		{money: NewMoney(-325, "EUR"), text: "-3.25"},
+		{money: NewMoney(1500, "JPY"), text: "1500"},
+		{money: NewMoney(0, "CLP"), text: "0"},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if text := test.money.String(); text != test.text {
+				t.Errorf("String() = %q, want %q", text, test.text)
+			}
+			parsed, err := ParseMoney(test.money.String(), test.money.Currency)
+			if err != nil || parsed != test.money {
+				t.Errorf("ParseMoney(String()) = %+v, %v, want %+v", parsed, err, test.money)
+			}
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		money Money
+		err   bool
+	}{
+		{name: "object", json: `{"amount":"10.50","currency":"USD"}`, money: NewMoney(1050, "USD")},
+		{name: "object with numeric amount", json: `{"amount":10.5,"currency":"USD"}`, money: NewMoney(1050, "USD")},
+		{name: "object without currency", json: `{"amount":"2"}`, money: NewMoney(200, DefaultCurrency)},
+		{name: "bare number", json: `19.99`, money: NewMoney(1999, DefaultCurrency)},
+		{name: "bare string", json: `"19.99"`, money: NewMoney(1999, DefaultCurrency)},
+		// read from the text, never through a float
+		{name: "number without float rounding", json: `0.3`, money: NewMoney(30, DefaultCurrency)},
+		{name: "too many decimal places", json: `{"amount":"1.001","currency":"USD"}`, err: true},
+		{name: "unsupported currency", json: `{"amount":"1","currency":"XYZ"}`, err: true},
+		{name: "boolean", json: `true`, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var money Money
+			err := json.Unmarshal([]byte(test.json), &money)
+			if (err != nil) != test.err {
+				t.Fatalf("Unmarshal() error = %v, want error %v", err, test.err)
+			}
+			if err == nil && money != test.money {
+				t.Errorf("Unmarshal() = %+v, want %+v", money, test.money)
+			}
+		})
+	}
+
+	content, err := json.Marshal(NewMoney(1500, "JPY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `{"amount":"1500","currency":"JPY"}` {
+		t.Errorf("Marshal() = %s", content)
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		src      any
+		money    Money
+		err      bool
+	}{
+		{name: "bytes in the default currency", src: []byte("10.50"), money: NewMoney(1050, DefaultCurrency)},
+		{name: "string in the currency set", currency: "JPY", src: "1500.00", money: NewMoney(1500, "JPY")},
+		{name: "integer", currency: "USD", src: int64(3), money: NewMoney(300, "USD")},
+		{name: "float", src: 10.5, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			money := Money{Currency: test.currency}
+			err := money.Scan(test.src)
+			if (err != nil) != test.err {
+				t.Fatalf("Scan() error = %v, want error %v", err, test.err)
+			}
+			if err == nil && money != test.money {
+				t.Errorf("Scan() = %+v, want %+v", money, test.money)
+			}
+		})
+	}
+}
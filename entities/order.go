@@ -0,0 +1,190 @@
+package entities
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OrderCreate    = "/orders"
+	OrderList      = "/orders"
+	OrderGet       = "/orders/%s"
+	OrderAction    = "/orders/%s/%s"
+	OrderAdminList = "/admin/orders"
+)
+
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderPaid      OrderStatus = "paid"
+	OrderShipped   OrderStatus = "shipped"
+	OrderDelivered OrderStatus = "delivered"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderRefunded  OrderStatus = "refunded"
+)
+
+// OrderTransition is an action moving an order from one status to another.
+type OrderTransition string
+
+const (
+	TransitionPay     OrderTransition = "pay"
+	TransitionShip    OrderTransition = "ship"
+	TransitionDeliver OrderTransition = "deliver"
+	TransitionCancel  OrderTransition = "cancel"
+	TransitionRefund  OrderTransition = "refund"
+)
+
+// OrderTransitions lists every transition in the order their links are shown.
+var OrderTransitions = []OrderTransition{
+	TransitionPay,
+	TransitionShip,
+	TransitionDeliver,
+	TransitionCancel,
+	TransitionRefund,
+}
+
+// orderStateMachine maps each status to the transitions leaving it and the
+// status they lead to. Cancelled and refunded orders are final.
+var orderStateMachine = map[OrderStatus]map[OrderTransition]OrderStatus{
+	OrderPending: {
+		TransitionPay:    OrderPaid,
+		TransitionCancel: OrderCancelled,
+	},
+	OrderPaid: {
+		TransitionShip:   OrderShipped,
+		TransitionRefund: OrderRefunded,
+	},
+	OrderShipped: {
+		TransitionDeliver: OrderDelivered,
+	},
+	OrderDelivered: {
+		TransitionRefund: OrderRefunded,
+	},
+	OrderCancelled: {},
+	OrderRefunded:  {},
+}
+
+type OrderInterface interface {
+	// CreateOrder prices the lines with the current name and price of the
+	// products and stores the order in the same transaction. It returns an
+	// UnavailableProductsError when a product does not exist or is inactive.
+	CreateOrder(ctx context.Context, order Order, lines []OrderLine) (Order, error)
+	// GetOrderById returns an empty order when there is none.
+	GetOrderById(ctx context.Context, id uuid.UUID) (Order, error)
+	// GetOrders lists the orders newest first, filtered by the owner and the
+	// status when they are not empty.
+	GetOrders(ctx context.Context, owner string, status OrderStatus, page int, limit int) ([]Order, int, error)
+	// UpdateOrderStatus moves the order from the status from to the status to,
+	// returning false when the order is no longer in from.
+	UpdateOrderStatus(ctx context.Context, id uuid.UUID, from OrderStatus, to OrderStatus, now time.Time) (bool, error)
+}
+
+// Order is a purchase of Owner. Its items and total never change after the
+// checkout, only the status does.
+type Order struct {
+	Id        uuid.UUID   `json:"id"`
+	Owner     string      `json:"owner"`
+	Status    OrderStatus `json:"status"`
+	Items     []OrderItem `json:"items"`
+	Total     Money       `json:"total"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// OrderItem keeps the name and price the product had at the checkout.
+type OrderItem struct {
+	ProductId uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	UnitPrice Money     `json:"unit_price"`
+	Quantity  int       `json:"quantity"`
+	Subtotal  Money     `json:"subtotal"`
+}
+
+// OrderLine is a product and the quantity requested at the checkout.
+type OrderLine struct {
+	ProductId uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+type OrderResource struct {
+	Order
+	Links Hateoas `json:"_meta"`
+}
+
+// UnavailableProductsError lists the products of a checkout that do not exist
+// or are inactive.
+type UnavailableProductsError struct {
+	ProductIds []uuid.UUID
+}
+
+func (e UnavailableProductsError) Error() string {
+	return fmt.Sprintf("unavailable products: %v", e.ProductIds)
+}
+
+func (o Order) IsEmpty() bool {
+	return o.Id == uuid.Nil
+}
+
+// NewOrderItems prices the lines with the products, which must contain every
+// product of the lines, and returns the items and the total of the order.
+func NewOrderItems(lines []OrderLine, products map[uuid.UUID]Product) ([]OrderItem, Money) {
+	items := make([]OrderItem, len(lines))
+	total := NewMoney(0, DefaultCurrency)
+	for index, line := range lines {
+		product := products[line.ProductId]
+		items[index] = OrderItem{
+			ProductId: line.ProductId,
+			Name:      product.Name,
+			UnitPrice: product.Price,
+			Quantity:  line.Quantity,
+			Subtotal:  product.Price.Multiply(line.Quantity),
+		}
+		total = total.Add(items[index].Subtotal)
+	}
+	return items, total
+}
+
+// UnavailableProducts returns the products of the lines missing from products
+// or inactive, in the order of the lines.
+func UnavailableProducts(lines []OrderLine, products map[uuid.UUID]Product) []uuid.UUID {
+	var unavailable []uuid.UUID
+	for _, line := range lines {
+		product, exists := products[line.ProductId]
+		if !exists || !product.Active {
+			unavailable = append(unavailable, line.ProductId)
+		}
+	}
+	return unavailable
+}
+
+func (s OrderStatus) IsValid() bool {
+	_, exists := orderStateMachine[s]
+	return exists
+}
+
+// Next returns the status reached by applying the transition, and false when
+// the transition is not allowed from s.
+func (s OrderStatus) Next(transition OrderTransition) (OrderStatus, bool) {
+	next, allowed := orderStateMachine[s][transition]
+	return next, allowed
+}
+
+// Transitions returns the transitions allowed from s.
+func (s OrderStatus) Transitions() []OrderTransition {
+	var transitions []OrderTransition
+	for _, transition := range OrderTransitions {
+		if _, allowed := s.Next(transition); allowed {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions
+}
+
+func (t OrderTransition) IsValid() bool {
+	return slices.Contains(OrderTransitions, t)
+}
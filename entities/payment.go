@@ -0,0 +1,102 @@
+package entities
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PaymentCreate  = "/payments"
+	PaymentGet     = "/payments/%s"
+	PaymentAction  = "/payments/%s/%s"
+	PaymentWebhook = "/payments/webhook"
+)
+
+type PaymentStatus string
+
+const (
+	// PaymentPending is an intent the gateway did not answer yet, such as
+	// after a timeout. A webhook moves it to the final authorization status.
+	PaymentPending    PaymentStatus = "pending"
+	PaymentAuthorized PaymentStatus = "authorized"
+	PaymentDeclined   PaymentStatus = "declined"
+	PaymentFailed     PaymentStatus = "failed"
+	PaymentCaptured   PaymentStatus = "captured"
+	PaymentVoided     PaymentStatus = "voided"
+	PaymentRefunded   PaymentStatus = "refunded"
+	// PaymentCapturing, PaymentVoiding and PaymentRefunding claim the payment
+	// while the gateway processes an action, so two requests cannot both send
+	// it. A refused action moves the payment back; after a timeout a webhook
+	// reports the final status.
+	PaymentCapturing PaymentStatus = "capturing"
+	PaymentVoiding   PaymentStatus = "voiding"
+	PaymentRefunding PaymentStatus = "refunding"
+)
+
+// paymentStateMachine maps each status to the statuses it may move to. The
+// return of a claimed payment to its previous status is left out, as only the
+// action that claimed it may undo the claim.
+var paymentStateMachine = map[PaymentStatus][]PaymentStatus{
+	PaymentPending:    {PaymentAuthorized, PaymentDeclined, PaymentFailed},
+	PaymentAuthorized: {PaymentCapturing, PaymentVoiding, PaymentCaptured, PaymentVoided},
+	PaymentDeclined:   {},
+	PaymentFailed:     {},
+	PaymentCapturing:  {PaymentCaptured},
+	PaymentCaptured:   {PaymentRefunding, PaymentRefunded},
+	PaymentVoiding:    {PaymentVoided},
+	PaymentVoided:     {},
+	PaymentRefunding:  {PaymentRefunded},
+	PaymentRefunded:   {},
+}
+
+// transientPaymentStatuses only hold a payment until the gateway answers.
+var transientPaymentStatuses = []PaymentStatus{PaymentPending, PaymentCapturing, PaymentVoiding, PaymentRefunding}
+
+type PaymentInterface interface {
+	CreatePayment(ctx context.Context, payment Payment) error
+	// GetPaymentById and GetPaymentByReference return an empty payment when
+	// there is none.
+	GetPaymentById(ctx context.Context, id uuid.UUID) (Payment, error)
+	GetPaymentByReference(ctx context.Context, createdBy string, reference string) (Payment, error)
+	// UpdatePayment stores the status, provider reference and failure reason
+	// of the payment, returning false when its status is no longer from.
+	UpdatePayment(ctx context.Context, payment Payment, from PaymentStatus) (bool, error)
+}
+
+// Payment is an intent to charge Amount for Reference, an identifier chosen by
+// the caller such as an order id. A caller has one payment per reference.
+type Payment struct {
+	Id                uuid.UUID     `json:"id"`
+	Reference         string        `json:"reference"`
+	Amount            Money         `json:"amount"`
+	Status            PaymentStatus `json:"status"`
+	Provider          string        `json:"provider"`
+	ProviderReference string        `json:"provider_reference,omitempty"`
+	FailureReason     string        `json:"failure_reason,omitempty"`
+	CreatedBy         string        `json:"created_by"`
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+func (p Payment) IsEmpty() bool {
+	return p.Id == uuid.Nil
+}
+
+func (s PaymentStatus) IsValid() bool {
+	_, exists := paymentStateMachine[s]
+	return exists
+}
+
+// IsTransient tells whether s waits for the answer of the gateway, which the
+// webhooks report as one of the other statuses.
+func (s PaymentStatus) IsTransient() bool {
+	return slices.Contains(transientPaymentStatuses, s)
+}
+
+// CanMoveTo tells whether a payment in s may reach the status next.
+func (s PaymentStatus) CanMoveTo(next PaymentStatus) bool {
+	return slices.Contains(paymentStateMachine[s], next)
+}
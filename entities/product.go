@@ -2,36 +2,74 @@ package entities
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type ProductInterface interface {
 	GetAllProducts(ctx context.Context, filters map[string][]string) ([]Product, int, error)
+	// GetProductsByCursor returns up to cursor.Limit+1 products, the extra one
+	// telling that the page is not the last in its direction, in the order of
+	// the list even when the cursor goes backward.
+	GetProductsByCursor(ctx context.Context, filters map[string][]string, cursor Cursor) ([]Product, int, error)
+	// GetProductById loads the CategoriesId of the product as well.
 	GetProductById(ctx context.Context, id uuid.UUID) (Product, error)
-	DeleteProductById(ctx context.Context, id uuid.UUID) error
+	// GetCategoriesIdByProducts returns the ids of the categories of each of
+	// the products in one query, leaving out the products without categories.
+	GetCategoriesIdByProducts(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
+	DeleteProductById(ctx context.Context, id uuid.UUID, versions []int64) error
 	DeleteProducts(ctx context.Context, ids []uuid.UUID) error
 	CreateProduct(ctx context.Context, product Product) (Product, error)
-	UpdateProductFields(ctx context.Context, id uuid.UUID, fields map[string]interface{}) (Product, error)
+	UpdateProductFields(ctx context.Context, id uuid.UUID, update ProductFieldsUpdate, versions []int64) (Product, error)
+	PatchProductFields(ctx context.Context, id uuid.UUID, build func(current Product) (ProductFieldsUpdate, error)) (Product, error)
+	// GetProductPrices lists the price list entries of the product, the
+	// currencies other than DefaultCurrency, ordered by currency.
+	GetProductPrices(ctx context.Context, id uuid.UUID) ([]Money, error)
+	// SetProductPrice inserts or replaces the entry of price.Currency.
+	SetProductPrice(ctx context.Context, id uuid.UUID, price Money, now time.Time) error
+	// DeleteProductPrice returns false when the product has no entry in currency.
+	DeleteProductPrice(ctx context.Context, id uuid.UUID, currency string) (bool, error)
+	// SearchProducts returns a page of the products matching every word of
+	// query, the most relevant first, along with the number of matches. As in
+	// GetAllProducts, the products are priced in currency and those without a
+	// price in it are left out.
+	SearchProducts(ctx context.Context, query string, currency string, page int, limit int) ([]ProductSearchResult, int, error)
 }
 
 const (
 	ProductGet    = "/products/%s"
 	ProductList   = "/products"
+	ProductSearch = "/products/search"
 	ProductCreate = "/admin/products"
 	ProductUpdate = "/admin/products/%s"
 	ProductDelete = "/admin/products/%s"
+	ProductPrices = "/products/%s/prices"
+	ProductPrice  = "/admin/products/%s/prices/%s"
 )
 
+// Product is priced in DefaultCurrency; listing it in another currency
+// replaces Price by the entry of its price list.
 type Product struct {
 	Id           uuid.UUID   `json:"-"`
 	Name         string      `json:"name"`
 	Description  string      `json:"description"`
-	Price        float64     `json:"price"`
+	Price        Money       `json:"price"`
 	Active       bool        `json:"active"`
 	CreatedAt    string      `json:"created_at"`
 	UpdatedAt    string      `json:"updated_at,omitempty"`
 	CategoriesId []uuid.UUID `json:"CategoriesId"`
+	Version      int64       `json:"-"`
+}
+
+// ProductFieldsUpdate holds the fields a partial update may change, nil meaning
+// "keep the current value". CategoriesId replaces the whole relation.
+type ProductFieldsUpdate struct {
+	Name         *string
+	Description  *string
+	Price        *Money
+	Active       *bool
+	CategoriesId *[]uuid.UUID
 }
 
 type ProductResource struct {
@@ -39,6 +77,31 @@ type ProductResource struct {
 	Links Hateoas `json:"_meta"`
 }
 
+// ProductSearchResult is a product found by a search, with its relevance and
+// the name and description, HTML escaped, with the matched words between
+// <mark> tags.
+type ProductSearchResult struct {
+	Product
+	Rank      float64          `json:"rank"`
+	Highlight ProductHighlight `json:"highlight"`
+}
+
+// ProductHighlight holds the name, whole, and a snippet of the description
+// around the matches.
+type ProductHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ProductSearchResource struct {
+	ProductSearchResult
+	Links Hateoas `json:"_meta"`
+}
+
 func (p *Product) IsEmpty() bool {
 	return p.Id == uuid.Nil
 }
+
+func (u ProductFieldsUpdate) IsEmpty() bool {
+	return u.Name == nil && u.Description == nil && u.Price == nil && u.Active == nil && u.CategoriesId == nil
+}
@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshTokenInterface interface {
+	CreateRefreshToken(ctx context.Context, token RefreshTokenRecord) error
+	GetRefreshTokenById(ctx context.Context, id uuid.UUID) (RefreshTokenRecord, error)
+	// MarkRefreshTokenUsed must only succeed once per token, returning false
+	// when the token was already used by a concurrent request.
+	MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyId uuid.UUID, revokedAt time.Time) error
+	RevokeUserRefreshTokens(ctx context.Context, login string, revokedAt time.Time) error
+}
+
+// RefreshTokenRecord is the server side state of an issued refresh token. Every
+// token created by a refresh belongs to the family started at login.
+type RefreshTokenRecord struct {
+	Id        uuid.UUID
+	FamilyId  uuid.UUID
+	Login     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+func (t RefreshTokenRecord) IsEmpty() bool {
+	return t.Id == uuid.Nil
+}
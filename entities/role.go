@@ -0,0 +1,60 @@
+package entities
+
+import "slices"
+
+type Role string
+
+type Permission string
+
+const (
+	RoleCustomer      Role = "customer"
+	RoleCatalogEditor Role = "catalog_editor"
+	RoleAdmin         Role = "admin"
+)
+
+const (
+	PermissionCategoryCreate Permission = "category:create"
+	PermissionCategoryUpdate Permission = "category:update"
+	PermissionCategoryDelete Permission = "category:delete"
+	PermissionProductCreate  Permission = "product:create"
+	PermissionProductUpdate  Permission = "product:update"
+	PermissionProductDelete  Permission = "product:delete"
+	PermissionOrderManage    Permission = "order:manage"
+	PermissionPaymentManage  Permission = "payment:manage"
+	PermissionStockRead      Permission = "stock:read"
+	PermissionStockAdjust    Permission = "stock:adjust"
+	PermissionUserManage     Permission = "user:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer: {},
+	RoleCatalogEditor: {
+		PermissionCategoryCreate,
+		PermissionCategoryUpdate,
+		PermissionProductCreate,
+		PermissionProductUpdate,
+		PermissionStockRead,
+	},
+	RoleAdmin: {
+		PermissionCategoryCreate,
+		PermissionCategoryUpdate,
+		PermissionCategoryDelete,
+		PermissionProductCreate,
+		PermissionProductUpdate,
+		PermissionProductDelete,
+		PermissionOrderManage,
+		PermissionPaymentManage,
+		PermissionStockRead,
+		PermissionStockAdjust,
+		PermissionUserManage,
+	},
+}
+
+func (r Role) IsValid() bool {
+	_, exists := rolePermissions[r]
+	return exists
+}
+
+func (r Role) HasPermission(permission Permission) bool {
+	return slices.Contains(rolePermissions[r], permission)
+}
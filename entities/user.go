@@ -5,9 +5,15 @@ import "context"
 type UserInterface interface {
 	GetCredentialsByLogin(ctx context.Context, login string) (Credentials, error)
 	InsertUser(ctx context.Context, credentials Credentials) error
+	UpdateUserRole(ctx context.Context, login string, role Role) error
 }
 
 type Credentials struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
+	Role     Role   `json:"-"`
+}
+
+func (c Credentials) IsEmpty() bool {
+	return c.Login == ""
 }
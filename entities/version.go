@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrVersionMismatch is returned by the repositories when a conditional write
+// finds the row at a version other than the expected ones.
+var ErrVersionMismatch = errors.New("resource version does not match")
+
+// VersionMatches reports whether version is one of the expected versions. No
+// expected version means the write is unconditional.
+func VersionMatches(expected []int64, version int64) bool {
+	return len(expected) == 0 || slices.Contains(expected, version)
+}
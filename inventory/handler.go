@@ -0,0 +1,227 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"rest-api-example/auth"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrFormatoJsonInvalido = errors.New("verifique o formato do JSON e tente novamente")
+)
+
+type InventoryHandler struct {
+	inventoryService InventoryService
+}
+
+func NewInventoryHandler(s InventoryService) InventoryHandler {
+	return InventoryHandler{
+		inventoryService: s,
+	}
+}
+
+type stockAdjustmentRequest struct {
+	Delta  int    `json:"delta"`
+	Reason string `json:"reason"`
+}
+
+type stockReservationRequest struct {
+	Quantity   int    `json:"quantity"`
+	TTLSeconds int    `json:"ttl_seconds"`
+	Reference  string `json:"reference"`
+}
+
+func (h InventoryHandler) GetStock(w http.ResponseWriter, r *http.Request) {
+	op := "InventoryHandler.GetStock()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	productId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+
+	stock, err := h.inventoryService.GetStock(ctx, productId)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, stock, stockLinks(productId), http.StatusOK)
+}
+
+func (h InventoryHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	op := "InventoryHandler.AdjustStock()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	productId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.JSONError(w, r, entities.NewUnauthorizedError(auth.ErrInvalidToken, auth.ErrInvalidToken.Error(), op))
+		return
+	}
+
+	var request stockAdjustmentRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrFormatoJsonInvalido.Error(), op))
+		return
+	}
+
+	adjustment, _, err := h.inventoryService.AdjustStock(ctx, productId, request.Delta, request.Reason, principal.Login)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, adjustment, stockLinks(productId), http.StatusCreated)
+}
+
+func (h InventoryHandler) GetStockAdjustments(w http.ResponseWriter, r *http.Request) {
+	op := "InventoryHandler.GetStockAdjustments()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	productId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	queryParams := r.URL.Query()
+	page := utils.GetQueryInt(queryParams, "page", 1)
+	limit := utils.GetQueryInt(queryParams, "limit", 10)
+
+	adjustments, totalCount, err := h.inventoryService.GetStockAdjustments(ctx, productId, page, limit)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	if adjustments == nil {
+		adjustments = []entities.StockAdjustment{}
+	}
+
+	listUrl := fmt.Sprintf(entities.StockAdjustments, productId.String())
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+	paginationLinksBuilder := entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf("%s?page=%d&limit=%d", listUrl, page, limit)).
+		AddGet("stock", fmt.Sprintf(entities.StockGet, productId.String()))
+	if page+1 <= totalPages {
+		paginationLinksBuilder.AddGet("next", fmt.Sprintf("%s?page=%d&limit=%d", listUrl, page+1, limit))
+	}
+	if page-1 > 0 {
+		paginationLinksBuilder.AddGet("prev", fmt.Sprintf("%s?page=%d&limit=%d", listUrl, page-1, limit))
+	}
+
+	meta := utils.PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		Results:    len(adjustments),
+		Hateoas:    paginationLinksBuilder.Build(),
+	}
+	utils.Respond(w, r, adjustments, meta, http.StatusOK)
+}
+
+func (h InventoryHandler) GetActiveReservations(w http.ResponseWriter, r *http.Request) {
+	op := "InventoryHandler.GetActiveReservations()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	productId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+
+	reservations, err := h.inventoryService.GetActiveReservations(ctx, productId)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	if reservations == nil {
+		reservations = []entities.StockReservation{}
+	}
+	utils.Respond(w, r, reservations, stockLinks(productId), http.StatusOK)
+}
+
+func (h InventoryHandler) ReserveStock(w http.ResponseWriter, r *http.Request) {
+	op := "InventoryHandler.ReserveStock()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	productId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+
+	var request stockReservationRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrFormatoJsonInvalido.Error(), op))
+		return
+	}
+
+	ttl := time.Duration(request.TTLSeconds) * time.Second
+	reservation, err := h.inventoryService.ReserveStock(ctx, productId, request.Quantity, ttl, request.Reference)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+
+	links := entities.NewHateoasBuilder().
+		AddGet("stock", fmt.Sprintf(entities.StockGet, productId.String())).
+		AddDelete("release", fmt.Sprintf(entities.StockRelease, productId.String(), reservation.Id.String())).
+		Build()
+	utils.Respond(w, r, reservation, links, http.StatusCreated)
+}
+
+func (h InventoryHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	op := "InventoryHandler.ReleaseReservation()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	productId, err := uuid.Parse(vars["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	reservationId, err := uuid.Parse(vars["reservationId"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+
+	err = h.inventoryService.ReleaseReservation(ctx, productId, reservationId)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func stockLinks(productId uuid.UUID) entities.Hateoas {
+	return entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf(entities.StockGet, productId.String())).
+		AddGet("product", fmt.Sprintf(entities.ProductGet, productId.String())).
+		AddGet("adjustments", fmt.Sprintf(entities.StockAdjustments, productId.String())).
+		AddPost("adjust", fmt.Sprintf(entities.StockAdjustments, productId.String())).
+		AddGet("reservations", fmt.Sprintf(entities.StockReservations, productId.String())).
+		AddPost("reserve", fmt.Sprintf(entities.StockReservations, productId.String())).
+		Build()
+}
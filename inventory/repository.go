@@ -0,0 +1,280 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type InventoryRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewInventoryRepositoryPostgres(db *sql.DB) entities.InventoryInterface {
+	return InventoryRepositoryPostgres{
+		db: db,
+	}
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// reservedQuantity sums the reservations of the product that are still valid.
+func reservedQuantity(ctx context.Context, q querier, builder sq.StatementBuilderType, productId any, now time.Time) (int, error) {
+	query, args, err := builder.Select("COALESCE(SUM(quantity), 0)").
+		From("stock_reservations").
+		Where("product_id = ? AND expires_at > ?", productId, now).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var reserved int
+	err = q.QueryRowContext(ctx, query, args...).Scan(&reserved)
+	return reserved, err
+}
+
+func (r InventoryRepositoryPostgres) GetStock(ctx context.Context, productId uuid.UUID, now time.Time) (entities.Stock, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Select("on_hand", "updated_at").
+		From("product_stock").
+		Where("product_id = ?", productId).
+		ToSql()
+	if err != nil {
+		return entities.Stock{}, err
+	}
+
+	stock := entities.Stock{ProductId: productId}
+	var updatedAt time.Time
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&stock.OnHand, &updatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return entities.Stock{}, err
+	}
+	if err == nil {
+		stock.UpdatedAt = &updatedAt
+	}
+
+	stock.Reserved, err = reservedQuantity(ctx, r.db, psql, productId, now)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	stock.Available = stock.OnHand - stock.Reserved
+	return stock, nil
+}
+
+// lockStock creates the stock row of the product when missing and locks it
+// until the end of the transaction, returning the quantity on hand.
+func (r InventoryRepositoryPostgres) lockStock(ctx context.Context, tx *sql.Tx, productId uuid.UUID, now time.Time) (int, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Insert("product_stock").
+		Columns("product_id", "on_hand", "updated_at").
+		Values(productId, 0, now).
+		Suffix("ON CONFLICT (product_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	query, args, err = psql.Select("on_hand").
+		From("product_stock").
+		Where("product_id = ?", productId).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var onHand int
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&onHand)
+	return onHand, err
+}
+
+func (r InventoryRepositoryPostgres) AdjustStock(ctx context.Context, adjustment entities.StockAdjustment, now time.Time) (entities.Stock, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	defer tx.Rollback()
+
+	onHand, err := r.lockStock(ctx, tx, adjustment.ProductId, now)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	adjustment.OnHandAfter = onHand + adjustment.Delta
+	if adjustment.OnHandAfter < 0 {
+		return entities.Stock{}, entities.ErrInsufficientStock
+	}
+	if adjustment.Delta < 0 {
+		// the stock row lock also holds the reservations, as in ReserveStock
+		reserved, err := reservedQuantity(ctx, tx, psql, adjustment.ProductId, now)
+		if err != nil {
+			return entities.Stock{}, err
+		}
+		if adjustment.OnHandAfter < reserved {
+			return entities.Stock{}, entities.ErrReservedStock
+		}
+	}
+
+	query, args, err := psql.Update("product_stock").
+		Set("on_hand", adjustment.OnHandAfter).
+		Set("updated_at", now).
+		Where("product_id = ?", adjustment.ProductId).
+		ToSql()
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+
+	query, args, err = psql.Insert("stock_adjustments").
+		Columns("id", "product_id", "delta", "on_hand_after", "reason", "created_by", "created_at").
+		Values(adjustment.Id, adjustment.ProductId, adjustment.Delta, adjustment.OnHandAfter, adjustment.Reason, adjustment.CreatedBy, adjustment.CreatedAt).
+		ToSql()
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	return r.GetStock(ctx, adjustment.ProductId, now)
+}
+
+func (r InventoryRepositoryPostgres) GetStockAdjustments(ctx context.Context, productId uuid.UUID, page int, limit int) ([]entities.StockAdjustment, int, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	countQuery, countArgs, err := psql.Select("COUNT(*)").
+		From("stock_adjustments").
+		Where("product_id = ?", productId).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query, args, err := psql.Select("id", "product_id", "delta", "on_hand_after", "reason", "created_by", "created_at").
+		From("stock_adjustments").
+		Where("product_id = ?", productId).
+		OrderBy("created_at DESC", "id").
+		Limit(uint64(limit)).
+		Offset(uint64((page - 1) * limit)).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var adjustments []entities.StockAdjustment
+	for rows.Next() {
+		var adjustment entities.StockAdjustment
+		err = rows.Scan(&adjustment.Id, &adjustment.ProductId, &adjustment.Delta, &adjustment.OnHandAfter, &adjustment.Reason, &adjustment.CreatedBy, &adjustment.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+	return adjustments, totalCount, rows.Err()
+}
+
+func (r InventoryRepositoryPostgres) GetActiveReservations(ctx context.Context, productId uuid.UUID, now time.Time) ([]entities.StockReservation, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Select("id", "product_id", "quantity", "reference", "expires_at", "created_at").
+		From("stock_reservations").
+		Where("product_id = ? AND expires_at > ?", productId, now).
+		OrderBy("expires_at", "id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []entities.StockReservation
+	for rows.Next() {
+		var reservation entities.StockReservation
+		err = rows.Scan(&reservation.Id, &reservation.ProductId, &reservation.Quantity, &reservation.Reference, &reservation.ExpiresAt, &reservation.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, rows.Err()
+}
+
+func (r InventoryRepositoryPostgres) ReserveStock(ctx context.Context, reservation entities.StockReservation, now time.Time) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the stock row lock serializes reservations of the same product
+	onHand, err := r.lockStock(ctx, tx, reservation.ProductId, now)
+	if err != nil {
+		return err
+	}
+	reserved, err := reservedQuantity(ctx, tx, psql, reservation.ProductId, now)
+	if err != nil {
+		return err
+	}
+	if onHand-reserved < reservation.Quantity {
+		return entities.ErrInsufficientStock
+	}
+
+	query, args, err := psql.Insert("stock_reservations").
+		Columns("id", "product_id", "quantity", "reference", "expires_at", "created_at").
+		Values(reservation.Id, reservation.ProductId, reservation.Quantity, reservation.Reference, reservation.ExpiresAt, reservation.CreatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r InventoryRepositoryPostgres) ReleaseReservation(ctx context.Context, productId uuid.UUID, id uuid.UUID) (bool, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Delete("stock_reservations").
+		Where("id = ? AND product_id = ?", id, productId).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
@@ -0,0 +1,113 @@
+package inventory
+
+import (
+	"context"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InventoryRepositoryMemory struct {
+	store *memory.Store
+}
+
+func NewInventoryRepositoryMemory(store *memory.Store) entities.InventoryInterface {
+	return InventoryRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r InventoryRepositoryMemory) GetStock(ctx context.Context, productId uuid.UUID, now time.Time) (entities.Stock, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	return r.stock(productId, now), nil
+}
+
+func (r InventoryRepositoryMemory) stock(productId uuid.UUID, now time.Time) entities.Stock {
+	stock := r.store.Stock[productId]
+	stock.ProductId = productId
+	stock.Reserved = r.store.ReservedStock(productId, now)
+	stock.Available = stock.OnHand - stock.Reserved
+	return stock
+}
+
+func (r InventoryRepositoryMemory) AdjustStock(ctx context.Context, adjustment entities.StockAdjustment, now time.Time) (entities.Stock, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	stock := r.store.Stock[adjustment.ProductId]
+	adjustment.OnHandAfter = stock.OnHand + adjustment.Delta
+	if adjustment.OnHandAfter < 0 {
+		return entities.Stock{}, entities.ErrInsufficientStock
+	}
+	if adjustment.Delta < 0 && adjustment.OnHandAfter < r.store.ReservedStock(adjustment.ProductId, now) {
+		return entities.Stock{}, entities.ErrReservedStock
+	}
+	stock.OnHand = adjustment.OnHandAfter
+	stock.UpdatedAt = &now
+	r.store.Stock[adjustment.ProductId] = stock
+	r.store.StockAdjustments = append(r.store.StockAdjustments, adjustment)
+	return r.stock(adjustment.ProductId, now), nil
+}
+
+func (r InventoryRepositoryMemory) GetStockAdjustments(ctx context.Context, productId uuid.UUID, page int, limit int) ([]entities.StockAdjustment, int, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	var adjustments []entities.StockAdjustment
+	for _, adjustment := range r.store.StockAdjustments {
+		if adjustment.ProductId == productId {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	// newest first, the ledger is stored in insertion order
+	sort.SliceStable(adjustments, func(i, j int) bool {
+		return adjustments[i].CreatedAt.After(adjustments[j].CreatedAt)
+	})
+	return memory.Page(adjustments, page, limit), len(adjustments), nil
+}
+
+func (r InventoryRepositoryMemory) GetActiveReservations(ctx context.Context, productId uuid.UUID, now time.Time) ([]entities.StockReservation, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	var reservations []entities.StockReservation
+	for _, reservation := range r.store.StockReservations {
+		if reservation.ProductId == productId && reservation.ExpiresAt.After(now) {
+			reservations = append(reservations, reservation)
+		}
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		if !reservations[i].ExpiresAt.Equal(reservations[j].ExpiresAt) {
+			return reservations[i].ExpiresAt.Before(reservations[j].ExpiresAt)
+		}
+		return reservations[i].Id.String() < reservations[j].Id.String()
+	})
+	return reservations, nil
+}
+
+func (r InventoryRepositoryMemory) ReserveStock(ctx context.Context, reservation entities.StockReservation, now time.Time) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	if r.stock(reservation.ProductId, now).Available < reservation.Quantity {
+		return entities.ErrInsufficientStock
+	}
+	r.store.StockReservations[reservation.Id] = reservation
+	return nil
+}
+
+func (r InventoryRepositoryMemory) ReleaseReservation(ctx context.Context, productId uuid.UUID, id uuid.UUID) (bool, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	reservation, exists := r.store.StockReservations[id]
+	if !exists || reservation.ProductId != productId {
+		return false, nil
+	}
+	delete(r.store.StockReservations, id)
+	return true, nil
+}
@@ -0,0 +1,271 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/google/uuid"
+)
+
+type InventoryRepositorySqlServer struct {
+	db *sql.DB
+}
+
+func NewInventoryRepositorySqlServer(db *sql.DB) entities.InventoryInterface {
+	return InventoryRepositorySqlServer{
+		db: db,
+	}
+}
+
+func (r InventoryRepositorySqlServer) GetStock(ctx context.Context, productId uuid.UUID, now time.Time) (entities.Stock, error) {
+	query, args, err := sq.Select("on_hand", "updated_at").
+		From("product_stock").
+		Where("product_id = ?", productId.String()).
+		ToSql()
+	if err != nil {
+		return entities.Stock{}, err
+	}
+
+	stock := entities.Stock{ProductId: productId}
+	var updatedAt time.Time
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&stock.OnHand, &updatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return entities.Stock{}, err
+	}
+	if err == nil {
+		stock.UpdatedAt = &updatedAt
+	}
+
+	stock.Reserved, err = reservedQuantity(ctx, r.db, sq.StatementBuilder, productId.String(), now)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	stock.Available = stock.OnHand - stock.Reserved
+	return stock, nil
+}
+
+// lockStock creates the stock row of the product when missing and holds an
+// update lock on it until the end of the transaction.
+func (r InventoryRepositorySqlServer) lockStock(ctx context.Context, tx *sql.Tx, productId uuid.UUID, now time.Time) (int, error) {
+	// SQL Server has no ON CONFLICT, HOLDLOCK keeps two inserts from racing
+	_, err := tx.ExecContext(ctx, `INSERT INTO product_stock (product_id, on_hand, updated_at)
+		SELECT ?, 0, ? WHERE NOT EXISTS (SELECT 1 FROM product_stock WITH (UPDLOCK, HOLDLOCK) WHERE product_id = ?)`,
+		productId.String(), now, productId.String())
+	if err != nil {
+		return 0, err
+	}
+
+	query, args, err := sq.Select("on_hand").
+		From("product_stock WITH (UPDLOCK, ROWLOCK)").
+		Where("product_id = ?", productId.String()).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var onHand int
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&onHand)
+	return onHand, err
+}
+
+func (r InventoryRepositorySqlServer) AdjustStock(ctx context.Context, adjustment entities.StockAdjustment, now time.Time) (entities.Stock, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	defer tx.Rollback()
+
+	onHand, err := r.lockStock(ctx, tx, adjustment.ProductId, now)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	adjustment.OnHandAfter = onHand + adjustment.Delta
+	if adjustment.OnHandAfter < 0 {
+		return entities.Stock{}, entities.ErrInsufficientStock
+	}
+	if adjustment.Delta < 0 {
+		// the stock row lock also holds the reservations, as in ReserveStock
+		reserved, err := reservedQuantity(ctx, tx, sq.StatementBuilder, adjustment.ProductId.String(), now)
+		if err != nil {
+			return entities.Stock{}, err
+		}
+		if adjustment.OnHandAfter < reserved {
+			return entities.Stock{}, entities.ErrReservedStock
+		}
+	}
+
+	query, args, err := sq.Update("product_stock").
+		Set("on_hand", adjustment.OnHandAfter).
+		Set("updated_at", now).
+		Where("product_id = ?", adjustment.ProductId.String()).
+		ToSql()
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+
+	query, args, err = sq.Insert("stock_adjustments").
+		Columns("id", "product_id", "delta", "on_hand_after", "reason", "created_by", "created_at").
+		Values(adjustment.Id.String(), adjustment.ProductId.String(), adjustment.Delta, adjustment.OnHandAfter, adjustment.Reason, adjustment.CreatedBy, adjustment.CreatedAt).
+		ToSql()
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	return r.GetStock(ctx, adjustment.ProductId, now)
+}
+
+func (r InventoryRepositorySqlServer) GetStockAdjustments(ctx context.Context, productId uuid.UUID, page int, limit int) ([]entities.StockAdjustment, int, error) {
+	countQuery, countArgs, err := sq.Select("COUNT(*)").
+		From("stock_adjustments").
+		Where("product_id = ?", productId.String()).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query, args, err := sq.Select("id", "product_id", "delta", "on_hand_after", "reason", "created_by", "created_at").
+		From("stock_adjustments").
+		Where("product_id = ?", productId.String()).
+		OrderBy("created_at DESC", "id").
+		Suffix("OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", (page-1)*limit, limit).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var adjustments []entities.StockAdjustment
+	for rows.Next() {
+		adjustment, err := scanStockAdjustmentSqlServer(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+	return adjustments, totalCount, rows.Err()
+}
+
+func (r InventoryRepositorySqlServer) GetActiveReservations(ctx context.Context, productId uuid.UUID, now time.Time) ([]entities.StockReservation, error) {
+	query, args, err := sq.Select("id", "product_id", "quantity", "reference", "expires_at", "created_at").
+		From("stock_reservations").
+		Where("product_id = ? AND expires_at > ?", productId.String(), now).
+		OrderBy("expires_at", "id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []entities.StockReservation
+	for rows.Next() {
+		reservation, err := scanStockReservationSqlServer(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, rows.Err()
+}
+
+func (r InventoryRepositorySqlServer) ReserveStock(ctx context.Context, reservation entities.StockReservation, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the stock row lock serializes reservations of the same product
+	onHand, err := r.lockStock(ctx, tx, reservation.ProductId, now)
+	if err != nil {
+		return err
+	}
+	reserved, err := reservedQuantity(ctx, tx, sq.StatementBuilder, reservation.ProductId.String(), now)
+	if err != nil {
+		return err
+	}
+	if onHand-reserved < reservation.Quantity {
+		return entities.ErrInsufficientStock
+	}
+
+	query, args, err := sq.Insert("stock_reservations").
+		Columns("id", "product_id", "quantity", "reference", "expires_at", "created_at").
+		Values(reservation.Id.String(), reservation.ProductId.String(), reservation.Quantity, reservation.Reference, reservation.ExpiresAt, reservation.CreatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r InventoryRepositorySqlServer) ReleaseReservation(ctx context.Context, productId uuid.UUID, id uuid.UUID) (bool, error) {
+	query, args, err := sq.Delete("stock_reservations").
+		Where("id = ? AND product_id = ?", id.String(), productId.String()).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func scanStockAdjustmentSqlServer(row utils.RowScanner) (entities.StockAdjustment, error) {
+	var id, productId mssql.UniqueIdentifier
+	adjustment := entities.StockAdjustment{}
+	err := row.Scan(&id, &productId, &adjustment.Delta, &adjustment.OnHandAfter, &adjustment.Reason, &adjustment.CreatedBy, &adjustment.CreatedAt)
+	if err != nil {
+		return entities.StockAdjustment{}, err
+	}
+	adjustment.Id = uuid.UUID(id)
+	adjustment.ProductId = uuid.UUID(productId)
+	return adjustment, nil
+}
+
+func scanStockReservationSqlServer(row utils.RowScanner) (entities.StockReservation, error) {
+	var id, productId mssql.UniqueIdentifier
+	reservation := entities.StockReservation{}
+	err := row.Scan(&id, &productId, &reservation.Quantity, &reservation.Reference, &reservation.ExpiresAt, &reservation.CreatedAt)
+	if err != nil {
+		return entities.StockReservation{}, err
+	}
+	reservation.Id = uuid.UUID(id)
+	reservation.ProductId = uuid.UUID(productId)
+	return reservation, nil
+}
@@ -0,0 +1,46 @@
+package inventory
+
+import (
+	"net/http"
+	"rest-api-example/auth"
+	"rest-api-example/entities"
+	"rest-api-example/middlewares"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/cors"
+)
+
+func SetupInventoryRoutes(mux *mux.Router, h InventoryHandler, authService auth.AuthService) {
+	admin := mux.PathPrefix("/admin/products/{id}/stock").Subrouter()
+	admin.Use(cors.New(cors.Options{
+		AllowedOrigins: []string{"http://127.0.0.1:5500"},
+		AllowedMethods: []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+	}).Handler)
+	admin.Use(authService.AuthenticationMiddleware)
+	admin.HandleFunc("",
+		authService.RequirePermission(entities.PermissionStockRead,
+			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.GetStock))).Methods(http.MethodOptions,
+		http.MethodGet)
+	admin.HandleFunc("/adjustments",
+		authService.RequirePermission(entities.PermissionStockRead,
+			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.GetStockAdjustments))).Methods(http.MethodOptions,
+		http.MethodGet)
+	admin.HandleFunc("/adjustments",
+		authService.RequirePermission(entities.PermissionStockAdjust,
+			middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
+				middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.AdjustStock)))).Methods(http.MethodOptions,
+		http.MethodPost)
+	admin.HandleFunc("/reservations",
+		authService.RequirePermission(entities.PermissionStockRead,
+			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.GetActiveReservations))).Methods(http.MethodOptions,
+		http.MethodGet)
+	admin.HandleFunc("/reservations",
+		authService.RequirePermission(entities.PermissionStockAdjust,
+			middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
+				middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.ReserveStock)))).Methods(http.MethodOptions,
+		http.MethodPost)
+	admin.HandleFunc("/reservations/{reservationId}",
+		authService.RequirePermission(entities.PermissionStockAdjust, h.ReleaseReservation)).Methods(http.MethodOptions,
+		http.MethodDelete)
+}
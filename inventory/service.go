@@ -0,0 +1,193 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"rest-api-example/entities"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultReservationTTL = 15 * time.Minute
+	MaxReservationTTL     = 24 * time.Hour
+)
+
+var (
+	ErrProdutoNaoCadastrado      = errors.New("produto não cadastrado")
+	ErrProdutoInativo            = errors.New("produto inativo não pode ser reservado")
+	ErrQuantidadeAjusteInvalida  = errors.New("a quantidade do ajuste deve ser diferente de zero")
+	ErrMotivoAjusteObrigatorio   = errors.New("o motivo do ajuste deve ser informado")
+	ErrEstoqueInsuficiente       = errors.New("estoque insuficiente")
+	ErrEstoqueReservado          = errors.New("o ajuste deixaria o estoque abaixo da quantidade reservada")
+	ErrQuantidadeReservaInvalida = errors.New("a quantidade reservada deve ser maior que zero")
+	ErrValidadeReservaInvalida   = errors.New("a validade da reserva deve ser de até 24 horas")
+	ErrReservaNaoEncontrada      = errors.New("reserva não encontrada")
+	ErrReferenciaReservaInvalida = errors.New("a referência da reserva deve ter até 255 caracteres")
+)
+
+type InventoryService struct {
+	inventoryRepository entities.InventoryInterface
+	productRepository   entities.ProductInterface
+}
+
+func NewInventoryService(i entities.InventoryInterface, p entities.ProductInterface) InventoryService {
+	return InventoryService{
+		inventoryRepository: i,
+		productRepository:   p,
+	}
+}
+
+func (s InventoryService) GetStock(ctx context.Context, productId uuid.UUID) (entities.Stock, error) {
+	op := "InventoryService.GetStock()"
+	_, err := s.getProduct(ctx, productId, op)
+	if err != nil {
+		return entities.Stock{}, err
+	}
+	stock, err := s.inventoryRepository.GetStock(ctx, productId, time.Now().UTC())
+	if err != nil {
+		return entities.Stock{}, entities.NewInternalServerErrorError(err, op)
+	}
+	return stock, nil
+}
+
+// AdjustStock adds delta (negative to remove) to the quantity on hand and
+// records it in the ledger. The stock on hand can never become negative, and
+// a removal cannot leave less than the valid reservations hold.
+func (s InventoryService) AdjustStock(ctx context.Context, productId uuid.UUID, delta int, reason string, login string) (entities.StockAdjustment, entities.Stock, error) {
+	op := "InventoryService.AdjustStock()"
+	var fields []entities.FieldError
+	if delta == 0 {
+		fields = append(fields, entities.FieldError{Field: "delta", Message: ErrQuantidadeAjusteInvalida.Error()})
+	}
+	if reason == "" {
+		fields = append(fields, entities.FieldError{Field: "reason", Message: ErrMotivoAjusteObrigatorio.Error()})
+	}
+	if len(fields) > 0 {
+		return entities.StockAdjustment{}, entities.Stock{}, entities.NewUnprocessableEntityError(errors.New(fields[0].Message), fields[0].Message, op, fields...)
+	}
+	_, err := s.getProduct(ctx, productId, op)
+	if err != nil {
+		return entities.StockAdjustment{}, entities.Stock{}, err
+	}
+
+	now := time.Now().UTC()
+	adjustment := entities.StockAdjustment{
+		Id:        uuid.New(),
+		ProductId: productId,
+		Delta:     delta,
+		Reason:    reason,
+		CreatedBy: login,
+		CreatedAt: now,
+	}
+	stock, err := s.inventoryRepository.AdjustStock(ctx, adjustment, now)
+	if errors.Is(err, entities.ErrInsufficientStock) {
+		return entities.StockAdjustment{}, entities.Stock{}, entities.NewConflictError(err, ErrEstoqueInsuficiente.Error(), op)
+	}
+	if errors.Is(err, entities.ErrReservedStock) {
+		return entities.StockAdjustment{}, entities.Stock{}, entities.NewConflictError(err, ErrEstoqueReservado.Error(), op)
+	}
+	if err != nil {
+		return entities.StockAdjustment{}, entities.Stock{}, entities.NewInternalServerErrorError(err, op)
+	}
+	adjustment.OnHandAfter = stock.OnHand
+	return adjustment, stock, nil
+}
+
+func (s InventoryService) GetStockAdjustments(ctx context.Context, productId uuid.UUID, page int, limit int) ([]entities.StockAdjustment, int, error) {
+	op := "InventoryService.GetStockAdjustments()"
+	_, err := s.getProduct(ctx, productId, op)
+	if err != nil {
+		return nil, 0, err
+	}
+	adjustments, totalCount, err := s.inventoryRepository.GetStockAdjustments(ctx, productId, page, limit)
+	if err != nil {
+		return nil, 0, entities.NewInternalServerErrorError(err, op)
+	}
+	return adjustments, totalCount, nil
+}
+
+func (s InventoryService) GetActiveReservations(ctx context.Context, productId uuid.UUID) ([]entities.StockReservation, error) {
+	op := "InventoryService.GetActiveReservations()"
+	_, err := s.getProduct(ctx, productId, op)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := s.inventoryRepository.GetActiveReservations(ctx, productId, time.Now().UTC())
+	if err != nil {
+		return nil, entities.NewInternalServerErrorError(err, op)
+	}
+	return reservations, nil
+}
+
+// ReserveStock holds quantity units of an active product for ttl, or for
+// DefaultReservationTTL when ttl is zero. Reference identifies the owner of the
+// reservation, such as a cart.
+func (s InventoryService) ReserveStock(ctx context.Context, productId uuid.UUID, quantity int, ttl time.Duration, reference string) (entities.StockReservation, error) {
+	op := "InventoryService.ReserveStock()"
+	if ttl == 0 {
+		ttl = DefaultReservationTTL
+	}
+	var fields []entities.FieldError
+	if quantity <= 0 {
+		fields = append(fields, entities.FieldError{Field: "quantity", Message: ErrQuantidadeReservaInvalida.Error()})
+	}
+	if ttl < 0 || ttl > MaxReservationTTL {
+		fields = append(fields, entities.FieldError{Field: "ttl_seconds", Message: ErrValidadeReservaInvalida.Error()})
+	}
+	if len(reference) > 255 {
+		fields = append(fields, entities.FieldError{Field: "reference", Message: ErrReferenciaReservaInvalida.Error()})
+	}
+	if len(fields) > 0 {
+		return entities.StockReservation{}, entities.NewUnprocessableEntityError(errors.New(fields[0].Message), fields[0].Message, op, fields...)
+	}
+	product, err := s.getProduct(ctx, productId, op)
+	if err != nil {
+		return entities.StockReservation{}, err
+	}
+	if !product.Active {
+		return entities.StockReservation{}, entities.NewConflictError(ErrProdutoInativo, ErrProdutoInativo.Error(), op)
+	}
+
+	now := time.Now().UTC()
+	reservation := entities.StockReservation{
+		Id:        uuid.New(),
+		ProductId: productId,
+		Quantity:  quantity,
+		Reference: reference,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	err = s.inventoryRepository.ReserveStock(ctx, reservation, now)
+	if errors.Is(err, entities.ErrInsufficientStock) {
+		return entities.StockReservation{}, entities.NewConflictError(err, ErrEstoqueInsuficiente.Error(), op)
+	}
+	if err != nil {
+		return entities.StockReservation{}, entities.NewInternalServerErrorError(err, op)
+	}
+	return reservation, nil
+}
+
+func (s InventoryService) ReleaseReservation(ctx context.Context, productId uuid.UUID, id uuid.UUID) error {
+	op := "InventoryService.ReleaseReservation()"
+	released, err := s.inventoryRepository.ReleaseReservation(ctx, productId, id)
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	if !released {
+		return entities.NewNotFoundError(ErrReservaNaoEncontrada, ErrReservaNaoEncontrada.Error(), op)
+	}
+	return nil
+}
+
+func (s InventoryService) getProduct(ctx context.Context, productId uuid.UUID, op string) (entities.Product, error) {
+	product, err := s.productRepository.GetProductById(ctx, productId)
+	if err != nil {
+		return entities.Product{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if product.IsEmpty() {
+		return entities.Product{}, entities.NewNotFoundError(ErrProdutoNaoCadastrado, ErrProdutoNaoCadastrado.Error(), op)
+	}
+	return product, nil
+}
@@ -0,0 +1,61 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+	"rest-api-example/product"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAdjustStockKeepsTheReservations(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	productId := uuid.New()
+	store.Products[productId] = entities.Product{Id: productId, Name: "Caneca", Active: true}
+	service := NewInventoryService(NewInventoryRepositoryMemory(store), product.NewProductRepositoryMemory(store))
+
+	_, _, err := service.AdjustStock(ctx, productId, 10, "compra", "ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.ReserveStock(ctx, productId, 6, 0, "pedido-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		delta int
+		// message is empty for the accepted adjustments
+		message string
+		onHand  int
+	}{
+		{name: "below the reservations", delta: -5, message: ErrEstoqueReservado.Error(), onHand: 10},
+		{name: "below zero", delta: -11, message: ErrEstoqueInsuficiente.Error(), onHand: 10},
+		{name: "down to the reservations", delta: -4, onHand: 6},
+		{name: "addition", delta: 1, onHand: 7},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := service.AdjustStock(ctx, productId, test.delta, "inventário", "ana")
+			if test.message == "" && err != nil {
+				t.Fatalf("AdjustStock() error = %v, want nil", err)
+			}
+			var apiError *entities.Error
+			if test.message != "" && (!errors.As(err, &apiError) || apiError.Code != entities.CONFLICT || apiError.Message != test.message) {
+				t.Fatalf("AdjustStock() error = %v, want a 409 %q", err, test.message)
+			}
+			stock, err := service.GetStock(ctx, productId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stock.OnHand != test.onHand || stock.Reserved != 6 {
+				t.Errorf("on hand = %d, reserved = %d, want %d and 6", stock.OnHand, stock.Reserved, test.onHand)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package listing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"rest-api-example/entities"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+const (
+	CursorParam = "cursor"
+	CountParam  = "count"
+	// DefaultCursorLimit and MaxCursorLimit bound the limit of a cursor page.
+	DefaultCursorLimit = 10
+	MaxCursorLimit     = 100
+)
+
+var (
+	ErrCursorInvalido       = errors.New("cursor inválido, use os links de paginação da resposta")
+	ErrCursorOutrosFiltros  = errors.New("o cursor foi gerado com outros filtros ou outra ordenação")
+	ErrContagemInvalida     = errors.New("count deve ser true ou false")
+	errCursorKeysMismatched = errors.New("the keys of the cursor do not match the sort")
+)
+
+// CursorCodec signs the cursors, so clients can only send back the ones the
+// API gave them.
+type CursorCodec struct {
+	secret []byte
+}
+
+// cursorPayload is encoded in the cursor. Filters is a digest of the filters
+// and the sort of the list, which the cursor is only valid for.
+type cursorPayload struct {
+	Keys     []string `json:"k"`
+	Backward bool     `json:"b,omitempty"`
+	Filters  []byte   `json:"f"`
+}
+
+// NewCursorCodec signs with secret or, when it is empty, with a random key,
+// in which case cursors stop working when the API restarts.
+func NewCursorCodec(secret string) CursorCodec {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return CursorCodec{secret: key}
+}
+
+// Encode writes the cursor of the page after, or before, the item with keys
+// in the list with the given filters.
+func (c CursorCodec) Encode(keys []string, backward bool, filters string) string {
+	digest := sha256.Sum256([]byte(filters))
+	payload, _ := json.Marshal(cursorPayload{Keys: keys, Backward: backward, Filters: digest[:8]})
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
+
+// Decode checks the signature of the cursor and that it belongs to a list
+// with the given filters. An empty cursor is the first page.
+func (c CursorCodec) Decode(cursor string, filters string) (entities.Cursor, error) {
+	op := "listing.CursorCodec.Decode()"
+	if cursor == "" {
+		return entities.Cursor{}, nil
+	}
+	invalid := entities.NewBadRequestError(ErrCursorInvalido, ErrCursorInvalido.Error(), op,
+		entities.FieldError{Field: CursorParam, Message: ErrCursorInvalido.Error()})
+
+	encodedPayload, encodedSignature, found := strings.Cut(cursor, ".")
+	if !found {
+		return entities.Cursor{}, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return entities.Cursor{}, invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return entities.Cursor{}, invalid
+	}
+	var decoded cursorPayload
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return entities.Cursor{}, invalid
+	}
+	digest := sha256.Sum256([]byte(filters))
+	if !bytes.Equal(decoded.Filters, digest[:8]) {
+		return entities.Cursor{}, entities.NewBadRequestError(ErrCursorOutrosFiltros, ErrCursorOutrosFiltros.Error(), op,
+			entities.FieldError{Field: CursorParam, Message: ErrCursorOutrosFiltros.Error()})
+	}
+	return entities.Cursor{Keys: decoded.Keys, Backward: decoded.Backward}, nil
+}
+
+// Parse reads the cursor, the limit and the count parameters of a request to
+// a list with the given filters. The limit is clamped to MaxCursorLimit.
+func (c CursorCodec) Parse(params map[string][]string, filters string) (entities.Cursor, error) {
+	op := "listing.CursorCodec.Parse()"
+	value := func(name string) string {
+		if values := params[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	cursor, err := c.Decode(value(CursorParam), filters)
+	if err != nil {
+		return entities.Cursor{}, err
+	}
+	cursor.Limit = DefaultCursorLimit
+	if limit, err := strconv.Atoi(value("limit")); err == nil {
+		cursor.Limit = min(max(limit, 1), MaxCursorLimit)
+	}
+	if count := value(CountParam); count != "" {
+		withCount, err := strconv.ParseBool(count)
+		if err != nil {
+			return entities.Cursor{}, entities.NewBadRequestError(err, ErrContagemInvalida.Error(), op,
+				entities.FieldError{Field: CountParam, Message: ErrContagemInvalida.Error()})
+		}
+		cursor.SkipCount = !withCount
+	}
+	return cursor, nil
+}
+
+func (c CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Paginate trims the items a repository returned for cursor, one more than
+// the limit when there are more, and returns the cursors of the next and the
+// previous pages, empty when there is none.
+func Paginate[T any](codec CursorCodec, list Query, items []T, cursor entities.Cursor, filters string, values func(T) Values) ([]T, string, string) {
+	more := len(items) > cursor.Limit
+	if more && cursor.Backward {
+		// the extra item of a backward page is the one before the others
+		items = items[1:]
+	} else if more {
+		items = items[:cursor.Limit]
+	}
+	if len(items) == 0 {
+		return items, "", ""
+	}
+
+	var next, prev string
+	if more || cursor.Backward {
+		next = codec.Encode(list.Keys(values(items[len(items)-1])), false, filters)
+	}
+	if (more && cursor.Backward) || (!cursor.Backward && len(cursor.Keys) > 0) {
+		prev = codec.Encode(list.Keys(values(items[0])), true, filters)
+	}
+	return items, next, prev
+}
+
+// Keys returns the values of the sort fields of the item, the id last, to be
+// encoded in a cursor.
+func (q Query) Keys(values Values) []string {
+	keys := make([]string, 0, len(q.Sort)+1)
+	for _, sort := range append(slices.Clone(q.Sort), Sort{Field: q.schema.IdColumn}) {
+		keys = append(keys, fmt.Sprint(values(sort.Field)))
+	}
+	return keys
+}
+
+// Seek returns the predicate of the items after the keys of the cursor in
+// the order of the list, or before them when it goes backward.
+func (q Query) Seek(cursor entities.Cursor) (sq.Sqlizer, error) {
+	if len(cursor.Keys) == 0 {
+		return sq.And{}, nil
+	}
+	keys, err := q.keyValues(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]Field, 0, len(q.Sort)+1)
+	for _, sort := range q.Sort {
+		field, _ := q.schema.field(sort.Field)
+		columns = append(columns, field)
+	}
+	columns = append(columns, Field{Column: q.schema.IdColumn})
+
+	// the databases compare the uuid of the id as text
+	keys[len(keys)-1] = keys[len(keys)-1].(uuid.UUID).String()
+
+	// (a > ka) OR (a = ka AND b > kb) OR ..., with < for the descending fields
+	seek := sq.Or{}
+	for index, column := range columns {
+		term := sq.And{}
+		for previous := range index {
+			term = append(term, columns[previous].compare("%s = ?", keys[previous]))
+		}
+		comparison := "%s > ?"
+		if q.descending(index) != cursor.Backward {
+			comparison = "%s < ?"
+		}
+		term = append(term, column.compare(comparison, keys[index]))
+		seek = append(seek, term)
+	}
+	return seek, nil
+}
+
+// CursorOrderBy returns the ORDER BY terms of a page of the cursor, reversed
+// when it goes backward; the repository reverses the rows back.
+func (q Query) CursorOrderBy(cursor entities.Cursor) []string {
+	orderBy := q.OrderBy()
+	if !cursor.Backward {
+		return orderBy
+	}
+	for index := range orderBy {
+		if q.descending(index) {
+			orderBy[index] = strings.TrimSuffix(orderBy[index], " DESC")
+		} else {
+			orderBy[index] += " DESC"
+		}
+	}
+	return orderBy
+}
+
+// Window returns from items, already filtered, what a SQL repository returns
+// for the cursor: up to Limit+1 items past the keys, in the order of the list.
+func Window[T any](q Query, items []T, values func(T) Values, cursor entities.Cursor) ([]T, error) {
+	keys, err := q.keyValues(cursor)
+	if err != nil {
+		return nil, err
+	}
+	position := func(item T) int {
+		if len(keys) == 0 {
+			return 1
+		}
+		itemValues := values(item)
+		for index, sort := range append(slices.Clone(q.Sort), Sort{Field: q.schema.IdColumn}) {
+			result := compareValues(itemValues(sort.Field), keys[index])
+			if q.descending(index) {
+				result = -result
+			}
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	}
+
+	slices.SortFunc(items, func(a T, b T) int {
+		return q.Compare(values(a), values(b))
+	})
+	var window []T
+	if cursor.Backward {
+		for index := len(items) - 1; index >= 0 && len(window) <= cursor.Limit; index-- {
+			if position(items[index]) < 0 {
+				window = append(window, items[index])
+			}
+		}
+		slices.Reverse(window)
+		return window, nil
+	}
+	for _, item := range items {
+		if len(window) > cursor.Limit {
+			break
+		}
+		if position(item) > 0 {
+			window = append(window, item)
+		}
+	}
+	return window, nil
+}
+
+func (q Query) descending(index int) bool {
+	return index < len(q.Sort) && q.Sort[index].Descending
+}
+
+// keyValues parses the keys of the cursor back into the values of the sort
+// fields.
+func (q Query) keyValues(cursor entities.Cursor) ([]any, error) {
+	if len(cursor.Keys) == 0 {
+		return nil, nil
+	}
+	if len(cursor.Keys) != len(q.Sort)+1 {
+		return nil, errCursorKeysMismatched
+	}
+	keys := make([]any, len(cursor.Keys))
+	for index, sort := range q.Sort {
+		field, _ := q.schema.field(sort.Field)
+		switch field.Kind {
+		case Decimal:
+			value, err := entities.ParseMoney(cursor.Keys[index], field.Currency)
+			if err != nil {
+				return nil, err
+			}
+			keys[index] = value
+		case Time:
+			value, err := time.Parse(time.RFC3339Nano, cursor.Keys[index])
+			if err != nil {
+				return nil, err
+			}
+			keys[index] = value
+		default:
+			keys[index] = cursor.Keys[index]
+		}
+	}
+	id, err := uuid.Parse(cursor.Keys[len(q.Sort)])
+	if err != nil {
+		return nil, err
+	}
+	keys[len(q.Sort)] = id
+	return keys, nil
+}
@@ -0,0 +1,219 @@
+package listing
+
+import (
+	"encoding/base64"
+	"errors"
+	"rest-api-example/entities"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorCodecDecode(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	cursor := codec.Encode([]string{"10.50", uuid.Nil.String()}, true, "&sort=-price")
+	payload, signature, _ := strings.Cut(cursor, ".")
+	forged, _ := base64.RawURLEncoding.DecodeString(payload)
+	forged = []byte(strings.Replace(string(forged), "10.50", "99.99", 1))
+
+	tests := []struct {
+		name    string
+		codec   CursorCodec
+		cursor  string
+		filters string
+		err     error
+	}{
+		{name: "first page", codec: codec, cursor: "", filters: "&sort=-price"},
+		{name: "signed cursor", codec: codec, cursor: cursor, filters: "&sort=-price"},
+		{name: "other filters", codec: codec, cursor: cursor, filters: "&sort=price", err: ErrCursorOutrosFiltros},
+		{name: "other secret", codec: NewCursorCodec("other"), cursor: cursor, filters: "&sort=-price", err: ErrCursorInvalido},
+		{name: "random secret", codec: NewCursorCodec(""), cursor: cursor, filters: "&sort=-price", err: ErrCursorInvalido},
+		{
+			name:    "tampered keys",
+			codec:   codec,
+			cursor:  base64.RawURLEncoding.EncodeToString(forged) + "." + signature,
+			filters: "&sort=-price",
+			err:     ErrCursorInvalido,
+		},
+		{name: "no signature", codec: codec, cursor: payload, filters: "&sort=-price", err: ErrCursorInvalido},
+		{name: "not base64", codec: codec, cursor: "!." + signature, filters: "&sort=-price", err: ErrCursorInvalido},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := test.codec.Decode(test.cursor, test.filters)
+			var apiError *entities.Error
+			if test.err != nil {
+				if !errors.As(err, &apiError) || apiError.Err != test.err {
+					t.Fatalf("Decode() error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.cursor != "" && (!decoded.Backward || !slices.Equal(decoded.Keys, []string{"10.50", uuid.Nil.String()})) {
+				t.Errorf("Decode() = %+v", decoded)
+			}
+		})
+	}
+}
+
+func TestCursorCodecParse(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	tests := []struct {
+		name      string
+		params    map[string][]string
+		limit     int
+		skipCount bool
+		err       bool
+	}{
+		{name: "defaults", params: map[string][]string{}, limit: DefaultCursorLimit},
+		{name: "limit", params: map[string][]string{"limit": {"25"}}, limit: 25},
+		{name: "limit above the max", params: map[string][]string{"limit": {"1000"}}, limit: MaxCursorLimit},
+		{name: "limit below one", params: map[string][]string{"limit": {"0"}}, limit: 1},
+		{name: "without count", params: map[string][]string{"count": {"false"}}, limit: DefaultCursorLimit, skipCount: true},
+		{name: "invalid count", params: map[string][]string{"count": {"talvez"}}, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cursor, err := codec.Parse(test.params, "")
+			if (err != nil) != test.err {
+				t.Fatalf("Parse() error = %v, want error %v", err, test.err)
+			}
+			if err == nil && (cursor.Limit != test.limit || cursor.SkipCount != test.skipCount) {
+				t.Errorf("Parse() = %+v, want limit %d and skip count %v", cursor, test.limit, test.skipCount)
+			}
+		})
+	}
+}
+
+type pricedItem struct {
+	id    uuid.UUID
+	price int64
+}
+
+func (i pricedItem) values(field string) any {
+	if field == "price" {
+		return entities.NewMoney(i.price, entities.DefaultCurrency)
+	}
+	return i.id
+}
+
+// TestPaginateWalk follows the next links to the last page and the prev
+// links back, as a client does, over prices with ties broken by the id.
+func TestPaginateWalk(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	var items []pricedItem
+	for _, price := range []int64{500, 100, 300, 300, 200, 300, 400, 100} {
+		items = append(items, pricedItem{id: uuid.New(), price: price})
+	}
+	values := func(item pricedItem) Values { return item.values }
+
+	for _, sort := range []string{"price", "-price"} {
+		t.Run(sort, func(t *testing.T) {
+			list, err := testSchema.Parse(map[string][]string{"sort": {sort}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			filters := list.Encode()
+			expected := slices.Clone(items)
+			slices.SortFunc(expected, func(a pricedItem, b pricedItem) int { return list.Compare(a.values, b.values) })
+
+			page := func(encoded string) ([]pricedItem, string, string) {
+				t.Helper()
+				cursor, err := codec.Decode(encoded, filters)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cursor.Limit = 3
+				window, err := Window(list, slices.Clone(items), values, cursor)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return Paginate(codec, list, window, cursor, filters, values)
+			}
+
+			var forward []pricedItem
+			var pages []string
+			next := ""
+			for {
+				pages = append(pages, next)
+				found, nextCursor, _ := page(next)
+				forward = append(forward, found...)
+				if nextCursor == "" {
+					break
+				}
+				next = nextCursor
+			}
+			if !slices.Equal(forward, expected) {
+				t.Fatalf("the next links returned %v, want %v", forward, expected)
+			}
+
+			var backward []pricedItem
+			_, _, prev := page(pages[len(pages)-1])
+			for prev != "" {
+				found, _, prevCursor := page(prev)
+				backward = append(found, backward...)
+				prev = prevCursor
+			}
+			last, _, _ := page(pages[len(pages)-1])
+			if !slices.Equal(append(backward, last...), expected) {
+				t.Errorf("the prev links returned %v, want %v", append(backward, last...), expected)
+			}
+		})
+	}
+}
+
+func TestQuerySeek(t *testing.T) {
+	list, err := testSchema.Parse(map[string][]string{"sort": {"-price"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"10.50", "00000000-0000-0000-0000-000000000001"}
+
+	tests := []struct {
+		name    string
+		cursor  entities.Cursor
+		seek    string
+		orderBy []string
+		err     error
+	}{
+		{name: "first page", cursor: entities.Cursor{}, seek: "(1=1)", orderBy: []string{"price DESC", "id"}},
+		{
+			name:    "forward",
+			cursor:  entities.Cursor{Keys: keys},
+			seek:    "((p.price < ?) OR (p.price = ? AND id > ?))",
+			orderBy: []string{"price DESC", "id"},
+		},
+		{
+			name:    "backward",
+			cursor:  entities.Cursor{Keys: keys, Backward: true},
+			seek:    "((p.price > ?) OR (p.price = ? AND id < ?))",
+			orderBy: []string{"price", "id DESC"},
+		},
+		{name: "keys of another sort", cursor: entities.Cursor{Keys: keys[1:]}, err: errCursorKeysMismatched},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seek, err := list.Seek(test.cursor)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Seek() error = %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+			sql, _, err := seek.ToSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != test.seek {
+				t.Errorf("Seek() = %q, want %q", sql, test.seek)
+			}
+			if orderBy := list.CursorOrderBy(test.cursor); !slices.Equal(orderBy, test.orderBy) {
+				t.Errorf("CursorOrderBy() = %v, want %v", orderBy, test.orderBy)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+// Package listing parses the filters and the sorting of the list endpoints,
+// such as GET /products?price[gte]=10&sort=-price,name, against the fields a
+// resource allows, and applies them to SQL queries and in-memory lists.
+package listing
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"rest-api-example/entities"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+const SortParam = "sort"
+
+var (
+	ErrFiltroInvalido       = errors.New("filtros ou ordenação inválidos")
+	ErrCampoDesconhecido    = errors.New("campo desconhecido")
+	ErrOperadorInvalido     = errors.New("operador não permitido para o campo")
+	ErrCampoNaoOrdenavel    = errors.New("o campo não pode ser usado na ordenação")
+	ErrValorTextoInvalido   = errors.New("deve ser informado um texto")
+	ErrValorDecimalInvalido = errors.New("deve ser um número decimal")
+	ErrValorBoolInvalido    = errors.New("deve ser true ou false")
+	ErrValorDataInvalido    = errors.New("deve ser uma data AAAA-MM-DD ou um horário RFC 3339")
+	ErrValorUUIDInvalido    = errors.New("deve ser um UUID")
+)
+
+// paramPattern splits "price[gte]" into the field and the operator.
+var paramPattern = regexp.MustCompile(`^([a-z_]+)(?:\[([a-z]+)\])?$`)
+
+type Operator string
+
+const (
+	Eq       Operator = "eq"
+	Ne       Operator = "ne"
+	Gt       Operator = "gt"
+	Gte      Operator = "gte"
+	Lt       Operator = "lt"
+	Lte      Operator = "lte"
+	Contains Operator = "contains"
+)
+
+// operatorOrder is the order of the filters of a field in Encode.
+var operatorOrder = []Operator{Eq, Ne, Gt, Gte, Lt, Lte, Contains}
+
+var comparisonSql = map[Operator]string{
+	Eq:  "=",
+	Ne:  "<>",
+	Gt:  ">",
+	Gte: ">=",
+	Lt:  "<",
+	Lte: "<=",
+}
+
+type Kind int
+
+const (
+	Text Kind = iota
+	// Decimal values are entities.Money in the Currency of the field.
+	Decimal
+	Bool
+	// Time values are time.Time. A date alone means the whole day, so
+	// created_at[lte]=2024-01-31 includes the 31st.
+	Time
+	UUID
+)
+
+var (
+	TextOperators     = []Operator{Eq, Ne, Contains}
+	RangeOperators    = []Operator{Eq, Ne, Gt, Gte, Lt, Lte}
+	EqualityOperators = []Operator{Eq, Ne}
+)
+
+// Field is a field clients may filter or sort by.
+type Field struct {
+	Name string
+	// Column is the SQL expression the filters compare, with Args bound to
+	// its placeholders. Sorting uses Name, so a sortable field must be
+	// selected under its own name.
+	Column    string
+	Args      []any
+	Kind      Kind
+	Currency  string
+	Operators []Operator
+	Sortable  bool
+	// Condition, when set, builds the predicate of the filters instead of
+	// comparing Column, for fields such as the category of a product that
+	// live in other tables.
+	Condition func(operator Operator, value any) sq.Sqlizer
+}
+
+// Schema lists the fields of a resource. Reserved names the parameters that
+// are not filters, such as page and limit, which Parse ignores.
+type Schema struct {
+	Fields   []Field
+	Reserved []string
+	// DefaultSort is used when the request has no sort parameter.
+	DefaultSort []Sort
+	// IdColumn breaks the ties of every sort, so pages never overlap.
+	IdColumn string
+}
+
+type Filter struct {
+	Field    string
+	Operator Operator
+	Value    any
+	raw      string
+}
+
+type Sort struct {
+	Field      string
+	Descending bool
+}
+
+// Query holds the filters and the sort of a request.
+type Query struct {
+	Filters []Filter
+	Sort    []Sort
+	schema  Schema
+	// explicitSort tells whether Sort came from the request.
+	explicitSort bool
+}
+
+// Parse reads the filters, written as field=value or field[operator]=value,
+// and the sort, a comma separated list of fields with a leading "-" for the
+// descending ones. Every problem found is reported in one 400 error.
+func (s Schema) Parse(params map[string][]string) (Query, error) {
+	op := "listing.Schema.Parse()"
+	query := Query{schema: s, Sort: s.DefaultSort}
+	var fields []entities.FieldError
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if name == SortParam || slices.Contains(s.Reserved, name) {
+			continue
+		}
+		matches := paramPattern.FindStringSubmatch(name)
+		if matches == nil {
+			fields = append(fields, entities.FieldError{Field: name, Message: ErrCampoDesconhecido.Error()})
+			continue
+		}
+		field, exists := s.field(matches[1])
+		if !exists {
+			fields = append(fields, entities.FieldError{Field: name, Message: ErrCampoDesconhecido.Error()})
+			continue
+		}
+		operator := Eq
+		if matches[2] != "" {
+			operator = Operator(matches[2])
+		}
+		if !slices.Contains(field.Operators, operator) {
+			fields = append(fields, entities.FieldError{Field: name, Message: ErrOperadorInvalido.Error()})
+			continue
+		}
+		for _, raw := range params[name] {
+			value, err := field.parse(raw)
+			if err != nil {
+				fields = append(fields, entities.FieldError{Field: name, Message: err.Error()})
+				continue
+			}
+			query.Filters = append(query.Filters, Filter{Field: field.Name, Operator: operator, Value: value, raw: raw})
+		}
+	}
+
+	if values, exists := params[SortParam]; exists {
+		query.Sort = nil
+		query.explicitSort = true
+		seen := make(map[string]bool)
+		for _, value := range values {
+			for _, item := range strings.Split(value, ",") {
+				name := strings.TrimSpace(item)
+				descending := strings.HasPrefix(name, "-")
+				name = strings.TrimLeft(name, "+-")
+				field, exists := s.field(name)
+				if !exists || !field.Sortable {
+					fields = append(fields, entities.FieldError{Field: SortParam, Message: fmt.Sprintf("%s: %s", name, ErrCampoNaoOrdenavel.Error())})
+					continue
+				}
+				if !seen[name] {
+					seen[name] = true
+					query.Sort = append(query.Sort, Sort{Field: name, Descending: descending})
+				}
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return Query{}, entities.NewBadRequestError(ErrFiltroInvalido, ErrFiltroInvalido.Error(), op, fields...)
+	}
+	return query, nil
+}
+
+func (s Schema) field(name string) (Field, bool) {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+func (f Field) parse(raw string) (any, error) {
+	switch f.Kind {
+	case Decimal:
+		value, err := entities.ParseMoney(raw, f.Currency)
+		if err != nil {
+			return nil, ErrValorDecimalInvalido
+		}
+		return value, nil
+	case Bool:
+		// "active=1" was the only filter before, so numbers are still accepted
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, ErrValorBoolInvalido
+		}
+		return value, nil
+	case Time:
+		if value, err := time.Parse(time.DateOnly, raw); err == nil {
+			return value, nil
+		}
+		value, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, ErrValorDataInvalido
+		}
+		return value.UTC(), nil
+	case UUID:
+		value, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, ErrValorUUIDInvalido
+		}
+		return value, nil
+	}
+	if raw == "" {
+		return nil, ErrValorTextoInvalido
+	}
+	return raw, nil
+}
+
+// Where returns the filters as a predicate, true when there are none.
+func (q Query) Where() sq.Sqlizer {
+	predicates := sq.And{}
+	for _, filter := range q.Filters {
+		field, _ := q.schema.field(filter.Field)
+		if field.Condition != nil {
+			predicates = append(predicates, field.Condition(filter.Operator, filter.Value))
+			continue
+		}
+		if filter.Operator == Contains {
+			pattern := "%" + likeEscaper.Replace(strings.ToLower(filter.Value.(string))) + "%"
+			predicates = append(predicates, field.compare("LOWER(%s) LIKE ? ESCAPE '\\'", pattern))
+			continue
+		}
+		if start, end, isDay := filter.day(); isDay {
+			switch filter.Operator {
+			case Eq:
+				predicates = append(predicates, sq.And{field.compare("%s >= ?", start), field.compare("%s < ?", end)})
+			case Ne:
+				predicates = append(predicates, sq.Or{field.compare("%s < ?", start), field.compare("%s >= ?", end)})
+			case Gt:
+				predicates = append(predicates, field.compare("%s >= ?", end))
+			case Gte:
+				predicates = append(predicates, field.compare("%s >= ?", start))
+			case Lt:
+				predicates = append(predicates, field.compare("%s < ?", start))
+			case Lte:
+				predicates = append(predicates, field.compare("%s < ?", end))
+			}
+			continue
+		}
+		value := filter.Value
+		if id, isUUID := value.(uuid.UUID); isUUID {
+			value = id.String()
+		}
+		predicates = append(predicates, field.compare("%s "+comparisonSql[filter.Operator]+" ?", value))
+	}
+	return predicates
+}
+
+// compare formats the column of the field into format, binding its arguments
+// before value.
+func (f Field) compare(format string, value any) sq.Sqlizer {
+	return sq.Expr(fmt.Sprintf(format, f.Column), append(slices.Clone(f.Args), value)...)
+}
+
+// OrderBy returns the ORDER BY terms of the sort, ending with the id column.
+func (q Query) OrderBy() []string {
+	orderBy := make([]string, 0, len(q.Sort)+1)
+	for _, sort := range q.Sort {
+		term := sort.Field
+		if sort.Descending {
+			term += " DESC"
+		}
+		orderBy = append(orderBy, term)
+	}
+	return append(orderBy, q.schema.IdColumn)
+}
+
+// Encode writes the filters and the sort back as query parameters, each one
+// preceded by "&", for the pagination links.
+func (q Query) Encode() string {
+	var builder strings.Builder
+	for _, field := range q.schema.Fields {
+		for _, operator := range operatorOrder {
+			for _, filter := range q.Filters {
+				if filter.Field != field.Name || filter.Operator != operator {
+					continue
+				}
+				name := field.Name
+				if operator != Eq {
+					name += "[" + string(operator) + "]"
+				}
+				fmt.Fprintf(&builder, "&%s=%s", url.QueryEscape(name), url.QueryEscape(filter.raw))
+			}
+		}
+	}
+	if q.explicitSort {
+		terms := make([]string, len(q.Sort))
+		for index, sort := range q.Sort {
+			terms[index] = sort.Field
+			if sort.Descending {
+				terms[index] = "-" + sort.Field
+			}
+		}
+		fmt.Fprintf(&builder, "&%s=%s", SortParam, url.QueryEscape(strings.Join(terms, ",")))
+	}
+	return builder.String()
+}
+
+// day returns the start of the day and of the next one when the filter is
+// on a date alone, which stands for the whole day.
+func (f Filter) day() (time.Time, time.Time, bool) {
+	date, isTime := f.Value.(time.Time)
+	if !isTime || len(f.raw) != len(time.DateOnly) {
+		return time.Time{}, time.Time{}, false
+	}
+	return date, date.AddDate(0, 0, 1), true
+}
+
+// likeEscaper escapes the wildcards of LIKE, SQL Server's brackets included,
+// for the ESCAPE '\' clause.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`, "[", `\[`)
@@ -0,0 +1,167 @@
+package listing
+
+import (
+	"errors"
+	"fmt"
+	"rest-api-example/entities"
+	"slices"
+	"testing"
+)
+
+var testSchema = Schema{
+	Fields: []Field{
+		{Name: "name", Column: "p.name", Kind: Text, Operators: TextOperators, Sortable: true},
+		{Name: "price", Column: "p.price", Kind: Decimal, Currency: entities.DefaultCurrency, Operators: RangeOperators, Sortable: true},
+		{Name: "active", Column: "p.active", Kind: Bool, Operators: EqualityOperators},
+		{Name: "created_at", Column: "p.created_at", Kind: Time, Operators: RangeOperators, Sortable: true},
+		{Name: "parent_id", Column: "p.parent_id", Kind: UUID, Operators: EqualityOperators},
+	},
+	Reserved:    []string{"limit"},
+	DefaultSort: []Sort{{Field: "name"}},
+	IdColumn:    "id",
+}
+
+// fieldErrors returns the fields of the 400 error of Parse.
+func fieldErrors(err error) []entities.FieldError {
+	var apiError *entities.Error
+	if errors.As(err, &apiError) {
+		return apiError.Fields
+	}
+	return nil
+}
+
+func TestSchemaParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string][]string
+		where   string
+		args    string
+		orderBy []string
+		encode  string
+		errors  []string
+	}{
+		{
+			name:    "no parameters",
+			params:  map[string][]string{"limit": {"5"}},
+			where:   "(1=1)",
+			args:    "[]",
+			orderBy: []string{"name", "id"},
+		},
+		{
+			name:    "range and equality",
+			params:  map[string][]string{"price[gte]": {"10.5"}, "active": {"1"}, "sort": {"-price,name"}},
+			where:   "(p.active = ? AND p.price >= ?)",
+			args:    "[true 10.50]",
+			orderBy: []string{"price DESC", "name", "id"},
+			encode:  "&price%5Bgte%5D=10.5&active=1&sort=-price%2Cname",
+		},
+		{
+			name:    "contains escapes the wildcards",
+			params:  map[string][]string{"name[contains]": {"50%_"}},
+			where:   `(LOWER(p.name) LIKE ? ESCAPE '\')`,
+			args:    `[%50\%\_%]`,
+			orderBy: []string{"name", "id"},
+			encode:  "&name%5Bcontains%5D=50%25_",
+		},
+		{
+			name:    "a date is the whole day",
+			params:  map[string][]string{"created_at[lte]": {"2024-01-31"}},
+			where:   "(p.created_at < ?)",
+			args:    "[2024-02-01 00:00:00 +0000 UTC]",
+			orderBy: []string{"name", "id"},
+			encode:  "&created_at%5Blte%5D=2024-01-31",
+		},
+		{
+			name:    "repeated sort field",
+			params:  map[string][]string{"sort": {"name,-name"}},
+			where:   "(1=1)",
+			args:    "[]",
+			orderBy: []string{"name", "id"},
+			encode:  "&sort=name",
+		},
+		{
+			name: "every problem at once",
+			params: map[string][]string{
+				"color":          {"red"},
+				"name[gt]":       {"a"},
+				"price":          {"10.001"},
+				"created_at[lt]": {"yesterday"},
+				"sort":           {"active"},
+			},
+			errors: []string{
+				"color: " + ErrCampoDesconhecido.Error(),
+				"created_at[lt]: " + ErrValorDataInvalido.Error(),
+				"name[gt]: " + ErrOperadorInvalido.Error(),
+				"price: " + ErrValorDecimalInvalido.Error(),
+				"sort: active: " + ErrCampoNaoOrdenavel.Error(),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := testSchema.Parse(test.params)
+			var problems []string
+			for _, field := range fieldErrors(err) {
+				problems = append(problems, field.Field+": "+field.Message)
+			}
+			if !slices.Equal(problems, test.errors) {
+				t.Fatalf("Parse() errors = %q, want %q", problems, test.errors)
+			}
+			if err != nil {
+				return
+			}
+
+			where, args, err := query.Where().ToSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if where != test.where || fmt.Sprint(args) != test.args {
+				t.Errorf("Where() = %q %v, want %q %s", where, args, test.where, test.args)
+			}
+			if orderBy := query.OrderBy(); !slices.Equal(orderBy, test.orderBy) {
+				t.Errorf("OrderBy() = %v, want %v", orderBy, test.orderBy)
+			}
+			if encode := query.Encode(); encode != test.encode {
+				t.Errorf("Encode() = %q, want %q", encode, test.encode)
+			}
+		})
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	item := map[string]any{
+		"name":       "Caneca",
+		"price":      entities.NewMoney(1990, entities.DefaultCurrency),
+		"active":     true,
+		"created_at": "2024-01-31T18:00:00Z",
+		"parent_id":  nil,
+	}
+	values := func(field string) any { return item[field] }
+
+	tests := []struct {
+		params  map[string][]string
+		matches bool
+	}{
+		{params: map[string][]string{"name[contains]": {"CAN"}}, matches: true},
+		{params: map[string][]string{"name[ne]": {"Caneca"}}, matches: false},
+		{params: map[string][]string{"price[lt]": {"19.90"}}, matches: false},
+		{params: map[string][]string{"price[lte]": {"19.90"}, "active": {"true"}}, matches: true},
+		{params: map[string][]string{"created_at": {"2024-01-31"}}, matches: true},
+		{params: map[string][]string{"created_at[gt]": {"2024-01-31"}}, matches: false},
+		{params: map[string][]string{"created_at[gte]": {"2024-01-31T18:00:00Z"}}, matches: true},
+		// a NULL column fails every filter, as in SQL
+		{params: map[string][]string{"parent_id": {"6f1c2d3e-0000-4000-8000-000000000001"}}, matches: false},
+		{params: map[string][]string{"parent_id[ne]": {"6f1c2d3e-0000-4000-8000-000000000001"}}, matches: false},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.params), func(t *testing.T) {
+			query, err := testSchema.Parse(test.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matches := query.Matches(values); matches != test.matches {
+				t.Errorf("Matches() = %v, want %v", matches, test.matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package listing
+
+import (
+	"cmp"
+	"rest-api-example/entities"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Values gives the value of a field of an item for Matches and Compare, of
+// the type its kind parses to: string, entities.Money, bool, uuid.UUID, or
+// []uuid.UUID for relations, and nil for NULL. Timestamps may be given as the
+// strings the repositories read, which Compare parses.
+type Values func(field string) any
+
+// Matches tells whether the item passes every filter, as Where would in SQL.
+func (q Query) Matches(values Values) bool {
+	for _, filter := range q.Filters {
+		if !filter.matches(values(filter.Field)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare orders two items as OrderBy would in SQL, for sort.Slice and
+// slices.SortFunc.
+func (q Query) Compare(a Values, b Values) int {
+	for _, sort := range q.Sort {
+		result := compareValues(a(sort.Field), b(sort.Field))
+		if sort.Descending {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return compareValues(a(q.schema.IdColumn), b(q.schema.IdColumn))
+}
+
+// matches compares value as SQL would: a nil value, a NULL column, fails
+// every filter, ne included.
+func (f Filter) matches(value any) bool {
+	if value == nil {
+		return false
+	}
+	if ids, isRelation := value.([]uuid.UUID); isRelation {
+		contains := slices.Contains(ids, f.Value.(uuid.UUID))
+		return contains == (f.Operator == Eq)
+	}
+	if f.Operator == Contains {
+		text, _ := value.(string)
+		return strings.Contains(strings.ToLower(text), strings.ToLower(f.Value.(string)))
+	}
+
+	if start, end, isDay := f.day(); isDay {
+		switch f.Operator {
+		case Eq, Ne:
+			inDay := compareValues(value, start) >= 0 && compareValues(value, end) < 0
+			return inDay == (f.Operator == Eq)
+		case Gt:
+			return compareValues(value, end) >= 0
+		case Gte:
+			return compareValues(value, start) >= 0
+		case Lt:
+			return compareValues(value, start) < 0
+		case Lte:
+			return compareValues(value, end) < 0
+		}
+	}
+
+	result := compareValues(value, f.Value)
+	switch f.Operator {
+	case Eq:
+		return result == 0
+	case Ne:
+		return result != 0
+	case Gt:
+		return result > 0
+	case Gte:
+		return result >= 0
+	case Lt:
+		return result < 0
+	case Lte:
+		return result <= 0
+	}
+	return false
+}
+
+func compareValues(a any, b any) int {
+	switch a := a.(type) {
+	case string:
+		if b, isTime := b.(time.Time); isTime {
+			return parseTime(a).Compare(b)
+		}
+		b, _ := b.(string)
+		if result := strings.Compare(strings.ToLower(a), strings.ToLower(b)); result != 0 {
+			return result
+		}
+		return strings.Compare(a, b)
+	case entities.Money:
+		b, _ := b.(entities.Money)
+		return cmp.Compare(a.Amount, b.Amount)
+	case bool:
+		b, _ := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	case time.Time:
+		if b, isString := b.(string); isString {
+			return a.Compare(parseTime(b))
+		}
+		b, _ := b.(time.Time)
+		return a.Compare(b)
+	case uuid.UUID:
+		b, _ := b.(uuid.UUID)
+		return strings.Compare(a.String(), b.String())
+	}
+	return 0
+}
+
+// parseTime reads the timestamps of the memory store, the zero time when
+// empty.
+func parseTime(value string) time.Time {
+	parsed, _ := time.Parse(time.RFC3339Nano, value)
+	return parsed
+}
@@ -9,10 +9,20 @@ import (
 	"os"
 	"os/signal"
 	"rest-api-example/auth"
+	"rest-api-example/cache"
+	"rest-api-example/cart"
 	"rest-api-example/category"
 	"rest-api-example/config"
+	"rest-api-example/inventory"
+	"rest-api-example/listing"
+	"rest-api-example/middlewares"
+	"rest-api-example/migration"
+	"rest-api-example/openapi"
+	"rest-api-example/order"
+	"rest-api-example/payment"
 	"rest-api-example/product"
 	"rest-api-example/user"
+	"slices"
 	"strings"
 	"time"
 
@@ -23,8 +33,9 @@ import (
 )
 
 func main() {
-	action := flag.String("action", "run", "defines action to perform: install, uninstall, run, stop")
+	action := flag.String("action", "run", "defines action to perform: install, uninstall, run, stop, migrate, migrate-down, migrate-status, grant-role")
 	configDir := flag.String("configs", os.Getenv("ECOM_CONFIG_DIR"), "path to config directory")
+	dryRun := flag.Bool("dry-run", false, "prints the SQL of migrate and migrate-down without executing it")
 	flag.Parse()
 
 	if configDir == nil || *configDir == "" {
@@ -47,33 +58,121 @@ func main() {
 	})
 	log.Info("Setup log file successfully")
 
-	dbInstance, err := config.NewDatabaseConnectionPostgreSQL(cfg.PostgresServerDatabase)
-	if err != nil {
-		panic(err)
+	var repositories repositories
+	if cfg.Driver == config.DriverMemory {
+		if isMigrationAction(*action) {
+			panic("migrations are not available for the memory driver")
+		}
+		repositories = newMemoryRepositories()
+		log.Warn("Using in-memory repositories, data will be lost when the service stops")
+	} else {
+		dbInstance, dialect, err := openDatabase(cfg)
+		if err != nil {
+			panic(err)
+		}
+		defer dbInstance.Close()
+		log.WithField("driver", cfg.Driver).Info("Database connection established")
+
+		migrator, err := migration.NewMigrator(dbInstance, dialect, *dryRun, os.Stdout)
+		if err != nil {
+			panic(err)
+		}
+
+		if isMigrationAction(*action) {
+			err = runMigrationAction(migrator, *action, flag.Args())
+			if err != nil {
+				panic(err)
+			}
+			return
+		}
+
+		err = migrator.EnsureUpToDate(context.Background())
+		if err != nil {
+			log.Error(err)
+			panic(err)
+		}
+		log.Info("Database schema is up to date")
+
+		repositories = newRepositories(cfg.Driver, dbInstance)
 	}
-	defer dbInstance.Close()
-	log.Info("Database connection established")
 
 	r := mux.NewRouter()
+	r.Use(middlewares.CorrelationId)
+	r.Use(middlewares.ConditionalGet(cfg.Cache.CacheControl, cfg.Cache.WeakETags))
 
-	userRepository := user.NewUserRepository(dbInstance)
+	userRepository := repositories.user
 	userService := user.NewUserService(userRepository)
+	if *action == "grant-role" {
+		err = grantRole(userService, flag.Args())
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
 	userHandler := user.NewUserHandler(userService)
 	user.SetupUserRoutes(r, userHandler)
 
-	authService := auth.NewAuthService(userRepository, os.Getenv("SECRET_KEY"))
-	authHandler := auth.NewAuthHandler(authService)
-	auth.SetupAuthRoutes(r, authHandler, userHandler)
+	keys, err := auth.NewKeySet(cfg.Jwt, os.Getenv("SECRET_KEY"))
+	if err != nil {
+		panic(err)
+	}
+	authService := auth.NewAuthService(userRepository, repositories.refreshToken, keys)
+
+	// the cursors of the lists only survive restarts with CURSOR_SECRET set
+	cursorCodec := listing.NewCursorCodec(os.Getenv("CURSOR_SECRET"))
+
+	categoryRepository := repositories.category
+	productRepository := repositories.product
+	if !cfg.Cache.Server.Disabled {
+		serverCache := cache.NewLayer(cache.NewLRU(cfg.Cache.Server.MaxEntries, cfg.Cache.Server.MaxBytes), cfg.Cache.Server.TTL)
+		categoryRepository = category.NewCategoryRepositoryCache(categoryRepository, serverCache)
+		productRepository = product.NewProductRepositoryCache(productRepository, serverCache)
+		go serverCache.LogStats(5 * time.Minute)
+		log.WithField("ttl", cfg.Cache.Server.TTL).Info("Server cache enabled")
+	}
 
-	categoryRepository := category.NewCategoryRepositoryPostgres(dbInstance)
 	categoryService := category.NewCategoryService(categoryRepository)
-	categoryHandler := category.NewCategoryHandler(categoryService)
+	categoryHandler := category.NewCategoryHandler(categoryService, cfg.RequireIfMatch, cursorCodec)
 	category.SetupCategoriesRoutes(r, categoryHandler, authService)
 
-	productRepository := product.NewProductRepositoryPostgres(dbInstance)
 	productService := product.NewProductService(productRepository, categoryRepository)
-	productHandler := product.NewProductHandler(productService)
+	productHandler := product.NewProductHandler(productService, cfg.RequireIfMatch, cursorCodec)
 	product.SetupProductsRoutes(r, productHandler, authService)
+
+	inventoryService := inventory.NewInventoryService(repositories.inventory, productRepository)
+	inventoryHandler := inventory.NewInventoryHandler(inventoryService)
+	inventory.SetupInventoryRoutes(r, inventoryHandler, authService)
+
+	cartService := cart.NewCartService(repositories.cart, productService)
+	cartHandler := cart.NewCartHandler(cartService)
+	cart.SetupCartRoutes(r, cartHandler, authService)
+
+	orderService := order.NewOrderService(repositories.order)
+	orderHandler := order.NewOrderHandler(orderService)
+	order.SetupOrderRoutes(r, orderHandler, authService)
+
+	paymentGateway, err := payment.NewGateway(cfg.Payment)
+	if err != nil {
+		panic(err)
+	}
+	paymentService := payment.NewPaymentService(repositories.payment, paymentGateway)
+	paymentHandler := payment.NewPaymentHandler(paymentService, os.Getenv("PAYMENT_WEBHOOK_SECRET"))
+	payment.SetupPaymentRoutes(r, paymentHandler, authService)
+	log.WithField("provider", paymentGateway.Name()).Info("Payment gateway configured")
+
+	// anonymous carts are merged into the cart of the user on login
+	authHandler := auth.NewAuthHandler(authService, cartHandler.MergeOnLogin)
+	auth.SetupAuthRoutes(r, authHandler, userHandler)
+
+	operations := slices.Concat(category.Operations(), product.Operations(), user.Operations(), auth.Operations())
+	document, err := openapi.Generate(r, openapi.Info{Title: "RESTful API em Golang", Version: "1.0.0"}, cfg.BaseUrl, operations)
+	if err != nil {
+		panic(err)
+	}
+	err = openapi.SetupOpenAPIRoutes(r, document)
+	if err != nil {
+		panic(err)
+	}
 	log.Info("Successfully initialized all system layers")
 
 	server := &http.Server{
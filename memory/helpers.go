@@ -0,0 +1,25 @@
+package memory
+
+import "time"
+
+// Page returns the slice of items that LIMIT/OFFSET would return for the
+// given page, mirroring the SQL repositories.
+func Page[T any](items []T, page int, limit int) []T {
+	if page < 1 || limit < 1 {
+		return nil
+	}
+	offset := (page - 1) * limit
+	if offset >= len(items) {
+		return nil
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+// Now formats the current time the way timestamps come back from the databases.
+func Now() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
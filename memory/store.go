@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"rest-api-example/entities"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store is the in-memory counterpart of the database: repositories of
+// different packages share one Store the same way they share a *sql.DB.
+// Callers must hold the lock while reading or writing the maps.
+type Store struct {
+	sync.RWMutex
+	Categories         map[uuid.UUID]entities.Category
+	Products           map[uuid.UUID]entities.Product
+	ProductsCategories map[uuid.UUID][]uuid.UUID
+	ProductPrices      map[uuid.UUID]map[string]entities.Money
+	Users              map[string]entities.Credentials
+	RefreshTokens      map[uuid.UUID]entities.RefreshTokenRecord
+	Stock              map[uuid.UUID]entities.Stock
+	StockReservations  map[uuid.UUID]entities.StockReservation
+	StockAdjustments   []entities.StockAdjustment
+	Carts              map[uuid.UUID]entities.Cart
+	Orders             map[uuid.UUID]entities.Order
+	Payments           map[uuid.UUID]entities.Payment
+}
+
+func NewStore() *Store {
+	return &Store{
+		Categories:         make(map[uuid.UUID]entities.Category),
+		Products:           make(map[uuid.UUID]entities.Product),
+		ProductsCategories: make(map[uuid.UUID][]uuid.UUID),
+		ProductPrices:      make(map[uuid.UUID]map[string]entities.Money),
+		Users:              make(map[string]entities.Credentials),
+		RefreshTokens:      make(map[uuid.UUID]entities.RefreshTokenRecord),
+		Stock:              make(map[uuid.UUID]entities.Stock),
+		StockReservations:  make(map[uuid.UUID]entities.StockReservation),
+		Carts:              make(map[uuid.UUID]entities.Cart),
+		Orders:             make(map[uuid.UUID]entities.Order),
+		Payments:           make(map[uuid.UUID]entities.Payment),
+	}
+}
+
+// ReservedStock sums the reservations of the product that did not expire at
+// now. Callers must hold the lock.
+func (s *Store) ReservedStock(productId uuid.UUID, now time.Time) int {
+	reserved := 0
+	for _, reservation := range s.StockReservations {
+		if reservation.ProductId == productId && reservation.ExpiresAt.After(now) {
+			reserved += reservation.Quantity
+		}
+	}
+	return reserved
+}
+
+// DeleteInventory mirrors the ON DELETE CASCADE of the inventory tables when a
+// product is deleted. Callers must hold the lock.
+func (s *Store) DeleteInventory(productId uuid.UUID) {
+	delete(s.Stock, productId)
+	for id, reservation := range s.StockReservations {
+		if reservation.ProductId == productId {
+			delete(s.StockReservations, id)
+		}
+	}
+	adjustments := s.StockAdjustments[:0]
+	for _, adjustment := range s.StockAdjustments {
+		if adjustment.ProductId != productId {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	s.StockAdjustments = adjustments
+}
+
+// RemoveProductFromCarts mirrors the ON DELETE CASCADE of cart items when a
+// product is deleted. Callers must hold the lock.
+func (s *Store) RemoveProductFromCarts(productId uuid.UUID) {
+	for id, cart := range s.Carts {
+		items := make([]entities.CartItem, 0, len(cart.Items))
+		for _, item := range cart.Items {
+			if item.ProductId != productId {
+				items = append(items, item)
+			}
+		}
+		cart.Items = items
+		s.Carts[id] = cart
+	}
+}
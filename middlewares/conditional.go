@@ -0,0 +1,115 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"rest-api-example/utils"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// bufferedResponse holds the response of the handler until its ETag is known.
+// The headers are those of the real writer, only sent by WriteHeader.
+type bufferedResponse struct {
+	w      http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.w.Header()
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// ConditionalGet answers with 304 Not Modified the GET requests for a
+// representation the client already has. The 200 responses are buffered to
+// get their ETag: the one the handler set, such as the version ETag of a
+// resource, or else the SHA-256 of the body, weak when weakETags is set.
+// If-None-Match accepts a list of tags or "*"; without it, If-Modified-Since
+// is checked against the Last-Modified the handler set. Every GET response
+// not setting its own Cache-Control gets cacheControl, when not empty.
+func ConditionalGet(cacheControl string, weakETags bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op := "middlewares.ConditionalGet()"
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buffer := &bufferedResponse{w: w}
+			next.ServeHTTP(buffer, r)
+			if buffer.status == 0 {
+				buffer.status = http.StatusOK
+			}
+			if cacheControl != "" && w.Header().Get("Cache-Control") == "" {
+				w.Header().Set("Cache-Control", cacheControl)
+			}
+
+			if buffer.status == http.StatusOK {
+				eTag := w.Header().Get("ETag")
+				if eTag == "" {
+					eTag = utils.GenerateETag(buffer.body.Bytes())
+					if weakETags {
+						eTag = "W/" + eTag
+					}
+					w.Header().Set("ETag", eTag)
+				}
+				if notModified(r, eTag, w.Header().Get("Last-Modified")) {
+					w.Header().Del("Content-Type")
+					w.Header().Del("Content-Length")
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+
+			w.WriteHeader(buffer.status)
+			_, err := w.Write(buffer.body.Bytes())
+			if err != nil {
+				log.WithError(err).WithField("operation", op).Error("could not write response")
+			}
+		})
+	}
+}
+
+// notModified evaluates the preconditions of RFC 9110 for GET: If-None-Match
+// with the weak comparison, and If-Modified-Since only when If-None-Match is
+// absent.
+func notModified(r *http.Request, eTag string, lastModified string) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(eTag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	header := r.Header.Get("If-Modified-Since")
+	if header == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConditionalGet(t *testing.T) {
+	modified := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		eTag         string
+		cacheControl string
+		weakETags    bool
+		headers      map[string]string
+		wantStatus   int
+		wantETag     string
+		wantCache    string
+	}{
+		{name: "no precondition", wantStatus: http.StatusOK, wantCache: "no-cache"},
+		{name: "handler ETag matches", eTag: `"v3"`, headers: map[string]string{"If-None-Match": `"v3"`}, wantStatus: http.StatusNotModified, wantETag: `"v3"`},
+		{name: "weak comparison", eTag: `"v3"`, headers: map[string]string{"If-None-Match": `W/"v3"`}, wantStatus: http.StatusNotModified, wantETag: `"v3"`},
+		{name: "tag in a list", eTag: `"v3"`, headers: map[string]string{"If-None-Match": `"v1", "v3"`}, wantStatus: http.StatusNotModified},
+		{name: "any tag", headers: map[string]string{"If-None-Match": "*"}, wantStatus: http.StatusNotModified},
+		{name: "other tag", eTag: `"v3"`, headers: map[string]string{"If-None-Match": `"v2"`}, wantStatus: http.StatusOK, wantETag: `"v3"`},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": after}, wantStatus: http.StatusNotModified},
+		{name: "modified since", headers: map[string]string{"If-Modified-Since": before}, wantStatus: http.StatusOK},
+		{
+			name:       "If-None-Match takes precedence",
+			eTag:       `"v3"`,
+			headers:    map[string]string{"If-None-Match": `"v2"`, "If-Modified-Since": after},
+			wantStatus: http.StatusOK,
+		},
+		{name: "error responses are not compared", status: http.StatusNotFound, headers: map[string]string{"If-None-Match": "*"}, wantStatus: http.StatusNotFound},
+		{name: "handler Cache-Control is kept", cacheControl: "private, max-age=60", wantStatus: http.StatusOK, wantCache: "private, max-age=60"},
+		{name: "weak hash ETag", weakETags: true, wantStatus: http.StatusOK},
+		{name: "other methods pass through", method: http.MethodPost, headers: map[string]string{"If-None-Match": "*"}, status: http.StatusCreated, wantStatus: http.StatusCreated},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+				if test.eTag != "" {
+					w.Header().Set("ETag", test.eTag)
+				}
+				if test.cacheControl != "" {
+					w.Header().Set("Cache-Control", test.cacheControl)
+				}
+				if test.status != 0 {
+					w.WriteHeader(test.status)
+				}
+				w.Write([]byte(`{"name":"a"}`))
+			})
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/products/1", nil)
+			for name, value := range test.headers {
+				r.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			ConditionalGet("no-cache", test.weakETags)(handler).ServeHTTP(w, r)
+
+			if w.Code != test.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, test.wantStatus)
+			}
+			eTag := w.Header().Get("ETag")
+			if test.wantETag != "" && eTag != test.wantETag {
+				t.Errorf("ETag = %q, want %q", eTag, test.wantETag)
+			}
+			if test.wantCache != "" && w.Header().Get("Cache-Control") != test.wantCache {
+				t.Errorf("Cache-Control = %q, want %q", w.Header().Get("Cache-Control"), test.wantCache)
+			}
+
+			switch {
+			case w.Code == http.StatusNotModified:
+				if w.Body.Len() > 0 || w.Header().Get("Content-Type") != "" {
+					t.Errorf("304 with body %q and Content-Type %q", w.Body, w.Header().Get("Content-Type"))
+				}
+			case w.Body.String() != `{"name":"a"}`:
+				t.Errorf("body = %q", w.Body)
+			}
+			if w.Code == http.StatusOK && strings.HasPrefix(eTag, "W/") != test.weakETags {
+				t.Errorf("ETag = %q, want weak %v", eTag, test.weakETags)
+			}
+			if method != http.MethodGet && eTag != "" {
+				t.Errorf("%s response got the ETag %q", method, eTag)
+			}
+		})
+	}
+}
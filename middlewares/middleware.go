@@ -6,8 +6,9 @@ import (
 	"net/http"
 	"rest-api-example/entities"
 	"rest-api-example/utils"
-	"slices"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
 func ValidateSupportedMediaTypes(mediaTypes []string, next http.HandlerFunc) http.HandlerFunc {
@@ -22,19 +23,41 @@ func ValidateSupportedMediaTypes(mediaTypes []string, next http.HandlerFunc) htt
 				}
 			}
 		}
-		utils.JSONError(w, entities.NewUnsupportedMediaType(errors.New("formato não suportado"),
+		utils.JSONError(w, r, entities.NewUnsupportedMediaType(errors.New("formato não suportado"),
 			fmt.Sprintf("tente os seguintes media types %s", strings.Join(mediaTypes, ",")), op))
 	})
 }
 
+// ValidadeAcceptHeader negotiates among acceptContents, the first being the
+// default, the media type of the response, which utils.Respond writes. The
+// Accept header is read with its quality values, and requests accepting none
+// of acceptContents are answered with 406 Not Acceptable.
 func ValidadeAcceptHeader(acceptContents []string, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		op := "middlewares.ValidadeAcceptHeader()"
-		acceptRequest := strings.TrimSpace(r.Header.Get("Accept"))
-		if slices.Contains(acceptContents, acceptRequest) || acceptRequest == "" || acceptRequest == "*/*" {
-			next.ServeHTTP(w, r)
+		w.Header().Add("Vary", "Accept")
+		mediaType, acceptable := utils.NegotiateMediaType(r.Header.Get("Accept"), acceptContents)
+		if acceptable {
+			next.ServeHTTP(w, r.WithContext(utils.WithMediaType(r.Context(), mediaType)))
 			return
 		}
-		utils.JSONError(w, entities.NewNotAcceptable(errors.New("formato não suportado"), fmt.Sprintf("formatos de retorno: %s", strings.Join(acceptContents, ",")), op))
+		utils.JSONError(w, r, entities.NewNotAcceptable(errors.New("formato não suportado"), fmt.Sprintf("formatos de retorno: %s", strings.Join(acceptContents, ",")), op))
+	})
+}
+
+// CorrelationId propagates the X-Correlation-Id (or X-Request-Id) sent by the
+// client, or generates a new one, so logs and problem details can be matched
+// to the request.
+func CorrelationId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get(utils.CorrelationIdHeader))
+		if id == "" {
+			id = strings.TrimSpace(r.Header.Get("X-Request-Id"))
+		}
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		w.Header().Set(utils.CorrelationIdHeader, id)
+		next.ServeHTTP(w, r.WithContext(utils.WithCorrelationId(r.Context(), id)))
 	})
 }
@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rest-api-example/utils"
+	"testing"
+)
+
+func TestValidadeAcceptHeader(t *testing.T) {
+	tests := []struct {
+		accept    string
+		status    int
+		mediaType string
+	}{
+		{accept: "", status: http.StatusOK, mediaType: utils.MediaTypeJSON},
+		{accept: "application/hal+json", status: http.StatusOK, mediaType: utils.MediaTypeHAL},
+		{accept: "text/csv;q=0.5, application/xml", status: http.StatusOK, mediaType: utils.MediaTypeXML},
+		{accept: "text/csv", status: http.StatusNotAcceptable},
+	}
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			var mediaType string
+			handler := ValidadeAcceptHeader(utils.ItemMediaTypes, func(w http.ResponseWriter, r *http.Request) {
+				mediaType = utils.MediaType(r.Context())
+			})
+			r := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+			r.Header.Set("Accept", test.accept)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.status || mediaType != test.mediaType {
+				t.Errorf("status %d with media type %q, want %d with %q", w.Code, mediaType, test.status, test.mediaType)
+			}
+			if vary := w.Header().Get("Vary"); vary != "Accept" {
+				t.Errorf("Vary = %q, want Accept", vary)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"rest-api-example/migration"
+	"strconv"
+	"time"
+)
+
+func isMigrationAction(action string) bool {
+	switch action {
+	case "migrate", "migrate-down", "migrate-status":
+		return true
+	}
+	return false
+}
+
+func runMigrationAction(migrator migration.Migrator, action string, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch action {
+	case "migrate":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if len(applied) == 0 {
+			fmt.Println("database schema is up to date")
+		}
+	case "migrate-down":
+		steps := 1
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid number of migrations to revert %q: %w", args[0], err)
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+	case "migrate-status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, appliedAt)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+//go:embed postgres/*.sql sqlserver/*.sql
+var migrationFiles embed.FS
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Dialect holds what changes between the supported databases: where the
+// migration scripts live and how the bookkeeping table is handled.
+type Dialect struct {
+	Name                  string
+	dir                   string
+	placeholder           sq.PlaceholderFormat
+	tableExistsQuery      string
+	createMigrationsTable string
+}
+
+var (
+	Postgres = Dialect{
+		Name:             "postgres",
+		dir:              "postgres",
+		placeholder:      sq.Dollar,
+		tableExistsQuery: "SELECT to_regclass('schema_migrations') IS NOT NULL",
+		createMigrationsTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    BIGINT PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    checksum   CHAR(64) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+)`,
+	}
+	SqlServer = Dialect{
+		Name:             "sqlserver",
+		dir:              "sqlserver",
+		placeholder:      sq.Question,
+		tableExistsQuery: "SELECT CASE WHEN OBJECT_ID(N'schema_migrations', N'U') IS NULL THEN 0 ELSE 1 END",
+		createMigrationsTable: `IF OBJECT_ID(N'schema_migrations', N'U') IS NULL
+CREATE TABLE schema_migrations (
+    version    BIGINT PRIMARY KEY,
+    name       NVARCHAR(255) NOT NULL,
+    checksum   CHAR(64) NOT NULL,
+    applied_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+)`,
+	}
+)
+
+func (d Dialect) Migrations() (fs.FS, error) {
+	return fs.Sub(migrationFiles, d.dir)
+}
+
+// LoadMigrations reads every NNNN_name.up.sql / NNNN_name.down.sql pair found
+// in the root of source and returns them ordered by version.
+func LoadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMigrationFileName, entry.Name())
+		}
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(source, path.Join(".", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("%w: version %d", ErrDuplicatedMigrationVersion, version)
+		}
+
+		switch matches[3] {
+		case "up":
+			migration.UpSQL = string(content)
+		case "down":
+			migration.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpSQL == "" || migration.DownSQL == "" {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrIncompleteMigration, migration.Version, migration.Name)
+		}
+		migration.Checksum = checksum(migration.UpSQL)
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func checksum(content string) string {
+	h := sha256.New()
+	h.Write([]byte(content))
+	return hex.EncodeToString(h.Sum(nil))
+}
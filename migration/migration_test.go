@@ -0,0 +1,85 @@
+package migration
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int
+		err      error
+	}{
+		{
+			name: "ordered by version",
+			files: fstest.MapFS{
+				"0002_second.up.sql":   {Data: []byte("up 2")},
+				"0002_second.down.sql": {Data: []byte("down 2")},
+				"0001_first.up.sql":    {Data: []byte("up 1")},
+				"0001_first.down.sql":  {Data: []byte("down 1")},
+			},
+			versions: []int{1, 2},
+		},
+		{
+			name:  "invalid file name",
+			files: fstest.MapFS{"first.sql": {Data: []byte("up")}},
+			err:   ErrInvalidMigrationFileName,
+		},
+		{
+			name:  "missing down file",
+			files: fstest.MapFS{"0001_first.up.sql": {Data: []byte("up 1")}},
+			err:   ErrIncompleteMigration,
+		},
+		{
+			name: "two names for a version",
+			files: fstest.MapFS{
+				"0001_first.up.sql":   {Data: []byte("up 1")},
+				"0001_other.up.sql":   {Data: []byte("up 1")},
+				"0001_first.down.sql": {Data: []byte("down 1")},
+			},
+			err: ErrDuplicatedMigrationVersion,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrations, err := LoadMigrations(test.files)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("LoadMigrations() error = %v, want %v", err, test.err)
+			}
+			var versions []int
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+				if migration.Checksum != checksum(migration.UpSQL) {
+					t.Errorf("version %d: checksum of another script", migration.Version)
+				}
+			}
+			if !slices.Equal(versions, test.versions) {
+				t.Errorf("versions = %v, want %v", versions, test.versions)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	for _, dialect := range []Dialect{Postgres, SqlServer} {
+		t.Run(dialect.Name, func(t *testing.T) {
+			source, err := dialect.Migrations()
+			if err != nil {
+				t.Fatal(err)
+			}
+			migrations, err := LoadMigrations(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for index, migration := range migrations {
+				if migration.Version != index+1 {
+					t.Errorf("version %d at position %d, the versions must have no gaps", migration.Version, index+1)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvalidMigrationFileName   = errors.New("invalid migration file name")
+	ErrDuplicatedMigrationVersion = errors.New("duplicated migration version")
+	ErrIncompleteMigration        = errors.New("migration must have both up and down files")
+	ErrChecksumMismatch           = errors.New("applied migration checksum does not match migration file")
+	ErrUnknownAppliedMigration    = errors.New("applied migration not found in migration files")
+	ErrInvalidSteps               = errors.New("number of migrations to revert must be greater than zero")
+	ErrSchemaOutdated             = errors.New("database schema is behind, run -action migrate")
+)
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	dryRun     bool
+	out        io.Writer
+}
+
+// NewMigrator loads the migrations of the given dialect. When dryRun is set the
+// SQL that would be executed is written to out and the database is left untouched.
+func NewMigrator(db *sql.DB, dialect Dialect, dryRun bool, out io.Writer) (Migrator, error) {
+	source, err := dialect.Migrations()
+	if err != nil {
+		return Migrator{}, err
+	}
+	migrations, err := LoadMigrations(source)
+	if err != nil {
+		return Migrator{}, err
+	}
+	return Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+		dryRun:     dryRun,
+		out:        out,
+	}, nil
+}
+
+func (m Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !m.dryRun {
+		if err := m.ensureMigrationsTable(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	builder := sq.StatementBuilder.PlaceholderFormat(m.dialect.placeholder)
+	for _, migration := range pending {
+		if m.dryRun {
+			fmt.Fprintf(m.out, "-- %04d_%s (up)\n%s\n", migration.Version, migration.Name, migration.UpSQL)
+			continue
+		}
+
+		insertSql := builder.Insert("schema_migrations").Columns("version", "name", "checksum").
+			Values(migration.Version, migration.Name, migration.Checksum)
+		err := m.execInTx(ctx, migration.UpSQL, insertSql)
+		if err != nil {
+			return nil, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		log.WithFields(log.Fields{"version": migration.Version, "name": migration.Name}).Info("Migration applied")
+	}
+	return pending, nil
+}
+
+func (m Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, ErrInvalidSteps
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	builder := sq.StatementBuilder.PlaceholderFormat(m.dialect.placeholder)
+	for i := len(statuses) - 1; i >= 0 && len(reverted) < steps; i-- {
+		if !statuses[i].Applied {
+			continue
+		}
+		migration := statuses[i].Migration
+		if m.dryRun {
+			fmt.Fprintf(m.out, "-- %04d_%s (down)\n%s\n", migration.Version, migration.Name, migration.DownSQL)
+			reverted = append(reverted, migration)
+			continue
+		}
+
+		deleteSql := builder.Delete("schema_migrations").Where("version = ?", migration.Version)
+		err := m.execInTx(ctx, migration.DownSQL, deleteSql)
+		if err != nil {
+			return nil, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		log.WithFields(log.Fields{"version": migration.Version, "name": migration.Name}).Info("Migration reverted")
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// Status reports every known migration and whether it was applied, after
+// checking that the applied ones were not edited since.
+func (m Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	for version, status := range applied {
+		migration, exists := known[version]
+		if !exists {
+			return nil, fmt.Errorf("%w: version %d", ErrUnknownAppliedMigration, version)
+		}
+		if migration.Checksum != status.Checksum {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for index, migration := range m.migrations {
+		statuses[index] = MigrationStatus{Migration: migration}
+		if status, exists := applied[migration.Version]; exists {
+			statuses[index].Applied = true
+			statuses[index].AppliedAt = status.AppliedAt
+		}
+	}
+	return statuses, nil
+}
+
+func (m Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// EnsureUpToDate fails with ErrSchemaOutdated when there are migrations that
+// were not applied yet, so the service never runs against an older schema.
+func (m Migrator) EnsureUpToDate(ctx context.Context) error {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w (%d pending, next %04d_%s)", ErrSchemaOutdated, len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
+
+type appliedMigration struct {
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (m Migrator) appliedMigrations(ctx context.Context) (map[int]appliedMigration, error) {
+	var exists bool
+	err := m.db.QueryRowContext(ctx, m.dialect.tableExistsQuery).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]appliedMigration)
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var migration appliedMigration
+		err = rows.Scan(&version, &migration.Checksum, &migration.AppliedAt)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = migration
+	}
+	return applied, rows.Err()
+}
+
+func (m Migrator) ensureMigrationsTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, m.dialect.createMigrationsTable)
+	return err
+}
+
+func (m Migrator) execInTx(ctx context.Context, script string, bookkeeping sq.Sqlizer) error {
+	query, args, err := bookkeeping.ToSql()
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, script)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
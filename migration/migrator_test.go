@@ -0,0 +1,246 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// fakeDialect talks to fakeDatabase, which knows its statements.
+var fakeDialect = Dialect{
+	Name:                  "fake",
+	placeholder:           sq.Question,
+	tableExistsQuery:      "table exists",
+	createMigrationsTable: "create table",
+}
+
+// fakeDatabase keeps schema_migrations in memory and records the scripts it
+// runs, failing the ones containing "FAIL". A rolled back transaction restores
+// the rows.
+type fakeDatabase struct {
+	mutex       sync.Mutex
+	tableExists bool
+	rows        map[int64]appliedMigration
+	scripts     []string
+	snapshot    map[int64]appliedMigration
+}
+
+func (d *fakeDatabase) Connect(context.Context) (driver.Conn, error) { return fakeConn{d}, nil }
+func (d *fakeDatabase) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ database *fakeDatabase }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.database.mutex.Lock()
+	defer c.database.mutex.Unlock()
+	c.database.snapshot = maps.Clone(c.database.rows)
+	return fakeTx(c), nil
+}
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	d := c.database
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	switch {
+	case query == fakeDialect.createMigrationsTable:
+		d.tableExists = true
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		d.rows[args[0].Value.(int64)] = appliedMigration{Checksum: args[2].Value.(string), AppliedAt: time.Now()}
+	case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+		delete(d.rows, args[0].Value.(int64))
+	case strings.Contains(query, "FAIL"):
+		return nil, errors.New("syntax error")
+	default:
+		d.scripts = append(d.scripts, query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	d := c.database
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if query == fakeDialect.tableExistsQuery {
+		return &fakeRows{columns: []string{"exists"}, values: [][]driver.Value{{d.tableExists}}}, nil
+	}
+	rows := &fakeRows{columns: []string{"version", "checksum", "applied_at"}}
+	for version, row := range d.rows {
+		rows.values = append(rows.values, []driver.Value{version, row.Checksum, row.AppliedAt})
+	}
+	return rows, nil
+}
+
+type fakeTx fakeConn
+
+func (t fakeTx) Commit() error { return nil }
+
+func (t fakeTx) Rollback() error {
+	t.database.mutex.Lock()
+	defer t.database.mutex.Unlock()
+	t.database.rows = t.database.snapshot
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func newFakeMigrator(t *testing.T, migrations []Migration, dryRun bool) (Migrator, *fakeDatabase, *bytes.Buffer) {
+	t.Helper()
+	database := &fakeDatabase{rows: make(map[int64]appliedMigration)}
+	db := sql.OpenDB(database)
+	t.Cleanup(func() { db.Close() })
+	var out bytes.Buffer
+	return Migrator{db: db, dialect: fakeDialect, migrations: migrations, dryRun: dryRun, out: &out}, database, &out
+}
+
+func testMigrations(ups ...string) []Migration {
+	migrations := make([]Migration, len(ups))
+	for index, up := range ups {
+		migrations[index] = Migration{
+			Version:  index + 1,
+			Name:     "step",
+			UpSQL:    up,
+			DownSQL:  "down " + up,
+			Checksum: checksum(up),
+		}
+	}
+	return migrations
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	migrator, database, _ := newFakeMigrator(t, testMigrations("one", "two", "three"), false)
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 3 || !slices.Equal(database.scripts, []string{"one", "two", "three"}) {
+		t.Fatalf("Up() applied %d, ran %v", len(applied), database.scripts)
+	}
+	if err := migrator.EnsureUpToDate(ctx); err != nil {
+		t.Errorf("EnsureUpToDate() after Up() = %v", err)
+	}
+
+	reverted, err := migrator.Down(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 2 || reverted[0].Version != 3 || reverted[1].Version != 2 {
+		t.Errorf("Down(2) reverted %+v, want versions 3 and 2", reverted)
+	}
+	if err := migrator.EnsureUpToDate(ctx); !errors.Is(err, ErrSchemaOutdated) {
+		t.Errorf("EnsureUpToDate() after Down() = %v, want ErrSchemaOutdated", err)
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].Version != 2 {
+		t.Errorf("Pending() = %+v, want versions 2 and 3", pending)
+	}
+
+	if _, err := migrator.Down(ctx, 0); !errors.Is(err, ErrInvalidSteps) {
+		t.Errorf("Down(0) error = %v, want ErrInvalidSteps", err)
+	}
+}
+
+func TestMigratorStopsAtFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	migrator, database, _ := newFakeMigrator(t, testMigrations("one", "FAIL", "three"), false)
+
+	_, err := migrator.Up(ctx)
+	if err == nil {
+		t.Fatal("Up() applied a failing migration")
+	}
+	if _, recorded := database.rows[2]; recorded {
+		t.Error("the failed migration was recorded as applied")
+	}
+	if _, recorded := database.rows[1]; !recorded || len(database.rows) != 1 {
+		t.Errorf("applied versions = %v, want only 1", database.rows)
+	}
+}
+
+func TestMigratorDetectsDrift(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		files []Migration
+		err   error
+	}{
+		{name: "applied script edited", files: testMigrations("one", "two edited"), err: ErrChecksumMismatch},
+		{name: "applied script removed", files: testMigrations("one"), err: ErrUnknownAppliedMigration},
+		{name: "new script added", files: testMigrations("one", "two", "three"), err: nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrator, database, _ := newFakeMigrator(t, testMigrations("one", "two"), false)
+			_, err := migrator.Up(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			migrator.migrations = test.files
+			_, err = migrator.Status(ctx)
+			if !errors.Is(err, test.err) {
+				t.Errorf("Status() error = %v, want %v", err, test.err)
+			}
+			_, err = migrator.Up(ctx)
+			if !errors.Is(err, test.err) {
+				t.Errorf("Up() error = %v, want %v", err, test.err)
+			}
+			if test.err != nil && len(database.scripts) != 2 {
+				t.Errorf("Up() ran %v after the drift", database.scripts[2:])
+			}
+		})
+	}
+}
+
+func TestMigratorDryRun(t *testing.T) {
+	ctx := context.Background()
+	migrator, database, out := newFakeMigrator(t, testMigrations("one", "two"), true)
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 {
+		t.Errorf("Up() listed %d migrations, want 2", len(applied))
+	}
+	if database.tableExists || len(database.rows) > 0 || len(database.scripts) > 0 {
+		t.Error("the dry run changed the database")
+	}
+	for _, expected := range []string{"-- 0001_step (up)\none", "-- 0002_step (up)\ntwo"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("dry run output %q misses %q", out.String(), expected)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+CREATE TABLE users (
+    login    VARCHAR(255) PRIMARY KEY,
+    password VARCHAR(255) NOT NULL
+);
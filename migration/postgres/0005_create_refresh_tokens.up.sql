@@ -0,0 +1,12 @@
+CREATE TABLE refresh_tokens (
+    id         UUID PRIMARY KEY,
+    family_id  UUID NOT NULL,
+    login      VARCHAR(255) NOT NULL REFERENCES users (login) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    used_at    TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_login ON refresh_tokens (login);
@@ -0,0 +1,28 @@
+CREATE TABLE product_stock (
+    product_id UUID PRIMARY KEY REFERENCES products (id) ON DELETE CASCADE,
+    on_hand    INTEGER NOT NULL DEFAULT 0 CHECK (on_hand >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE stock_reservations (
+    id         UUID PRIMARY KEY,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity   INTEGER NOT NULL CHECK (quantity > 0),
+    reference  VARCHAR(255) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_reservations_product_id ON stock_reservations (product_id, expires_at);
+
+CREATE TABLE stock_adjustments (
+    id            UUID PRIMARY KEY,
+    product_id    UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    delta         INTEGER NOT NULL,
+    on_hand_after INTEGER NOT NULL,
+    reason        VARCHAR(255) NOT NULL,
+    created_by    VARCHAR(255) NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_adjustments_product_id ON stock_adjustments (product_id, created_at);
@@ -0,0 +1,18 @@
+CREATE TABLE carts (
+    id         UUID PRIMARY KEY,
+    owner      VARCHAR(255) NULL REFERENCES users (login) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_carts_owner ON carts (owner) WHERE owner IS NOT NULL;
+
+CREATE TABLE cart_items (
+    cart_id    UUID NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    name       VARCHAR(255) NOT NULL,
+    unit_price NUMERIC(12, 2) NOT NULL,
+    quantity   INTEGER NOT NULL CHECK (quantity > 0),
+    added_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (cart_id, product_id)
+);
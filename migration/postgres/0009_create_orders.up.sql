@@ -0,0 +1,21 @@
+CREATE TABLE orders (
+    id         UUID PRIMARY KEY,
+    owner      VARCHAR(255) NOT NULL REFERENCES users (login),
+    status     VARCHAR(20) NOT NULL,
+    total      NUMERIC(12, 2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_orders_owner ON orders (owner, created_at);
+CREATE INDEX idx_orders_status ON orders (status, created_at);
+
+CREATE TABLE order_items (
+    order_id   UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    product_id UUID NOT NULL,
+    name       VARCHAR(255) NOT NULL,
+    unit_price NUMERIC(12, 2) NOT NULL,
+    quantity   INTEGER NOT NULL CHECK (quantity > 0),
+    subtotal   NUMERIC(12, 2) NOT NULL,
+    PRIMARY KEY (order_id, product_id)
+);
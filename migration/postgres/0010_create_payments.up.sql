@@ -0,0 +1,14 @@
+CREATE TABLE payments (
+    id                 UUID PRIMARY KEY,
+    reference          VARCHAR(255) NOT NULL,
+    amount             NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    currency           CHAR(3) NOT NULL,
+    status             VARCHAR(20) NOT NULL,
+    provider           VARCHAR(50) NOT NULL,
+    provider_reference VARCHAR(255) NULL,
+    failure_reason     VARCHAR(255) NULL,
+    created_by         VARCHAR(255) NOT NULL REFERENCES users (login),
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (created_by, reference)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE product_prices (
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    currency   CHAR(3) NOT NULL,
+    price      NUMERIC(12, 2) NOT NULL CHECK (price >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, currency)
+);
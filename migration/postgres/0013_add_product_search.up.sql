@@ -0,0 +1,7 @@
+-- name weighs more than description in the ranking of the search
+ALTER TABLE products ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('portuguese', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('portuguese', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
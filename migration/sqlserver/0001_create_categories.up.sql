@@ -0,0 +1,8 @@
+CREATE TABLE categories (
+    id          UNIQUEIDENTIFIER PRIMARY KEY,
+    name        NVARCHAR(255) NOT NULL,
+    description NVARCHAR(MAX) NOT NULL DEFAULT '',
+    active      BIT NOT NULL DEFAULT 1,
+    created_at  DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+    updated_at  DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+);
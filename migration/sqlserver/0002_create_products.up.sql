@@ -0,0 +1,17 @@
+CREATE TABLE products (
+    id          UNIQUEIDENTIFIER PRIMARY KEY,
+    name        NVARCHAR(255) NOT NULL,
+    description NVARCHAR(MAX) NOT NULL DEFAULT '',
+    price       DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    active      BIT NOT NULL DEFAULT 1,
+    created_at  DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+    updated_at  DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+);
+
+CREATE TABLE products_categories (
+    product_id  UNIQUEIDENTIFIER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    category_id UNIQUEIDENTIFIER NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX idx_products_categories_category_id ON products_categories (category_id);
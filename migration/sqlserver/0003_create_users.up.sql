@@ -0,0 +1,4 @@
+CREATE TABLE users (
+    login    NVARCHAR(255) PRIMARY KEY,
+    password NVARCHAR(255) NOT NULL
+);
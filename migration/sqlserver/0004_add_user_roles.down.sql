@@ -0,0 +1,2 @@
+ALTER TABLE users DROP CONSTRAINT df_users_role;
+ALTER TABLE users DROP COLUMN role;
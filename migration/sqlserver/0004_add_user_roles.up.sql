@@ -0,0 +1 @@
+ALTER TABLE users ADD role NVARCHAR(50) NOT NULL CONSTRAINT df_users_role DEFAULT 'customer';
@@ -0,0 +1,12 @@
+CREATE TABLE refresh_tokens (
+    id         UNIQUEIDENTIFIER PRIMARY KEY,
+    family_id  UNIQUEIDENTIFIER NOT NULL,
+    login      NVARCHAR(255) NOT NULL REFERENCES users (login) ON DELETE CASCADE,
+    expires_at DATETIME2 NOT NULL,
+    created_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+    used_at    DATETIME2 NULL,
+    revoked_at DATETIME2 NULL
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_login ON refresh_tokens (login);
@@ -0,0 +1,4 @@
+ALTER TABLE products DROP CONSTRAINT df_products_version;
+ALTER TABLE products DROP COLUMN version;
+ALTER TABLE categories DROP CONSTRAINT df_categories_version;
+ALTER TABLE categories DROP COLUMN version;
@@ -0,0 +1,2 @@
+ALTER TABLE categories ADD version BIGINT NOT NULL CONSTRAINT df_categories_version DEFAULT 1;
+ALTER TABLE products ADD version BIGINT NOT NULL CONSTRAINT df_products_version DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP TABLE stock_adjustments;
+DROP TABLE stock_reservations;
+DROP TABLE product_stock;
@@ -0,0 +1,28 @@
+CREATE TABLE product_stock (
+    product_id UNIQUEIDENTIFIER PRIMARY KEY REFERENCES products (id) ON DELETE CASCADE,
+    on_hand    INT NOT NULL DEFAULT 0 CHECK (on_hand >= 0),
+    updated_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+);
+
+CREATE TABLE stock_reservations (
+    id         UNIQUEIDENTIFIER PRIMARY KEY,
+    product_id UNIQUEIDENTIFIER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity   INT NOT NULL CHECK (quantity > 0),
+    reference  NVARCHAR(255) NOT NULL DEFAULT '',
+    expires_at DATETIME2 NOT NULL,
+    created_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+);
+
+CREATE INDEX idx_stock_reservations_product_id ON stock_reservations (product_id, expires_at);
+
+CREATE TABLE stock_adjustments (
+    id            UNIQUEIDENTIFIER PRIMARY KEY,
+    product_id    UNIQUEIDENTIFIER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    delta         INT NOT NULL,
+    on_hand_after INT NOT NULL,
+    reason        NVARCHAR(255) NOT NULL,
+    created_by    NVARCHAR(255) NOT NULL,
+    created_at    DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+);
+
+CREATE INDEX idx_stock_adjustments_product_id ON stock_adjustments (product_id, created_at);
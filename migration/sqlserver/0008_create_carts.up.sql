@@ -0,0 +1,18 @@
+CREATE TABLE carts (
+    id         UNIQUEIDENTIFIER PRIMARY KEY,
+    owner      NVARCHAR(255) NULL REFERENCES users (login) ON DELETE CASCADE,
+    created_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+    updated_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+);
+
+CREATE UNIQUE INDEX idx_carts_owner ON carts (owner) WHERE owner IS NOT NULL;
+
+CREATE TABLE cart_items (
+    cart_id    UNIQUEIDENTIFIER NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    product_id UNIQUEIDENTIFIER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    name       NVARCHAR(255) NOT NULL,
+    unit_price DECIMAL(12, 2) NOT NULL,
+    quantity   INT NOT NULL CHECK (quantity > 0),
+    added_at   DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+    PRIMARY KEY (cart_id, product_id)
+);
@@ -0,0 +1,21 @@
+CREATE TABLE orders (
+    id         UNIQUEIDENTIFIER PRIMARY KEY,
+    owner      NVARCHAR(255) NOT NULL REFERENCES users (login),
+    status     NVARCHAR(20) NOT NULL,
+    total      DECIMAL(12, 2) NOT NULL,
+    created_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+    updated_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+);
+
+CREATE INDEX idx_orders_owner ON orders (owner, created_at);
+CREATE INDEX idx_orders_status ON orders (status, created_at);
+
+CREATE TABLE order_items (
+    order_id   UNIQUEIDENTIFIER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    product_id UNIQUEIDENTIFIER NOT NULL,
+    name       NVARCHAR(255) NOT NULL,
+    unit_price DECIMAL(12, 2) NOT NULL,
+    quantity   INT NOT NULL CHECK (quantity > 0),
+    subtotal   DECIMAL(12, 2) NOT NULL,
+    PRIMARY KEY (order_id, product_id)
+);
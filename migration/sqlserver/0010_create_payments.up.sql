@@ -0,0 +1,14 @@
+CREATE TABLE payments (
+    id                 UNIQUEIDENTIFIER PRIMARY KEY,
+    reference          NVARCHAR(255) NOT NULL,
+    amount             DECIMAL(12, 2) NOT NULL CHECK (amount > 0),
+    currency           CHAR(3) NOT NULL,
+    status             NVARCHAR(20) NOT NULL,
+    provider           NVARCHAR(50) NOT NULL,
+    provider_reference NVARCHAR(255) NULL,
+    failure_reason     NVARCHAR(255) NULL,
+    created_by         NVARCHAR(255) NOT NULL REFERENCES users (login),
+    created_at         DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+    updated_at         DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+    UNIQUE (created_by, reference)
+);
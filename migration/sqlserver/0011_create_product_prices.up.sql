@@ -0,0 +1,7 @@
+CREATE TABLE product_prices (
+    product_id UNIQUEIDENTIFIER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    currency   CHAR(3) NOT NULL,
+    price      DECIMAL(12, 2) NOT NULL CHECK (price >= 0),
+    updated_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+    PRIMARY KEY (product_id, currency)
+);
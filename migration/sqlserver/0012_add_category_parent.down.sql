@@ -0,0 +1,3 @@
+DROP INDEX idx_categories_parent_id ON categories;
+ALTER TABLE categories DROP CONSTRAINT fk_categories_parent;
+ALTER TABLE categories DROP COLUMN parent_id;
@@ -0,0 +1,3 @@
+ALTER TABLE categories ADD parent_id UNIQUEIDENTIFIER NULL CONSTRAINT fk_categories_parent REFERENCES categories (id);
+
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
@@ -0,0 +1,311 @@
+// Package openapi generates the OpenAPI 3.1 document of the API from the
+// routes registered in the router, described by the Operations of each
+// package, and the JSON encoding of the entities.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const Version = "3.1.0"
+
+// Operation describes a route of the router, matched by Method and Path, the
+// path template as registered.
+type Operation struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	// Permission, when set, is required from the bearer token; Authenticated
+	// asks for a valid token alone.
+	Permission    entities.Permission
+	Authenticated bool
+	// Parameters are the query and header parameters; the path parameters
+	// come from Path, those named id being UUIDs.
+	Parameters []Parameter
+	// Request is a value of the type of the JSON body, or Bodies when the
+	// route reads other media types.
+	Request any
+	Status  int
+	// Response is a value of the type of data in the {"data", "_meta"}
+	// envelope, with the types of _meta in Meta, or of the whole body when
+	// Raw is set. Nil means a response without body.
+	Response      any
+	Meta          []any
+	Raw           bool
+	ResponseTypes []string
+}
+
+// Bodies holds a value of the type of the body read in each media type.
+type Bodies map[string]any
+
+type Parameter struct {
+	Name string
+	// In is "query" when empty.
+	In          string
+	Description string
+	// Value is a value of the type of the parameter.
+	Value    any
+	Required bool
+}
+
+type Document struct {
+	OpenAPI    string                                `json:"openapi"`
+	Info       Info                                  `json:"info"`
+	Servers    []Server                              `json:"servers,omitempty"`
+	Paths      map[string]map[string]operationObject `json:"paths"`
+	Components components                            `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	Url string `json:"url"`
+}
+
+type components struct {
+	Schemas         map[string]Schema         `json:"schemas"`
+	Responses       map[string]responseObject `json:"responses"`
+	SecuritySchemes map[string]Schema         `json:"securitySchemes"`
+}
+
+type operationObject struct {
+	Tags        []string                  `json:"tags,omitempty"`
+	Summary     string                    `json:"summary,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Parameters  []parameterObject         `json:"parameters,omitempty"`
+	RequestBody *requestBodyObject        `json:"requestBody,omitempty"`
+	Responses   map[string]responseObject `json:"responses"`
+	Security    []map[string][]string     `json:"security,omitempty"`
+}
+
+type parameterObject struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Schema      Schema `json:"schema"`
+}
+
+type requestBodyObject struct {
+	Required bool                       `json:"required"`
+	Content  map[string]mediaTypeObject `json:"content"`
+}
+
+type responseObject struct {
+	Ref         string                     `json:"$ref,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Content     map[string]mediaTypeObject `json:"content,omitempty"`
+}
+
+type mediaTypeObject struct {
+	Schema Schema `json:"schema,omitempty"`
+}
+
+// Route is a method and a path template of the router.
+type Route struct {
+	Method string
+	Path   string
+}
+
+func (r Route) String() string {
+	return r.Method + " " + r.Path
+}
+
+// Routes lists the routes of router, leaving out the OPTIONS requests
+// answered by the CORS middleware and the routes without methods, such as
+// the path prefixes of the subrouters.
+func Routes(router *mux.Router) ([]Route, error) {
+	var routes []Route
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			if method != http.MethodOptions {
+				routes = append(routes, Route{Method: method, Path: template})
+			}
+		}
+		return nil
+	})
+	return routes, err
+}
+
+// Undocumented lists the routes of router without an operation.
+func Undocumented(router *mux.Router, operations []Operation) ([]Route, error) {
+	routes, err := Routes(router)
+	if err != nil {
+		return nil, err
+	}
+	var undocumented []Route
+	for _, route := range routes {
+		documented := slices.ContainsFunc(operations, func(operation Operation) bool {
+			return operation.Method == route.Method && operation.Path == route.Path
+		})
+		if !documented {
+			undocumented = append(undocumented, route)
+		}
+	}
+	return undocumented, nil
+}
+
+// Generate documents the routes of router described by operations. The
+// routes without an operation are left out, while an operation without a
+// route, left behind when the route changed, is an error.
+func Generate(router *mux.Router, info Info, serverUrl string, operations []Operation) (Document, error) {
+	routes, err := Routes(router)
+	if err != nil {
+		return Document{}, err
+	}
+
+	schemas := newSchemas()
+	document := Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]map[string]operationObject),
+		Components: components{
+			Responses: map[string]responseObject{
+				"Problem": {
+					Description: "Erro no formato Problem Details (RFC 9457)",
+					Content: map[string]mediaTypeObject{
+						utils.MediaTypeProblem: {Schema: schemas.valueOf(utils.Problem{})},
+					},
+				},
+			},
+			SecuritySchemes: map[string]Schema{
+				"bearer": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+	if serverUrl != "" {
+		document.Servers = []Server{{Url: serverUrl}}
+	}
+
+	for _, operation := range operations {
+		route := Route{Method: operation.Method, Path: operation.Path}
+		if !slices.Contains(routes, route) {
+			return Document{}, fmt.Errorf("openapi: no route for the operation %s", route)
+		}
+		path := pathParamPattern.ReplaceAllString(operation.Path, "{$1}")
+		if document.Paths[path] == nil {
+			document.Paths[path] = make(map[string]operationObject)
+		}
+		document.Paths[path][strings.ToLower(operation.Method)] = operation.object(schemas)
+	}
+	document.Components.Schemas = schemas.components
+	return document, nil
+}
+
+// pathParamPattern matches the variables of the path templates, capturing
+// their names without the patterns some of them have.
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+func (o Operation) object(schemas *schemas) operationObject {
+	object := operationObject{
+		Summary:   o.Summary,
+		Responses: make(map[string]responseObject),
+	}
+	if o.Tag != "" {
+		object.Tags = []string{o.Tag}
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(o.Path, -1) {
+		schema := Schema{"type": "string"}
+		if match[1] == "id" {
+			schema = Schema{"type": "string", "format": "uuid"}
+		}
+		object.Parameters = append(object.Parameters, parameterObject{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	for _, parameter := range o.Parameters {
+		in := parameter.In
+		if in == "" {
+			in = "query"
+		}
+		object.Parameters = append(object.Parameters, parameterObject{
+			Name:        parameter.Name,
+			In:          in,
+			Description: parameter.Description,
+			Required:    parameter.Required,
+			Schema:      schemas.valueOf(parameter.Value),
+		})
+	}
+
+	if o.Request != nil {
+		bodies, isBodies := o.Request.(Bodies)
+		if !isBodies {
+			bodies = Bodies{utils.MediaTypeJSON: o.Request}
+		}
+		object.RequestBody = &requestBodyObject{Required: true, Content: make(map[string]mediaTypeObject)}
+		for mediaType, body := range bodies {
+			object.RequestBody.Content[mediaType] = mediaTypeObject{Schema: schemas.valueOf(body)}
+		}
+	}
+
+	status := o.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := responseObject{Description: http.StatusText(status)}
+	if o.Response != nil {
+		response.Content = make(map[string]mediaTypeObject)
+		responseTypes := o.ResponseTypes
+		if len(responseTypes) == 0 {
+			responseTypes = []string{utils.MediaTypeJSON}
+		}
+		for _, mediaType := range responseTypes {
+			// only the JSON representations follow the schema of the types
+			var schema Schema
+			if mediaType == utils.MediaTypeJSON {
+				schema = o.responseSchema(schemas)
+			}
+			response.Content[mediaType] = mediaTypeObject{Schema: schema}
+		}
+	}
+	object.Responses[strconv.Itoa(status)] = response
+	object.Responses["default"] = responseObject{Ref: "#/components/responses/Problem"}
+
+	if o.Permission != "" || o.Authenticated {
+		object.Security = []map[string][]string{{"bearer": {}}}
+	}
+	if o.Permission != "" {
+		object.Description = fmt.Sprintf("Requer a permissão `%s`.", o.Permission)
+	}
+	return object
+}
+
+func (o Operation) responseSchema(schemas *schemas) Schema {
+	data := schemas.valueOf(o.Response)
+	if o.Raw {
+		return data
+	}
+	properties := map[string]Schema{"data": data}
+	switch len(o.Meta) {
+	case 0:
+	case 1:
+		properties["_meta"] = schemas.valueOf(o.Meta[0])
+	default:
+		meta := make([]Schema, len(o.Meta))
+		for index, value := range o.Meta {
+			meta[index] = schemas.valueOf(value)
+		}
+		properties["_meta"] = Schema{"oneOf": meta}
+	}
+	return Schema{"type": "object", "properties": properties}
+}
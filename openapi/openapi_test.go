@@ -0,0 +1,96 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rest-api-example/auth"
+	"rest-api-example/category"
+	"rest-api-example/config"
+	"rest-api-example/listing"
+	"rest-api-example/memory"
+	"rest-api-example/openapi"
+	"rest-api-example/product"
+	"rest-api-example/user"
+	"slices"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// documentedRouter registers the routes the document covers, on the memory
+// repositories.
+func documentedRouter(t *testing.T) *mux.Router {
+	store := memory.NewStore()
+	userRepository := user.NewUserRepositoryMemory(store)
+	categoryRepository := category.NewCategoryRepositoryMemory(store)
+	productRepository := product.NewProductRepositoryMemory(store)
+	keys, err := auth.NewKeySet(config.JwtConfig{}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authService := auth.NewAuthService(userRepository, auth.NewRefreshTokenRepositoryMemory(store), keys)
+	cursorCodec := listing.NewCursorCodec("secret")
+
+	router := mux.NewRouter()
+	userHandler := user.NewUserHandler(user.NewUserService(userRepository))
+	user.SetupUserRoutes(router, userHandler)
+	category.SetupCategoriesRoutes(router, category.NewCategoryHandler(category.NewCategoryService(categoryRepository), false, cursorCodec), authService)
+	product.SetupProductsRoutes(router, product.NewProductHandler(product.NewProductService(productRepository, categoryRepository), false, cursorCodec), authService)
+	auth.SetupAuthRoutes(router, auth.NewAuthHandler(authService), userHandler)
+	return router
+}
+
+func operations() []openapi.Operation {
+	return slices.Concat(category.Operations(), product.Operations(), user.Operations(), auth.Operations())
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	undocumented, err := openapi.Undocumented(documentedRouter(t), operations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, route := range undocumented {
+		t.Errorf("%s has no operation in Operations()", route)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	router := documentedRouter(t)
+	document, err := openapi.Generate(router, openapi.Info{Title: "test", Version: "1"}, "", operations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = openapi.SetupOpenAPIRoutes(router, document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", recorder.Code)
+	}
+	var served struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &served)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if served.OpenAPI != openapi.Version {
+		t.Errorf("openapi = %q, want %q", served.OpenAPI, openapi.Version)
+	}
+	if _, exists := served.Paths["/products/{id}"]["get"]; !exists {
+		t.Error("GET /products/{id} is missing from the paths")
+	}
+}
+
+func TestGenerateRejectsOperationWithoutRoute(t *testing.T) {
+	stale := append(operations(), openapi.Operation{Method: http.MethodGet, Path: "/removed"})
+	_, err := openapi.Generate(documentedRouter(t), openapi.Info{}, "", stale)
+	if err == nil {
+		t.Error("an operation without a route was accepted")
+	}
+}
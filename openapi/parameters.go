@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"fmt"
+	"rest-api-example/listing"
+	"rest-api-example/utils"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var operatorDescriptions = map[listing.Operator]string{
+	listing.Eq:       "igual a",
+	listing.Ne:       "diferente de",
+	listing.Gt:       "maior que",
+	listing.Gte:      "maior ou igual a",
+	listing.Lt:       "menor que",
+	listing.Lte:      "menor ou igual a",
+	listing.Contains: "contém",
+}
+
+var kindValues = map[listing.Kind]any{
+	listing.Text:    "",
+	listing.Decimal: "",
+	listing.Bool:    false,
+	listing.Time:    "",
+	listing.UUID:    uuid.UUID{},
+}
+
+var (
+	IfMatch = Parameter{Name: "If-Match", In: "header", Value: "",
+		Description: "ETags aceitos, como \"v3\"; sem ele a operação não verifica a versão, a menos que requireIfMatch esteja ativo"}
+	IfNoneMatch = Parameter{Name: "If-None-Match", In: "header", Value: "",
+		Description: "ETags já obtidos, respondidos com 304 Not Modified quando atuais"}
+)
+
+// PageParameters are the parameters of the lists paginated by page.
+var PageParameters = []Parameter{
+	{Name: "page", Value: 0, Description: "página, a partir de 1"},
+	{Name: "limit", Value: 0, Description: "itens por página"},
+}
+
+// ListParameters are the parameters of the lists of schema: the pagination
+// by page or by cursor, the sort and one filter per field and operator.
+func ListParameters(schema listing.Schema) []Parameter {
+	parameters := append([]Parameter{
+		{Name: listing.CursorParam, Value: "", Description: "cursor da página, vazio para a primeira; ativa a paginação por cursor"},
+		{Name: listing.CountParam, Value: false, Description: "false omite o totalCount na paginação por cursor"},
+	}, PageParameters...)
+
+	var sortable []string
+	for _, field := range schema.Fields {
+		if field.Sortable {
+			sortable = append(sortable, field.Name)
+		}
+		for _, operator := range field.Operators {
+			name := field.Name
+			if operator != listing.Eq {
+				name += "[" + string(operator) + "]"
+			}
+			description := fmt.Sprintf("%s %s o valor", field.Name, operatorDescriptions[operator])
+			if field.Kind == listing.Time {
+				description += ", uma data AAAA-MM-DD ou um horário RFC 3339"
+			}
+			parameters = append(parameters, Parameter{Name: name, Value: kindValues[field.Kind], Description: description})
+		}
+	}
+	return append(parameters, Parameter{Name: listing.SortParam, Value: "",
+		Description: "campos separados por vírgula, com - para a ordem decrescente: " + strings.Join(sortable, ", ")})
+}
+
+// RepresentationParameters choose the fields and the related resources of the
+// responses, among relations.
+func RepresentationParameters(relations ...string) []Parameter {
+	return []Parameter{
+		{Name: utils.FieldsParam, Value: "", Description: "campos da resposta, separados por vírgula"},
+		{Name: utils.EmbedParam, Value: "", Description: "recursos relacionados incluídos em _embedded: " + strings.Join(relations, ", ")},
+	}
+}
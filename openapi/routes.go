@@ -0,0 +1,38 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// SetupOpenAPIRoutes serves document at GET /openapi.json and its Redoc page
+// at GET /docs.
+func SetupOpenAPIRoutes(mux *mux.Router, document Document) error {
+	body, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		write(w, body)
+	}).Methods(http.MethodGet)
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		write(w, docsPage)
+	}).Methods(http.MethodGet)
+	return nil
+}
+
+func write(w http.ResponseWriter, body []byte) {
+	_, err := w.Write(body)
+	if err != nil {
+		log.WithError(err).WithField("operation", "openapi.SetupOpenAPIRoutes()").Error("could not write response")
+	}
+}
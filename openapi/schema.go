@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"rest-api-example/entities"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	uuidType   = reflect.TypeFor[uuid.UUID]()
+	timeType   = reflect.TypeFor[time.Time]()
+	moneyType  = reflect.TypeFor[entities.Money]()
+	numberType = reflect.TypeFor[json.Number]()
+	rawType    = reflect.TypeFor[json.RawMessage]()
+)
+
+// Schema is a JSON Schema, as OpenAPI 3.1 uses it.
+type Schema map[string]any
+
+// schemas builds the schemas of Go types from their JSON encoding, the named
+// structs becoming components referenced by name.
+type schemas struct {
+	components map[string]Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// valueOf returns the schema of the type of value, nil for a nil value.
+func (s *schemas) valueOf(value any) Schema {
+	if value == nil {
+		return nil
+	}
+	return s.of(reflect.TypeOf(value))
+}
+
+func (s *schemas) of(t reflect.Type) Schema {
+	switch t {
+	case uuidType:
+		return Schema{"type": "string", "format": "uuid"}
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case rawType:
+		return Schema{}
+	case numberType:
+		return Schema{"type": []string{"number", "string"}}
+	case moneyType:
+		// written by Money.MarshalJSON
+		return s.component(t, func() Schema {
+			return Schema{
+				"type": "object",
+				"properties": map[string]Schema{
+					"amount":   {"type": []string{"string", "number"}, "description": "valor decimal, com as casas decimais da moeda"},
+					"currency": {"type": "string", "description": "código ISO 4217"},
+				},
+			}
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(s.of(t.Elem()))
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return Schema{"type": "integer"}
+	case reflect.Int64:
+		return Schema{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.component(t, func() Schema {
+			return s.object(t)
+		})
+	}
+	return Schema{}
+}
+
+// component registers the schema of t under its name, prefixed by its
+// package when another type took the name, and returns a reference to it.
+func (s *schemas) component(t reflect.Type, build func() Schema) Schema {
+	name, exists := s.names[t]
+	if !exists {
+		name = t.Name()
+		if _, taken := s.components[name]; taken {
+			name = path.Base(t.PkgPath()) + name
+		}
+		// registered before building, for the types referencing themselves
+		s.names[t] = name
+		s.components[name] = Schema{}
+		s.components[name] = build()
+	}
+	return Schema{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *schemas) object(t reflect.Type) Schema {
+	properties := make(map[string]Schema)
+	s.addFields(properties, t)
+	return Schema{"type": "object", "properties": properties}
+}
+
+// addFields adds the fields encoding/json writes, the fields of the embedded
+// structs included.
+func (s *schemas) addFields(properties map[string]Schema, t reflect.Type) {
+	for index := range t.NumField() {
+		field := t.Field(index)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(properties, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.of(field.Type)
+	}
+}
+
+func nullable(schema Schema) Schema {
+	if kind, isString := schema["type"].(string); isString {
+		copied := Schema{}
+		for key, value := range schema {
+			copied[key] = value
+		}
+		copied["type"] = []string{kind, "null"}
+		return copied
+	}
+	return Schema{"anyOf": []Schema{schema, {"type": "null"}}}
+}
@@ -0,0 +1,180 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"rest-api-example/auth"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrFormatoJsonInvalido  = errors.New("verifique o formato do JSON e tente novamente")
+	ErrFiltroStatusInvalido = errors.New("status deve ser pending, paid, shipped, delivered, cancelled ou refunded")
+)
+
+type OrderHandler struct {
+	orderService OrderService
+}
+
+func NewOrderHandler(s OrderService) OrderHandler {
+	return OrderHandler{
+		orderService: s,
+	}
+}
+
+type createOrderRequest struct {
+	Items []entities.OrderLine `json:"items"`
+}
+
+func (h OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	op := "OrderHandler.CreateOrder()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.JSONError(w, r, entities.NewUnauthorizedError(auth.ErrInvalidToken, auth.ErrInvalidToken.Error(), op))
+		return
+	}
+	var request createOrderRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrFormatoJsonInvalido.Error(), op))
+		return
+	}
+
+	order, err := h.orderService.CreateOrder(ctx, principal.Login, request.Items)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf(entities.OrderGet, order.Id.String()))
+	utils.Respond(w, r, order, orderLinks(order, principal), http.StatusCreated)
+}
+
+func (h OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	op := "OrderHandler.GetOrder()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	principal, _ := auth.PrincipalFromContext(r.Context())
+
+	order, err := h.orderService.GetOrder(ctx, id, principal.Login, principal.Role)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, order, orderLinks(order, principal), http.StatusOK)
+}
+
+// GetMyOrders lists the orders of the authenticated user.
+func (h OrderHandler) GetMyOrders(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	h.getOrders(w, r, principal.Login, entities.OrderList, "OrderHandler.GetMyOrders()")
+}
+
+// GetAllOrders lists the orders of every user, for the staff handling them.
+func (h OrderHandler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
+	h.getOrders(w, r, "", entities.OrderAdminList, "OrderHandler.GetAllOrders()")
+}
+
+func (h OrderHandler) getOrders(w http.ResponseWriter, r *http.Request, owner string, listUrl string, op string) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	queryParams := r.URL.Query()
+	page := utils.GetQueryInt(queryParams, "page", 1)
+	limit := utils.GetQueryInt(queryParams, "limit", 10)
+
+	var filtersUrl string
+	status := entities.OrderStatus(queryParams.Get("status"))
+	if status != "" {
+		if !status.IsValid() {
+			utils.JSONError(w, r, entities.NewBadRequestError(ErrFiltroStatusInvalido, ErrFiltroStatusInvalido.Error(), op,
+				entities.FieldError{Field: "status", Message: ErrFiltroStatusInvalido.Error()}))
+			return
+		}
+		filtersUrl += fmt.Sprintf("&status=%s", status)
+	}
+
+	orders, totalCount, err := h.orderService.GetOrders(ctx, owner, status, page, limit)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+
+	resources := make([]entities.OrderResource, len(orders))
+	for index, order := range orders {
+		resources[index] = entities.OrderResource{
+			Order: order,
+			Links: orderLinks(order, principal),
+		}
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+	paginationLinksBuilder := entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf("%s?page=%d&limit=%d%s", listUrl, page, limit, filtersUrl))
+	if page+1 <= totalPages {
+		paginationLinksBuilder.AddGet("next", fmt.Sprintf("%s?page=%d&limit=%d%s", listUrl, page+1, limit, filtersUrl))
+	}
+	if page-1 > 0 {
+		paginationLinksBuilder.AddGet("prev", fmt.Sprintf("%s?page=%d&limit=%d%s", listUrl, page-1, limit, filtersUrl))
+	}
+
+	meta := utils.PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		Results:    len(orders),
+		Hateoas:    paginationLinksBuilder.Build(),
+	}
+	utils.Respond(w, r, resources, meta, http.StatusOK)
+}
+
+func (h OrderHandler) ApplyTransition(w http.ResponseWriter, r *http.Request) {
+	op := "OrderHandler.ApplyTransition()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	principal, _ := auth.PrincipalFromContext(r.Context())
+
+	order, err := h.orderService.ApplyTransition(ctx, id, entities.OrderTransition(vars["transition"]), principal.Login, principal.Role)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, order, orderLinks(order, principal), http.StatusOK)
+}
+
+// orderLinks only links the transitions the principal may apply to the order
+// in its current status.
+func orderLinks(order entities.Order, principal auth.Principal) entities.Hateoas {
+	id := order.Id.String()
+	builder := entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf(entities.OrderGet, id))
+	for _, transition := range AllowedTransitions(order, principal.Login, principal.Role) {
+		builder.AddPost(string(transition), fmt.Sprintf(entities.OrderAction, id, transition))
+	}
+	return builder.Build()
+}
@@ -0,0 +1,242 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type OrderRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewOrderRepositoryPostgres(db *sql.DB) entities.OrderInterface {
+	return OrderRepositoryPostgres{
+		db: db,
+	}
+}
+
+// CreateOrder reads the products FOR SHARE, so their prices cannot change
+// between the snapshot and the commit of the order.
+func (r OrderRepositoryPostgres) CreateOrder(ctx context.Context, order entities.Order, lines []entities.OrderLine) (entities.Order, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Order{}, err
+	}
+	defer tx.Rollback()
+
+	productIds := make([]uuid.UUID, len(lines))
+	for index, line := range lines {
+		productIds[index] = line.ProductId
+	}
+	query, args, err := psql.Select("id", "name", "price", "active").
+		From("products").
+		Where(sq.Eq{"id": productIds}).
+		Suffix("FOR SHARE").
+		ToSql()
+	if err != nil {
+		return entities.Order{}, err
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return entities.Order{}, err
+	}
+	products := make(map[uuid.UUID]entities.Product)
+	for rows.Next() {
+		var product entities.Product
+		err = rows.Scan(&product.Id, &product.Name, &product.Price, &product.Active)
+		if err != nil {
+			rows.Close()
+			return entities.Order{}, err
+		}
+		products[product.Id] = product
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return entities.Order{}, err
+	}
+
+	unavailable := entities.UnavailableProducts(lines, products)
+	if len(unavailable) > 0 {
+		return entities.Order{}, entities.UnavailableProductsError{ProductIds: unavailable}
+	}
+	order.Items, order.Total = entities.NewOrderItems(lines, products)
+
+	query, args, err = psql.Insert("orders").
+		Columns("id", "owner", "status", "total", "created_at", "updated_at").
+		Values(order.Id, order.Owner, order.Status, order.Total, order.CreatedAt, order.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return entities.Order{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	insert := psql.Insert("order_items").
+		Columns("order_id", "product_id", "name", "unit_price", "quantity", "subtotal")
+	for _, item := range order.Items {
+		insert = insert.Values(order.Id, item.ProductId, item.Name, item.UnitPrice, item.Quantity, item.Subtotal)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return entities.Order{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Order{}, err
+	}
+	return order, tx.Commit()
+}
+
+func (r OrderRepositoryPostgres) GetOrderById(ctx context.Context, id uuid.UUID) (entities.Order, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Select("id", "owner", "status", "total", "created_at", "updated_at").
+		From("orders").
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	var order entities.Order
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&order.Id, &order.Owner, &order.Status, &order.Total, &order.CreatedAt, &order.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Order{}, nil
+	}
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	items, err := r.getOrderItems(ctx, []uuid.UUID{order.Id})
+	if err != nil {
+		return entities.Order{}, err
+	}
+	order.Items = items[order.Id]
+	return order, nil
+}
+
+func (r OrderRepositoryPostgres) GetOrders(ctx context.Context, owner string, status entities.OrderStatus, page int, limit int) ([]entities.Order, int, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	where := sq.Eq{}
+	if owner != "" {
+		where["owner"] = owner
+	}
+	if status != "" {
+		where["status"] = status
+	}
+
+	countQuery, countArgs, err := psql.Select("COUNT(*)").
+		From("orders").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query, args, err := psql.Select("id", "owner", "status", "total", "created_at", "updated_at").
+		From("orders").
+		Where(where).
+		OrderBy("created_at DESC", "id").
+		Limit(uint64(limit)).
+		Offset(uint64((page - 1) * limit)).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var orders []entities.Order
+	var ids []uuid.UUID
+	for rows.Next() {
+		var order entities.Order
+		err = rows.Scan(&order.Id, &order.Owner, &order.Status, &order.Total, &order.CreatedAt, &order.UpdatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, order)
+		ids = append(ids, order.Id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(orders) == 0 {
+		return orders, totalCount, nil
+	}
+
+	items, err := r.getOrderItems(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for index := range orders {
+		orders[index].Items = items[orders[index].Id]
+	}
+	return orders, totalCount, nil
+}
+
+// getOrderItems returns the items of the orders grouped by order.
+func (r OrderRepositoryPostgres) getOrderItems(ctx context.Context, orderIds []uuid.UUID) (map[uuid.UUID][]entities.OrderItem, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Select("order_id", "product_id", "name", "unit_price", "quantity", "subtotal").
+		From("order_items").
+		Where(sq.Eq{"order_id": orderIds}).
+		OrderBy("order_id", "name", "product_id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[uuid.UUID][]entities.OrderItem)
+	for _, id := range orderIds {
+		items[id] = []entities.OrderItem{}
+	}
+	for rows.Next() {
+		var orderId uuid.UUID
+		var item entities.OrderItem
+		err = rows.Scan(&orderId, &item.ProductId, &item.Name, &item.UnitPrice, &item.Quantity, &item.Subtotal)
+		if err != nil {
+			return nil, err
+		}
+		items[orderId] = append(items[orderId], item)
+	}
+	return items, rows.Err()
+}
+
+func (r OrderRepositoryPostgres) UpdateOrderStatus(ctx context.Context, id uuid.UUID, from entities.OrderStatus, to entities.OrderStatus, now time.Time) (bool, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Update("orders").
+		Set("status", to).
+		Set("updated_at", now).
+		Where("id = ? AND status = ?", id, from).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
@@ -0,0 +1,79 @@
+package order
+
+import (
+	"context"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrderRepositoryMemory struct {
+	store *memory.Store
+}
+
+func NewOrderRepositoryMemory(store *memory.Store) entities.OrderInterface {
+	return OrderRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r OrderRepositoryMemory) CreateOrder(ctx context.Context, order entities.Order, lines []entities.OrderLine) (entities.Order, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	products := make(map[uuid.UUID]entities.Product)
+	for _, line := range lines {
+		if product, exists := r.store.Products[line.ProductId]; exists {
+			products[line.ProductId] = product
+		}
+	}
+	unavailable := entities.UnavailableProducts(lines, products)
+	if len(unavailable) > 0 {
+		return entities.Order{}, entities.UnavailableProductsError{ProductIds: unavailable}
+	}
+	order.Items, order.Total = entities.NewOrderItems(lines, products)
+	r.store.Orders[order.Id] = order
+	return order, nil
+}
+
+func (r OrderRepositoryMemory) GetOrderById(ctx context.Context, id uuid.UUID) (entities.Order, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	return r.store.Orders[id], nil
+}
+
+func (r OrderRepositoryMemory) GetOrders(ctx context.Context, owner string, status entities.OrderStatus, page int, limit int) ([]entities.Order, int, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	var orders []entities.Order
+	for _, order := range r.store.Orders {
+		if (owner == "" || order.Owner == owner) && (status == "" || order.Status == status) {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].Id.String() < orders[j].Id.String()
+		}
+		return orders[i].CreatedAt.After(orders[j].CreatedAt)
+	})
+	return memory.Page(orders, page, limit), len(orders), nil
+}
+
+func (r OrderRepositoryMemory) UpdateOrderStatus(ctx context.Context, id uuid.UUID, from entities.OrderStatus, to entities.OrderStatus, now time.Time) (bool, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	order, exists := r.store.Orders[id]
+	if !exists || order.Status != from {
+		return false, nil
+	}
+	order.Status = to
+	order.UpdatedAt = now
+	r.store.Orders[id] = order
+	return true, nil
+}
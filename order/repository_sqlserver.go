@@ -0,0 +1,253 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/google/uuid"
+)
+
+type OrderRepositorySqlServer struct {
+	db *sql.DB
+}
+
+func NewOrderRepositorySqlServer(db *sql.DB) entities.OrderInterface {
+	return OrderRepositorySqlServer{
+		db: db,
+	}
+}
+
+// CreateOrder reads the products WITH (HOLDLOCK), so their prices cannot
+// change between the snapshot and the commit of the order.
+func (r OrderRepositorySqlServer) CreateOrder(ctx context.Context, order entities.Order, lines []entities.OrderLine) (entities.Order, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Order{}, err
+	}
+	defer tx.Rollback()
+
+	productIds := make([]string, len(lines))
+	for index, line := range lines {
+		productIds[index] = line.ProductId.String()
+	}
+	query, args, err := sq.Select("id", "name", "price", "active").
+		From("products WITH (HOLDLOCK)").
+		Where(sq.Eq{"id": productIds}).
+		ToSql()
+	if err != nil {
+		return entities.Order{}, err
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return entities.Order{}, err
+	}
+	products := make(map[uuid.UUID]entities.Product)
+	for rows.Next() {
+		var id mssql.UniqueIdentifier
+		var product entities.Product
+		err = rows.Scan(&id, &product.Name, &product.Price, &product.Active)
+		if err != nil {
+			rows.Close()
+			return entities.Order{}, err
+		}
+		product.Id = uuid.UUID(id)
+		products[product.Id] = product
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return entities.Order{}, err
+	}
+
+	unavailable := entities.UnavailableProducts(lines, products)
+	if len(unavailable) > 0 {
+		return entities.Order{}, entities.UnavailableProductsError{ProductIds: unavailable}
+	}
+	order.Items, order.Total = entities.NewOrderItems(lines, products)
+
+	query, args, err = sq.Insert("orders").
+		Columns("id", "owner", "status", "total", "created_at", "updated_at").
+		Values(order.Id.String(), order.Owner, string(order.Status), order.Total, order.CreatedAt, order.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return entities.Order{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	insert := sq.Insert("order_items").
+		Columns("order_id", "product_id", "name", "unit_price", "quantity", "subtotal")
+	for _, item := range order.Items {
+		insert = insert.Values(order.Id.String(), item.ProductId.String(), item.Name, item.UnitPrice, item.Quantity, item.Subtotal)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return entities.Order{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.Order{}, err
+	}
+	return order, tx.Commit()
+}
+
+func (r OrderRepositorySqlServer) GetOrderById(ctx context.Context, id uuid.UUID) (entities.Order, error) {
+	query, args, err := sq.Select("id", "owner", "status", "total", "created_at", "updated_at").
+		From("orders").
+		Where("id = ?", id.String()).
+		ToSql()
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	order, err := scanOrderSqlServer(r.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Order{}, nil
+	}
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	items, err := r.getOrderItems(ctx, []uuid.UUID{order.Id})
+	if err != nil {
+		return entities.Order{}, err
+	}
+	order.Items = items[order.Id]
+	return order, nil
+}
+
+func (r OrderRepositorySqlServer) GetOrders(ctx context.Context, owner string, status entities.OrderStatus, page int, limit int) ([]entities.Order, int, error) {
+	where := sq.Eq{}
+	if owner != "" {
+		where["owner"] = owner
+	}
+	if status != "" {
+		where["status"] = string(status)
+	}
+
+	countQuery, countArgs, err := sq.Select("COUNT(*)").
+		From("orders").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query, args, err := sq.Select("id", "owner", "status", "total", "created_at", "updated_at").
+		From("orders").
+		Where(where).
+		OrderBy("created_at DESC", "id").
+		Suffix("OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", (page-1)*limit, limit).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var orders []entities.Order
+	var ids []uuid.UUID
+	for rows.Next() {
+		order, err := scanOrderSqlServer(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, order)
+		ids = append(ids, order.Id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(orders) == 0 {
+		return orders, totalCount, nil
+	}
+
+	items, err := r.getOrderItems(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for index := range orders {
+		orders[index].Items = items[orders[index].Id]
+	}
+	return orders, totalCount, nil
+}
+
+// getOrderItems returns the items of the orders grouped by order.
+func (r OrderRepositorySqlServer) getOrderItems(ctx context.Context, orderIds []uuid.UUID) (map[uuid.UUID][]entities.OrderItem, error) {
+	ids := make([]string, len(orderIds))
+	for index, id := range orderIds {
+		ids[index] = id.String()
+	}
+	query, args, err := sq.Select("order_id", "product_id", "name", "unit_price", "quantity", "subtotal").
+		From("order_items").
+		Where(sq.Eq{"order_id": ids}).
+		OrderBy("order_id", "name", "product_id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[uuid.UUID][]entities.OrderItem)
+	for _, id := range orderIds {
+		items[id] = []entities.OrderItem{}
+	}
+	for rows.Next() {
+		var orderId, productId mssql.UniqueIdentifier
+		var item entities.OrderItem
+		err = rows.Scan(&orderId, &productId, &item.Name, &item.UnitPrice, &item.Quantity, &item.Subtotal)
+		if err != nil {
+			return nil, err
+		}
+		item.ProductId = uuid.UUID(productId)
+		items[uuid.UUID(orderId)] = append(items[uuid.UUID(orderId)], item)
+	}
+	return items, rows.Err()
+}
+
+func (r OrderRepositorySqlServer) UpdateOrderStatus(ctx context.Context, id uuid.UUID, from entities.OrderStatus, to entities.OrderStatus, now time.Time) (bool, error) {
+	query, args, err := sq.Update("orders").
+		Set("status", string(to)).
+		Set("updated_at", now).
+		Where("id = ? AND status = ?", id.String(), string(from)).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func scanOrderSqlServer(row utils.RowScanner) (entities.Order, error) {
+	var id mssql.UniqueIdentifier
+	order := entities.Order{}
+	err := row.Scan(&id, &order.Owner, &order.Status, &order.Total, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return entities.Order{}, err
+	}
+	order.Id = uuid.UUID(id)
+	return order, nil
+}
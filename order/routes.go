@@ -0,0 +1,41 @@
+package order
+
+import (
+	"net/http"
+	"rest-api-example/auth"
+	"rest-api-example/entities"
+	"rest-api-example/middlewares"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/cors"
+)
+
+func SetupOrderRoutes(mux *mux.Router, h OrderHandler, authService auth.AuthService) {
+	corsHandler := cors.New(cors.Options{
+		AllowedOrigins: []string{"http://127.0.0.1:5500"},
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+	}).Handler
+
+	r := mux.PathPrefix("/orders").Subrouter()
+	r.Use(corsHandler)
+	r.Use(authService.AuthenticationMiddleware)
+	r.HandleFunc("",
+		middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
+			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.CreateOrder))).Methods(http.MethodOptions,
+		http.MethodPost)
+	r.HandleFunc("", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+		h.GetMyOrders)).Methods(http.MethodOptions, http.MethodGet)
+	r.HandleFunc("/{id}", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+		h.GetOrder)).Methods(http.MethodOptions, http.MethodGet)
+	r.HandleFunc("/{id}/{transition:pay|ship|deliver|cancel|refund}", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+		h.ApplyTransition)).Methods(http.MethodOptions, http.MethodPost)
+
+	admin := mux.PathPrefix("/admin/orders").Subrouter()
+	admin.Use(corsHandler)
+	admin.Use(authService.AuthenticationMiddleware)
+	admin.HandleFunc("",
+		authService.RequirePermission(entities.PermissionOrderManage,
+			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.GetAllOrders))).Methods(http.MethodOptions,
+		http.MethodGet)
+}
@@ -0,0 +1,179 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rest-api-example/entities"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxItemQuantity = 999
+	MaxOrderLines   = 100
+)
+
+var (
+	ErrPedidoNaoEncontrado   = errors.New("pedido não encontrado")
+	ErrPedidoSemItens        = errors.New("o pedido deve ter ao menos um item e no máximo 100")
+	ErrProdutoObrigatorio    = errors.New("o produto deve ser informado")
+	ErrQuantidadeInvalida    = errors.New("a quantidade deve estar entre 1 e 999")
+	ErrProdutoIndisponivel   = errors.New("produto inexistente ou inativo")
+	ErrTransicaoNaoPermitida = errors.New("usuário não pode executar esta ação no pedido")
+	ErrTransicaoInvalida     = errors.New("a ação não é permitida no status atual do pedido")
+	ErrStatusAlterado        = errors.New("o status do pedido foi alterado por outra requisição")
+)
+
+// customerTransitions are the transitions the owner of an order may apply
+// without the order:manage permission.
+var customerTransitions = []entities.OrderTransition{entities.TransitionCancel}
+
+type OrderService struct {
+	orderRepository entities.OrderInterface
+}
+
+func NewOrderService(o entities.OrderInterface) OrderService {
+	return OrderService{
+		orderRepository: o,
+	}
+}
+
+// CreateOrder checks out the lines for login. Lines of the same product are
+// merged, and the order keeps the prices the products have now.
+func (s OrderService) CreateOrder(ctx context.Context, login string, lines []entities.OrderLine) (entities.Order, error) {
+	op := "OrderService.CreateOrder()"
+	merged, err := mergeOrderLines(lines, op)
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	now := time.Now().UTC()
+	order := entities.Order{
+		Id:        uuid.New(),
+		Owner:     login,
+		Status:    entities.OrderPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	order, err = s.orderRepository.CreateOrder(ctx, order, merged)
+	var unavailable entities.UnavailableProductsError
+	if errors.As(err, &unavailable) {
+		var fields []entities.FieldError
+		for index, line := range lines {
+			if slices.Contains(unavailable.ProductIds, line.ProductId) {
+				fields = append(fields, entities.FieldError{Field: fmt.Sprintf("items[%d].product_id", index), Message: ErrProdutoIndisponivel.Error()})
+			}
+		}
+		return entities.Order{}, entities.NewUnprocessableEntityError(err, ErrProdutoIndisponivel.Error(), op, fields...)
+	}
+	if err != nil {
+		return entities.Order{}, entities.NewInternalServerErrorError(err, op)
+	}
+	return order, nil
+}
+
+// GetOrder returns the order when it belongs to login or role may manage
+// orders; other orders are reported as not found.
+func (s OrderService) GetOrder(ctx context.Context, id uuid.UUID, login string, role entities.Role) (entities.Order, error) {
+	op := "OrderService.GetOrder()"
+	order, err := s.orderRepository.GetOrderById(ctx, id)
+	if err != nil {
+		return entities.Order{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if order.IsEmpty() || (order.Owner != login && !role.HasPermission(entities.PermissionOrderManage)) {
+		return entities.Order{}, entities.NewNotFoundError(ErrPedidoNaoEncontrado, ErrPedidoNaoEncontrado.Error(), op)
+	}
+	return order, nil
+}
+
+// GetOrders lists the orders of owner, or of every user when owner is empty.
+func (s OrderService) GetOrders(ctx context.Context, owner string, status entities.OrderStatus, page int, limit int) ([]entities.Order, int, error) {
+	op := "OrderService.GetOrders()"
+	orders, totalCount, err := s.orderRepository.GetOrders(ctx, owner, status, page, limit)
+	if err != nil {
+		return nil, 0, entities.NewInternalServerErrorError(err, op)
+	}
+	return orders, totalCount, nil
+}
+
+// ApplyTransition moves the order through the state machine. The owner may
+// only cancel, every other transition requires the order:manage permission.
+func (s OrderService) ApplyTransition(ctx context.Context, id uuid.UUID, transition entities.OrderTransition, login string, role entities.Role) (entities.Order, error) {
+	op := "OrderService.ApplyTransition()"
+	order, err := s.GetOrder(ctx, id, login, role)
+	if err != nil {
+		return entities.Order{}, err
+	}
+	if !canApply(order, transition, login, role) {
+		return entities.Order{}, entities.NewForbiddenError(ErrTransicaoNaoPermitida, ErrTransicaoNaoPermitida.Error(), op)
+	}
+	next, allowed := order.Status.Next(transition)
+	if !allowed {
+		return entities.Order{}, entities.NewConflictError(ErrTransicaoInvalida, ErrTransicaoInvalida.Error(), op)
+	}
+
+	updated, err := s.orderRepository.UpdateOrderStatus(ctx, id, order.Status, next, time.Now().UTC())
+	if err != nil {
+		return entities.Order{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if !updated {
+		return entities.Order{}, entities.NewConflictError(ErrStatusAlterado, ErrStatusAlterado.Error(), op)
+	}
+	return s.GetOrder(ctx, id, login, role)
+}
+
+// AllowedTransitions returns the transitions login may apply to the order in
+// its current status.
+func AllowedTransitions(order entities.Order, login string, role entities.Role) []entities.OrderTransition {
+	var transitions []entities.OrderTransition
+	for _, transition := range order.Status.Transitions() {
+		if canApply(order, transition, login, role) {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions
+}
+
+func canApply(order entities.Order, transition entities.OrderTransition, login string, role entities.Role) bool {
+	if role.HasPermission(entities.PermissionOrderManage) {
+		return true
+	}
+	return order.Owner == login && slices.Contains(customerTransitions, transition)
+}
+
+// mergeOrderLines validates the lines and sums the quantities of lines of the
+// same product, keeping the position of the first one.
+func mergeOrderLines(lines []entities.OrderLine, op string) ([]entities.OrderLine, error) {
+	if len(lines) == 0 || len(lines) > MaxOrderLines {
+		return nil, entities.NewUnprocessableEntityError(ErrPedidoSemItens, ErrPedidoSemItens.Error(), op,
+			entities.FieldError{Field: "items", Message: ErrPedidoSemItens.Error()})
+	}
+
+	var fields []entities.FieldError
+	var merged []entities.OrderLine
+	for index, line := range lines {
+		if line.ProductId == uuid.Nil {
+			fields = append(fields, entities.FieldError{Field: fmt.Sprintf("items[%d].product_id", index), Message: ErrProdutoObrigatorio.Error()})
+			continue
+		}
+		position := slices.IndexFunc(merged, func(m entities.OrderLine) bool {
+			return m.ProductId == line.ProductId
+		})
+		if position < 0 {
+			merged = append(merged, line)
+			position = len(merged) - 1
+		} else {
+			merged[position].Quantity += line.Quantity
+		}
+		if line.Quantity < 1 || merged[position].Quantity > MaxItemQuantity {
+			fields = append(fields, entities.FieldError{Field: fmt.Sprintf("items[%d].quantity", index), Message: ErrQuantidadeInvalida.Error()})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, entities.NewUnprocessableEntityError(errors.New(fields[0].Message), fields[0].Message, op, fields...)
+	}
+	return merged, nil
+}
@@ -0,0 +1,271 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"rest-api-example/entities"
+	"strconv"
+	"strings"
+)
+
+const (
+	MediaTypeJSONPatch = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedOperation = errors.New("operação não suportada, use add, remove, replace ou test")
+	ErrMissingValue         = errors.New("a operação exige o membro value")
+	ErrInvalidPath          = errors.New("path inválido")
+	ErrPathNotFound         = errors.New("path não encontrado")
+	ErrTestFailed           = errors.New("o valor atual é diferente do informado na operação test")
+)
+
+// Operation is one entry of an RFC 6902 document.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ReadJSONPatch reads an RFC 6902 document and checks that every operation
+// is supported and well formed before anything is applied.
+func ReadJSONPatch(body io.Reader) ([]Operation, error) {
+	var operations []Operation
+	err := json.NewDecoder(body).Decode(&operations)
+	if err != nil {
+		return nil, err
+	}
+	for index, operation := range operations {
+		switch operation.Op {
+		case "add", "replace", "test":
+			if len(operation.Value) == 0 {
+				return nil, fmt.Errorf("operação %d: %w", index, ErrMissingValue)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("operação %d: %w", index, ErrUnsupportedOperation)
+		}
+		if _, err := parsePointer(operation.Path); err != nil {
+			return nil, fmt.Errorf("operação %d: %w", index, err)
+		}
+	}
+	return operations, nil
+}
+
+// ApplyJSONPatch applies the operations, in order, to the current document and
+// returns the top level members that changed as a merge patch document, so the
+// result goes through the same allowlist as ApplyMergePatch. Nothing is
+// returned if any operation fails, a failed test included.
+func ApplyJSONPatch(document map[string]json.RawMessage, operations []Operation) (map[string]json.RawMessage, error) {
+	before, err := decodeDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	working, err := decodeDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var root any = working
+	for index, operation := range operations {
+		root, err = applyOperation(root, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operação %d (%s %s): %w", index, operation.Op, operation.Path, err)
+		}
+	}
+	after := root.(map[string]any)
+
+	changes := make(map[string]json.RawMessage)
+	for name, value := range after {
+		if previous, exists := before[name]; exists && reflect.DeepEqual(previous, value) {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		changes[name] = raw
+	}
+	for name := range before {
+		if _, exists := after[name]; !exists {
+			changes[name] = json.RawMessage("null")
+		}
+	}
+	return changes, nil
+}
+
+func decodeDocument(document map[string]json.RawMessage) (map[string]any, error) {
+	decoded := make(map[string]any, len(document))
+	for name, raw := range document {
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		decoded[name] = value
+	}
+	return decoded, nil
+}
+
+func applyOperation(root any, operation Operation) (any, error) {
+	tokens, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	if len(operation.Value) > 0 {
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	if operation.Op == "test" {
+		current, err := getValue(root, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+	}
+
+	return updateAt(root, tokens, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			_, exists := node[token]
+			switch operation.Op {
+			case "add":
+				node[token] = value
+			case "remove":
+				if !exists {
+					return nil, ErrPathNotFound
+				}
+				delete(node, token)
+			case "replace":
+				if !exists {
+					return nil, ErrPathNotFound
+				}
+				node[token] = value
+			}
+			return node, nil
+		case []any:
+			index, err := arrayIndex(token, len(node), operation.Op == "add")
+			if err != nil {
+				return nil, err
+			}
+			switch operation.Op {
+			case "add":
+				node = append(node, nil)
+				copy(node[index+1:], node[index:])
+				node[index] = value
+			case "remove":
+				node = append(node[:index], node[index+1:]...)
+			case "replace":
+				node[index] = value
+			}
+			return node, nil
+		default:
+			return nil, ErrPathNotFound
+		}
+	})
+}
+
+// updateAt walks to the parent of the last token and lets change rebuild it,
+// storing the result back since slices may be reallocated.
+func updateAt(node any, tokens []string, change func(parent any, token string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return change(node, tokens[0])
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		child, exists := n[tokens[0]]
+		if !exists {
+			return nil, ErrPathNotFound
+		}
+		child, err := updateAt(child, tokens[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = child
+		return n, nil
+	case []any:
+		index, err := arrayIndex(tokens[0], len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := updateAt(n[index], tokens[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		n[index] = child
+		return n, nil
+	default:
+		return nil, ErrPathNotFound
+	}
+}
+
+func getValue(node any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]any:
+			child, exists := n[token]
+			if !exists {
+				return nil, ErrPathNotFound
+			}
+			node = child
+		case []any:
+			index, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return node, nil
+}
+
+// arrayIndex resolves an array token; "-" and len are only valid when adding.
+func arrayIndex(token string, length int, adding bool) (int, error) {
+	if adding && token == "-" {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, ErrInvalidPath
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, ErrInvalidPath
+	}
+	if index > length || (index == length && !adding) {
+		return 0, ErrPathNotFound
+	}
+	return index, nil
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer. The whole document ("") can
+// not be targeted, since resources are patched member by member.
+func parsePointer(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, ErrInvalidPath
+	}
+	tokens := strings.Split(path[1:], "/")
+	for index, token := range tokens {
+		tokens[index] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// ApplyError maps a failed ApplyJSONPatch to the API error: 409 when a test
+// operation failed and 422 when the document can not be applied.
+func ApplyError(err error, operation string) error {
+	if errors.Is(err, ErrTestFailed) {
+		return entities.NewConflictError(err, err.Error(), operation)
+	}
+	return entities.NewUnprocessableEntityError(err, err.Error(), operation)
+}
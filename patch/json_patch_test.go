@@ -0,0 +1,121 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"rest-api-example/entities"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  error
+	}{
+		{name: "valid", body: `[{"op":"replace","path":"/name","value":"a"},{"op":"remove","path":"/tags/0"}]`},
+		{name: "unsupported operation", body: `[{"op":"move","from":"/a","path":"/b"}]`, err: ErrUnsupportedOperation},
+		{name: "missing value", body: `[{"op":"add","path":"/name"}]`, err: ErrMissingValue},
+		{name: "null value", body: `[{"op":"test","path":"/name","value":null}]`},
+		{name: "whole document", body: `[{"op":"replace","path":"","value":{}}]`, err: ErrInvalidPath},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadJSONPatch(strings.NewReader(test.body))
+			if !errors.Is(err, test.err) {
+				t.Errorf("ReadJSONPatch() error = %v, want %v", err, test.err)
+			}
+		})
+	}
+
+	if _, err := ReadJSONPatch(strings.NewReader(`{"op":"add"}`)); err == nil {
+		t.Error("ReadJSONPatch() accepted an object")
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	document := `{"name":"a","tags":["x","y"],"price":{"amount":10,"currency":"BRL"},"active":true}`
+
+	tests := []struct {
+		name       string
+		operations string
+		// changes is the expected merge patch, with sorted keys
+		changes string
+		err     error
+	}{
+		{name: "replace member", operations: `[{"op":"replace","path":"/name","value":"b"}]`, changes: `{"name":"b"}`},
+		{name: "replace with same value", operations: `[{"op":"replace","path":"/name","value":"a"}]`, changes: `{}`},
+		{name: "append to array", operations: `[{"op":"add","path":"/tags/-","value":"z"}]`, changes: `{"tags":["x","y","z"]}`},
+		{name: "insert in array", operations: `[{"op":"add","path":"/tags/0","value":"w"}]`, changes: `{"tags":["w","x","y"]}`},
+		{name: "remove from array", operations: `[{"op":"remove","path":"/tags/0"}]`, changes: `{"tags":["y"]}`},
+		{name: "remove member", operations: `[{"op":"remove","path":"/active"}]`, changes: `{"active":null}`},
+		{name: "nested member", operations: `[{"op":"replace","path":"/price/amount","value":12}]`, changes: `{"price":{"amount":12,"currency":"BRL"}}`},
+		{name: "escaped pointer", operations: `[{"op":"add","path":"/a~1b~0c","value":1}]`, changes: `{"a/b~c":1}`},
+		{
+			name:       "test then replace",
+			operations: `[{"op":"test","path":"/price/currency","value":"BRL"},{"op":"replace","path":"/name","value":"b"}]`,
+			changes:    `{"name":"b"}`,
+		},
+		{name: "test fails", operations: `[{"op":"test","path":"/name","value":"b"}]`, err: ErrTestFailed},
+		{name: "replace missing member", operations: `[{"op":"replace","path":"/missing","value":1}]`, err: ErrPathNotFound},
+		{name: "index out of range", operations: `[{"op":"remove","path":"/tags/2"}]`, err: ErrPathNotFound},
+		{name: "index with leading zero", operations: `[{"op":"replace","path":"/tags/01","value":"z"}]`, err: ErrInvalidPath},
+		{name: "end of array outside add", operations: `[{"op":"replace","path":"/tags/-","value":"z"}]`, err: ErrInvalidPath},
+		{name: "missing parent", operations: `[{"op":"add","path":"/missing/name","value":1}]`, err: ErrPathNotFound},
+		{
+			name:       "failed operation after a successful one",
+			operations: `[{"op":"replace","path":"/name","value":"b"},{"op":"test","path":"/active","value":false}]`,
+			err:        ErrTestFailed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var current map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(document), &current); err != nil {
+				t.Fatal(err)
+			}
+			operations, err := ReadJSONPatch(strings.NewReader(test.operations))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			changes, err := ApplyJSONPatch(current, operations)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("ApplyJSONPatch() error = %v, want %v", err, test.err)
+			}
+			if err != nil {
+				if changes != nil {
+					t.Errorf("ApplyJSONPatch() returned %v along with the error", changes)
+				}
+				return
+			}
+			content, err := json.Marshal(changes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.changes {
+				t.Errorf("ApplyJSONPatch() = %s, want %s", content, test.changes)
+			}
+		})
+	}
+}
+
+func TestApplyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{name: "failed test", err: ErrTestFailed, code: entities.CONFLICT},
+		{name: "path not found", err: ErrPathNotFound, code: entities.UNPROCESSABLE_ENTITY},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var apiError *entities.Error
+			if !errors.As(ApplyError(test.err, "test"), &apiError) || apiError.Code != test.code {
+				t.Errorf("ApplyError() = %v, want code %q", apiError, test.code)
+			}
+		})
+	}
+}
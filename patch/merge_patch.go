@@ -0,0 +1,170 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"rest-api-example/entities"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MediaTypeMergePatch = "application/merge-patch+json"
+)
+
+var (
+	ErrDocumentMustBeObject = errors.New("o documento de merge patch deve ser um objeto JSON")
+	ErrInvalidFields        = errors.New("um ou mais campos são inválidos")
+)
+
+// Field is one entry of the allowlist of a resource. Apply decodes the raw
+// JSON value and stores it in the update being built.
+type Field struct {
+	Name     string
+	ReadOnly bool
+	Nullable bool
+	Apply    func(raw json.RawMessage) error
+}
+
+// ReadMergePatch reads an RFC 7396 document, which must be a JSON object.
+func ReadMergePatch(body io.Reader) (map[string]json.RawMessage, error) {
+	var document map[string]json.RawMessage
+	decoder := json.NewDecoder(body)
+	err := decoder.Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, ErrDocumentMustBeObject
+	}
+	return document, nil
+}
+
+// ApplyMergePatch applies every member of the document through the allowlist
+// and reports all the rejected members at once.
+func ApplyMergePatch(document map[string]json.RawMessage, fields []Field) []entities.FieldError {
+	allowed := make(map[string]Field, len(fields))
+	for _, field := range fields {
+		allowed[field.Name] = field
+	}
+
+	names := make([]string, 0, len(document))
+	for name := range document {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fieldErrors []entities.FieldError
+	for _, name := range names {
+		raw := document[name]
+		field, exists := allowed[name]
+		switch {
+		case !exists:
+			fieldErrors = append(fieldErrors, entities.FieldError{Field: name, Message: "campo desconhecido"})
+		case field.ReadOnly:
+			fieldErrors = append(fieldErrors, entities.FieldError{Field: name, Message: "campo não pode ser alterado"})
+		case isNull(raw) && !field.Nullable:
+			fieldErrors = append(fieldErrors, entities.FieldError{Field: name, Message: "campo não pode ser removido"})
+		default:
+			if err := field.Apply(raw); err != nil {
+				fieldErrors = append(fieldErrors, entities.FieldError{Field: name, Message: err.Error()})
+			}
+		}
+	}
+	return fieldErrors
+}
+
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+// String accepts a JSON string with length between min and max characters.
+// A null value (for nullable fields) is stored as an empty string.
+func String(target **string, min int, max int) func(json.RawMessage) error {
+	return func(raw json.RawMessage) error {
+		var value string
+		if !isNull(raw) {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return errors.New("deve ser um texto")
+			}
+		}
+		length := utf8.RuneCountInString(value)
+		if length < min || length > max {
+			return fmt.Errorf("deve ter entre %d e %d caracteres", min, max)
+		}
+		*target = &value
+		return nil
+	}
+}
+
+// Bool accepts JSON booleans and, for older clients, the strings "true" and "false".
+func Bool(target **bool) func(json.RawMessage) error {
+	return func(raw json.RawMessage) error {
+		var value bool
+		if err := json.Unmarshal(raw, &value); err != nil {
+			var text string
+			if json.Unmarshal(raw, &text) != nil {
+				return errors.New("deve ser um booleano")
+			}
+			value, err = strconv.ParseBool(text)
+			if err != nil {
+				return errors.New("deve ser um booleano")
+			}
+		}
+		*target = &value
+		return nil
+	}
+}
+
+// NullableUUID accepts a UUID string, or null, stored as an invalid NullUUID.
+func NullableUUID(target **uuid.NullUUID) func(json.RawMessage) error {
+	return func(raw json.RawMessage) error {
+		value := uuid.NullUUID{}
+		if !isNull(raw) {
+			var text string
+			if err := json.Unmarshal(raw, &text); err != nil {
+				return errors.New("deve ser um UUID")
+			}
+			id, err := uuid.Parse(text)
+			if err != nil {
+				return fmt.Errorf("UUID inválido: %s", text)
+			}
+			value = uuid.NullUUID{UUID: id, Valid: true}
+		}
+		*target = &value
+		return nil
+	}
+}
+
+// UUIDList accepts an array of UUID strings with at least min distinct items.
+func UUIDList(target **[]uuid.UUID, min int) func(json.RawMessage) error {
+	return func(raw json.RawMessage) error {
+		var values []string
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return errors.New("deve ser uma lista de UUIDs")
+		}
+		ids := make([]uuid.UUID, 0, len(values))
+		seen := make(map[uuid.UUID]bool, len(values))
+		for _, value := range values {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return fmt.Errorf("UUID inválido: %s", value)
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) < min {
+			return fmt.Errorf("deve ter ao menos %d item(s)", min)
+		}
+		*target = &ids
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"rest-api-example/entities"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestReadMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{name: "object", body: `{"name":"a"}`, valid: true},
+		{name: "empty object", body: `{}`, valid: true},
+		{name: "null", body: `null`},
+		{name: "array", body: `[{"name":"a"}]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadMergePatch(strings.NewReader(test.body))
+			if (err == nil) != test.valid {
+				t.Errorf("ReadMergePatch() error = %v, want valid %v", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	var name, description *string
+	var active *bool
+	var parent *uuid.NullUUID
+	fields := []Field{
+		{Name: "id", ReadOnly: true},
+		{Name: "name", Apply: String(&name, 1, 5)},
+		{Name: "description", Nullable: true, Apply: String(&description, 0, 5)},
+		{Name: "active", Apply: Bool(&active)},
+		{Name: "parentId", Nullable: true, Apply: NullableUUID(&parent)},
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		rejected []entities.FieldError
+	}{
+		{name: "allowed fields", document: `{"name":"abc","description":null,"active":"true","parentId":null}`},
+		{
+			name:     "every rejected field at once",
+			document: `{"zeta":1,"id":"x","name":null,"active":"maybe","parentId":"123"}`,
+			rejected: []entities.FieldError{
+				{Field: "active", Message: "deve ser um booleano"},
+				{Field: "id", Message: "campo não pode ser alterado"},
+				{Field: "name", Message: "campo não pode ser removido"},
+				{Field: "parentId", Message: "UUID inválido: 123"},
+				{Field: "zeta", Message: "campo desconhecido"},
+			},
+		},
+		{
+			name:     "too long",
+			document: `{"name":"abcdef"}`,
+			rejected: []entities.FieldError{{Field: "name", Message: "deve ter entre 1 e 5 caracteres"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var document map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(test.document), &document); err != nil {
+				t.Fatal(err)
+			}
+			rejected := ApplyMergePatch(document, fields)
+			if !slices.Equal(rejected, test.rejected) {
+				t.Errorf("ApplyMergePatch() = %v, want %v", rejected, test.rejected)
+			}
+		})
+	}
+
+	if name == nil || *name != "abc" || description == nil || *description != "" || active == nil || !*active || parent == nil || parent.Valid {
+		t.Errorf("the allowed fields were not applied: name %v, description %v, active %v, parent %v", name, description, active, parent)
+	}
+}
+
+func TestUUIDList(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	tests := []struct {
+		name string
+		raw  string
+		ids  []uuid.UUID
+		err  bool
+	}{
+		{name: "list", raw: `["` + first.String() + `","` + second.String() + `"]`, ids: []uuid.UUID{first, second}},
+		{name: "duplicated ids", raw: `["` + first.String() + `","` + first.String() + `"]`, ids: []uuid.UUID{first}},
+		{name: "below min after removing duplicates", raw: `[]`, err: true},
+		{name: "invalid id", raw: `["x"]`, err: true},
+		{name: "not a list", raw: `"` + first.String() + `"`, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ids *[]uuid.UUID
+			err := UUIDList(&ids, 1)(json.RawMessage(test.raw))
+			if (err != nil) != test.err {
+				t.Fatalf("UUIDList() error = %v, want error %v", err, test.err)
+			}
+			if err == nil && !slices.Equal(*ids, test.ids) {
+				t.Errorf("UUIDList() = %v, want %v", *ids, test.ids)
+			}
+		})
+	}
+}
+
+func TestBool(t *testing.T) {
+	tests := []struct {
+		raw   string
+		value bool
+		err   error
+	}{
+		{raw: `true`, value: true},
+		{raw: `false`},
+		{raw: `"true"`, value: true},
+		{raw: `"0"`},
+		{raw: `1`, err: errors.New("deve ser um booleano")},
+		{raw: `"sim"`, err: errors.New("deve ser um booleano")},
+	}
+	for _, test := range tests {
+		t.Run(test.raw, func(t *testing.T) {
+			var value *bool
+			err := Bool(&value)(json.RawMessage(test.raw))
+			if (err == nil) != (test.err == nil) {
+				t.Fatalf("Bool() error = %v, want %v", err, test.err)
+			}
+			if err == nil && *value != test.value {
+				t.Errorf("Bool() = %v, want %v", *value, test.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rest-api-example/config"
+	"rest-api-example/entities"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrPaymentDeclined is returned by a gateway that refused the operation.
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrGatewayTimeout is returned by a gateway that did not answer in time;
+	// the outcome of the operation is unknown until a webhook reports it.
+	ErrGatewayTimeout        = errors.New("payment gateway timeout")
+	ErrUnsupportedProvider   = errors.New("unsupported payment provider")
+	ErrUnsupportedFakeResult = errors.New("unsupported fake payment mode, use succeed, decline or timeout")
+)
+
+// PaymentGateway is implemented by each payment provider. Amounts carry the
+// currency the payment was authorized in.
+type PaymentGateway interface {
+	// Name identifies the provider in the payments it processed.
+	Name() string
+	// Authorize holds the amount and returns the reference of the
+	// authorization at the provider.
+	Authorize(ctx context.Context, request AuthorizationRequest) (string, error)
+	Capture(ctx context.Context, providerReference string, amount entities.Money) error
+	Refund(ctx context.Context, providerReference string, amount entities.Money) error
+	Void(ctx context.Context, providerReference string) error
+}
+
+type AuthorizationRequest struct {
+	PaymentId uuid.UUID
+	Reference string
+	Amount    entities.Money
+}
+
+// NewGateway returns the gateway of the provider chosen in the configuration.
+func NewGateway(cfg config.PaymentConfig) (PaymentGateway, error) {
+	switch cfg.Provider {
+	case config.PaymentProviderFake:
+		return NewFakeGateway(FakeMode(cfg.FakeMode))
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedProvider, cfg.Provider)
+	}
+}
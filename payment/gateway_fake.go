@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"context"
+	"rest-api-example/config"
+	"rest-api-example/entities"
+
+	"github.com/google/uuid"
+)
+
+// FakeMode is the outcome of every request sent to the FakeGateway.
+type FakeMode string
+
+const (
+	FakeSucceed FakeMode = "succeed"
+	FakeDecline FakeMode = "decline"
+	FakeTimeout FakeMode = "timeout"
+)
+
+// FakeGateway answers locally, so payment flows can be exercised offline. In
+// the timeout mode it blocks until the context of the request is done.
+type FakeGateway struct {
+	mode FakeMode
+}
+
+func NewFakeGateway(mode FakeMode) (PaymentGateway, error) {
+	switch mode {
+	case "":
+		mode = FakeSucceed
+	case FakeSucceed, FakeDecline, FakeTimeout:
+	default:
+		return nil, ErrUnsupportedFakeResult
+	}
+	return FakeGateway{
+		mode: mode,
+	}, nil
+}
+
+func (g FakeGateway) Name() string {
+	return config.PaymentProviderFake
+}
+
+func (g FakeGateway) Authorize(ctx context.Context, request AuthorizationRequest) (string, error) {
+	err := g.answer(ctx)
+	if err != nil {
+		return "", err
+	}
+	return "fake_" + uuid.NewString(), nil
+}
+
+func (g FakeGateway) Capture(ctx context.Context, providerReference string, amount entities.Money) error {
+	return g.answer(ctx)
+}
+
+func (g FakeGateway) Refund(ctx context.Context, providerReference string, amount entities.Money) error {
+	return g.answer(ctx)
+}
+
+func (g FakeGateway) Void(ctx context.Context, providerReference string) error {
+	return g.answer(ctx)
+}
+
+func (g FakeGateway) answer(ctx context.Context) error {
+	switch g.mode {
+	case FakeDecline:
+		return ErrPaymentDeclined
+	case FakeTimeout:
+		<-ctx.Done()
+		return ErrGatewayTimeout
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"rest-api-example/auth"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// maxWebhookBody bounds the body read before the signature is verified.
+const maxWebhookBody = 64 << 10
+
+var (
+	ErrFormatoJsonInvalido  = errors.New("verifique o formato do JSON e tente novamente")
+	ErrWebhookDesabilitado  = errors.New("webhook de pagamentos desabilitado, defina PAYMENT_WEBHOOK_SECRET")
+	ErrAssinaturaInvalida   = errors.New("assinatura do webhook inválida")
+	ErrCorpoWebhookInvalido = errors.New("não foi possível ler o corpo do webhook")
+)
+
+type PaymentHandler struct {
+	paymentService PaymentService
+	webhookSecret  []byte
+}
+
+// NewPaymentHandler answers every webhook with 501 Not Implemented when
+// webhookSecret is empty.
+func NewPaymentHandler(s PaymentService, webhookSecret string) PaymentHandler {
+	return PaymentHandler{
+		paymentService: s,
+		webhookSecret:  []byte(webhookSecret),
+	}
+}
+
+// createPaymentRequest takes the amount as a JSON number or a decimal string,
+// read exactly in the currency once it is known.
+type createPaymentRequest struct {
+	Amount    json.Number `json:"amount"`
+	Currency  string      `json:"currency"`
+	Reference string      `json:"reference"`
+}
+
+func (h PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
+	op := "PaymentHandler.CreatePayment()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.JSONError(w, r, entities.NewUnauthorizedError(auth.ErrInvalidToken, auth.ErrInvalidToken.Error(), op))
+		return
+	}
+	var request createPaymentRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrFormatoJsonInvalido.Error(), op))
+		return
+	}
+
+	payment, created, err := h.paymentService.CreatePayment(ctx, principal.Login, request.Amount.String(), request.Currency, request.Reference)
+	if !payment.IsEmpty() {
+		// declined and timed out intents are recorded too
+		w.Header().Set("Location", fmt.Sprintf(entities.PaymentGet, payment.Id.String()))
+	}
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	statusCode := http.StatusOK
+	if created {
+		statusCode = http.StatusCreated
+	}
+	utils.Respond(w, r, payment, paymentLinks(payment, principal), statusCode)
+}
+
+func (h PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	op := "PaymentHandler.GetPayment()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	principal, _ := auth.PrincipalFromContext(r.Context())
+
+	payment, err := h.paymentService.GetPayment(ctx, id, principal.Login, principal.Role)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, payment, paymentLinks(payment, principal), http.StatusOK)
+}
+
+func (h PaymentHandler) ApplyAction(w http.ResponseWriter, r *http.Request) {
+	op := "PaymentHandler.ApplyAction()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	principal, _ := auth.PrincipalFromContext(r.Context())
+
+	payment, err := h.paymentService.ApplyAction(ctx, id, vars["action"], principal.Login, principal.Role)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, payment, paymentLinks(payment, principal), http.StatusOK)
+}
+
+// Webhook receives the status changes reported by the provider, signed as
+// described in WebhookSignatureHeader.
+func (h PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	op := "PaymentHandler.Webhook()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	if len(h.webhookSecret) == 0 {
+		utils.JSONError(w, r, entities.NewNotImplementedError(ErrWebhookDesabilitado, ErrWebhookDesabilitado.Error(), op))
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrCorpoWebhookInvalido.Error(), op))
+		return
+	}
+	err = VerifyWebhookSignature(h.webhookSecret, r.Header.Get(WebhookSignatureHeader), body, time.Now())
+	if err != nil {
+		utils.JSONError(w, r, entities.NewUnauthorizedError(err, ErrAssinaturaInvalida.Error(), op))
+		return
+	}
+
+	var event WebhookEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrFormatoJsonInvalido.Error(), op))
+		return
+	}
+	_, err = h.paymentService.HandleWebhookEvent(ctx, event)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// paymentLinks only links the actions the principal may apply to the payment
+// in its current status.
+func paymentLinks(payment entities.Payment, principal auth.Principal) entities.Hateoas {
+	id := payment.Id.String()
+	builder := entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf(entities.PaymentGet, id))
+	if principal.Role.HasPermission(entities.PermissionPaymentManage) {
+		for _, action := range []string{ActionCapture, ActionVoid, ActionRefund} {
+			if payment.Status.CanMoveTo(actionClaim[action]) {
+				builder.AddPost(action, fmt.Sprintf(entities.PaymentAction, id, action))
+			}
+		}
+	}
+	return builder.Build()
+}
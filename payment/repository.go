@@ -0,0 +1,108 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+var paymentColumns = []string{"id", "reference", "amount", "currency", "status", "provider", "provider_reference", "failure_reason", "created_by", "created_at", "updated_at"}
+
+type PaymentRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewPaymentRepositoryPostgres(db *sql.DB) entities.PaymentInterface {
+	return PaymentRepositoryPostgres{
+		db: db,
+	}
+}
+
+func (r PaymentRepositoryPostgres) CreatePayment(ctx context.Context, payment entities.Payment) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Insert("payments").
+		Columns(paymentColumns...).
+		Values(payment.Id, payment.Reference, payment.Amount, payment.Amount.Currency, payment.Status, payment.Provider,
+			nullString(payment.ProviderReference), nullString(payment.FailureReason), payment.CreatedBy, payment.CreatedAt, payment.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r PaymentRepositoryPostgres) GetPaymentById(ctx context.Context, id uuid.UUID) (entities.Payment, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return r.getPayment(ctx, psql.Select(paymentColumns...).
+		From("payments").
+		Where("id = ?", id))
+}
+
+func (r PaymentRepositoryPostgres) GetPaymentByReference(ctx context.Context, createdBy string, reference string) (entities.Payment, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	return r.getPayment(ctx, psql.Select(paymentColumns...).
+		From("payments").
+		Where("created_by = ? AND reference = ?", createdBy, reference))
+}
+
+func (r PaymentRepositoryPostgres) getPayment(ctx context.Context, builder sq.SelectBuilder) (entities.Payment, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	payment, err := scanPayment(r.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Payment{}, nil
+	}
+	return payment, err
+}
+
+func (r PaymentRepositoryPostgres) UpdatePayment(ctx context.Context, payment entities.Payment, from entities.PaymentStatus) (bool, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Update("payments").
+		Set("status", payment.Status).
+		Set("provider_reference", nullString(payment.ProviderReference)).
+		Set("failure_reason", nullString(payment.FailureReason)).
+		Set("updated_at", payment.UpdatedAt).
+		Where("id = ? AND status = ?", payment.Id, from).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// scanPayment reads the amount as text, to parse it once the currency that
+// follows it is known.
+func scanPayment(row utils.RowScanner) (entities.Payment, error) {
+	var payment entities.Payment
+	var amount, currency string
+	var providerReference, failureReason sql.NullString
+	err := row.Scan(&payment.Id, &payment.Reference, &amount, &currency, &payment.Status, &payment.Provider,
+		&providerReference, &failureReason, &payment.CreatedBy, &payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	payment.Amount, err = entities.ParseMoney(amount, currency)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	payment.ProviderReference = providerReference.String
+	payment.FailureReason = failureReason.String
+	return payment, nil
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
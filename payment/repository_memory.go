@@ -0,0 +1,59 @@
+package payment
+
+import (
+	"context"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+
+	"github.com/google/uuid"
+)
+
+type PaymentRepositoryMemory struct {
+	store *memory.Store
+}
+
+func NewPaymentRepositoryMemory(store *memory.Store) entities.PaymentInterface {
+	return PaymentRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r PaymentRepositoryMemory) CreatePayment(ctx context.Context, payment entities.Payment) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+	r.store.Payments[payment.Id] = payment
+	return nil
+}
+
+func (r PaymentRepositoryMemory) GetPaymentById(ctx context.Context, id uuid.UUID) (entities.Payment, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	return r.store.Payments[id], nil
+}
+
+func (r PaymentRepositoryMemory) GetPaymentByReference(ctx context.Context, createdBy string, reference string) (entities.Payment, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	for _, payment := range r.store.Payments {
+		if payment.CreatedBy == createdBy && payment.Reference == reference {
+			return payment, nil
+		}
+	}
+	return entities.Payment{}, nil
+}
+
+func (r PaymentRepositoryMemory) UpdatePayment(ctx context.Context, payment entities.Payment, from entities.PaymentStatus) (bool, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	current, exists := r.store.Payments[payment.Id]
+	if !exists || current.Status != from {
+		return false, nil
+	}
+	current.Status = payment.Status
+	current.ProviderReference = payment.ProviderReference
+	current.FailureReason = payment.FailureReason
+	current.UpdatedAt = payment.UpdatedAt
+	r.store.Payments[payment.Id] = current
+	return true, nil
+}
@@ -0,0 +1,94 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+
+	sq "github.com/Masterminds/squirrel"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/google/uuid"
+)
+
+type PaymentRepositorySqlServer struct {
+	db *sql.DB
+}
+
+func NewPaymentRepositorySqlServer(db *sql.DB) entities.PaymentInterface {
+	return PaymentRepositorySqlServer{
+		db: db,
+	}
+}
+
+func (r PaymentRepositorySqlServer) CreatePayment(ctx context.Context, payment entities.Payment) error {
+	query, args, err := sq.Insert("payments").
+		Columns(paymentColumns...).
+		Values(payment.Id.String(), payment.Reference, payment.Amount, payment.Amount.Currency, string(payment.Status), payment.Provider,
+			nullString(payment.ProviderReference), nullString(payment.FailureReason), payment.CreatedBy, payment.CreatedAt, payment.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r PaymentRepositorySqlServer) GetPaymentById(ctx context.Context, id uuid.UUID) (entities.Payment, error) {
+	return r.getPayment(ctx, sq.Select(paymentColumns...).
+		From("payments").
+		Where("id = ?", id.String()))
+}
+
+func (r PaymentRepositorySqlServer) GetPaymentByReference(ctx context.Context, createdBy string, reference string) (entities.Payment, error) {
+	return r.getPayment(ctx, sq.Select(paymentColumns...).
+		From("payments").
+		Where("created_by = ? AND reference = ?", createdBy, reference))
+}
+
+func (r PaymentRepositorySqlServer) getPayment(ctx context.Context, builder sq.SelectBuilder) (entities.Payment, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return entities.Payment{}, err
+	}
+
+	var payment entities.Payment
+	var id mssql.UniqueIdentifier
+	var amount, currency string
+	var providerReference, failureReason sql.NullString
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&id, &payment.Reference, &amount, &currency, &payment.Status,
+		&payment.Provider, &providerReference, &failureReason, &payment.CreatedBy, &payment.CreatedAt, &payment.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Payment{}, nil
+	}
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	payment.Amount, err = entities.ParseMoney(amount, currency)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	payment.Id = uuid.UUID(id)
+	payment.ProviderReference = providerReference.String
+	payment.FailureReason = failureReason.String
+	return payment, nil
+}
+
+func (r PaymentRepositorySqlServer) UpdatePayment(ctx context.Context, payment entities.Payment, from entities.PaymentStatus) (bool, error) {
+	query, args, err := sq.Update("payments").
+		Set("status", string(payment.Status)).
+		Set("provider_reference", nullString(payment.ProviderReference)).
+		Set("failure_reason", nullString(payment.FailureReason)).
+		Set("updated_at", payment.UpdatedAt).
+		Where("id = ? AND status = ?", payment.Id.String(), string(from)).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
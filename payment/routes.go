@@ -0,0 +1,36 @@
+package payment
+
+import (
+	"net/http"
+	"rest-api-example/auth"
+	"rest-api-example/entities"
+	"rest-api-example/middlewares"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/cors"
+)
+
+// SetupPaymentRoutes registers the webhook before the authenticated routes:
+// the provider proves who it is with the signature instead of a token.
+func SetupPaymentRoutes(mux *mux.Router, h PaymentHandler, authService auth.AuthService) {
+	mux.HandleFunc(entities.PaymentWebhook,
+		middlewares.ValidateSupportedMediaTypes([]string{"application/json"}, h.Webhook)).Methods(http.MethodPost)
+
+	r := mux.PathPrefix("/payments").Subrouter()
+	r.Use(cors.New(cors.Options{
+		AllowedOrigins: []string{"http://127.0.0.1:5500"},
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+	}).Handler)
+	r.Use(authService.AuthenticationMiddleware)
+	r.HandleFunc("",
+		middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
+			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.CreatePayment))).Methods(http.MethodOptions,
+		http.MethodPost)
+	r.HandleFunc("/{id}", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+		h.GetPayment)).Methods(http.MethodOptions, http.MethodGet)
+	r.HandleFunc("/{id}/{action:capture|void|refund}",
+		authService.RequirePermission(entities.PermissionPaymentManage,
+			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.ApplyAction))).Methods(http.MethodOptions,
+		http.MethodPost)
+}
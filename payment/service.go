@@ -0,0 +1,289 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"rest-api-example/entities"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// GatewayTimeout bounds every call to the gateway, leaving the handler
+	// time to answer before its own deadline.
+	GatewayTimeout = 3 * time.Second
+)
+
+const (
+	ActionCapture = "capture"
+	ActionVoid    = "void"
+	ActionRefund  = "refund"
+)
+
+var (
+	ErrPagamentoNaoEncontrado  = errors.New("pagamento não encontrado")
+	ErrValorInvalido           = errors.New("o valor deve ser maior que zero e ter no máximo as casas decimais da moeda")
+	ErrMoedaInvalida           = errors.New("a moeda deve ser um código ISO 4217 suportado")
+	ErrReferenciaInvalida      = errors.New("a referência deve ser informada e ter até 255 caracteres")
+	ErrReferenciaEmUso         = errors.New("a referência já foi usada em um pagamento com outro valor")
+	ErrPagamentoRecusado       = errors.New("pagamento recusado pela operadora")
+	ErrOperadoraSemResposta    = errors.New("a operadora não respondeu a tempo, o resultado será informado por webhook")
+	ErrAcaoInvalida            = errors.New("a ação não é permitida no status atual do pagamento")
+	ErrStatusAlterado          = errors.New("o status do pagamento foi alterado por outra requisição")
+	ErrEventoInvalido          = errors.New("o tipo do evento deve ser payment.<status> com um status final")
+	ErrEventoForaDeOrdem       = errors.New("o evento não se aplica ao status atual do pagamento")
+	ErrPagamentoOutraOperadora = errors.New("pagamento processado por outra operadora")
+)
+
+// actionStatus is the status each action leads the payment to.
+var actionStatus = map[string]entities.PaymentStatus{
+	ActionCapture: entities.PaymentCaptured,
+	ActionVoid:    entities.PaymentVoided,
+	ActionRefund:  entities.PaymentRefunded,
+}
+
+// actionClaim is the status holding the payment while the gateway processes
+// each action.
+var actionClaim = map[string]entities.PaymentStatus{
+	ActionCapture: entities.PaymentCapturing,
+	ActionVoid:    entities.PaymentVoiding,
+	ActionRefund:  entities.PaymentRefunding,
+}
+
+// WebhookEvent is sent by the provider when the status of a payment changes
+// outside a request, Type being "payment." followed by the new status.
+type WebhookEvent struct {
+	Type              string    `json:"type"`
+	PaymentId         uuid.UUID `json:"payment_id"`
+	ProviderReference string    `json:"provider_reference"`
+	FailureReason     string    `json:"failure_reason"`
+}
+
+type PaymentService struct {
+	paymentRepository entities.PaymentInterface
+	gateway           PaymentGateway
+}
+
+func NewPaymentService(p entities.PaymentInterface, g PaymentGateway) PaymentService {
+	return PaymentService{
+		paymentRepository: p,
+		gateway:           g,
+	}
+}
+
+// CreatePayment records the intent and authorizes it at the gateway. Posting
+// the same reference again returns the payment already recorded, with false,
+// instead of charging twice. The payment is returned along with declines and
+// timeouts, which keep it recorded as declined and pending. The amount is the
+// decimal text sent by the client, read in currency.
+func (s PaymentService) CreatePayment(ctx context.Context, login string, amount string, currency string, reference string) (entities.Payment, bool, error) {
+	op := "PaymentService.CreatePayment()"
+	if currency == "" {
+		currency = entities.DefaultCurrency
+	}
+	var fields []entities.FieldError
+	var value entities.Money
+	if !entities.IsCurrency(currency) {
+		fields = append(fields, entities.FieldError{Field: "currency", Message: ErrMoedaInvalida.Error()})
+	} else if parsed, err := entities.ParseMoney(amount, currency); err != nil || parsed.Amount <= 0 {
+		fields = append(fields, entities.FieldError{Field: "amount", Message: ErrValorInvalido.Error()})
+	} else {
+		value = parsed
+	}
+	if strings.TrimSpace(reference) == "" || len(reference) > 255 {
+		fields = append(fields, entities.FieldError{Field: "reference", Message: ErrReferenciaInvalida.Error()})
+	}
+	if len(fields) > 0 {
+		return entities.Payment{}, false, entities.NewUnprocessableEntityError(errors.New(fields[0].Message), fields[0].Message, op, fields...)
+	}
+
+	existing, err := s.paymentRepository.GetPaymentByReference(ctx, login, reference)
+	if err != nil {
+		return entities.Payment{}, false, entities.NewInternalServerErrorError(err, op)
+	}
+	if !existing.IsEmpty() {
+		if existing.Amount != value {
+			return entities.Payment{}, false, entities.NewConflictError(ErrReferenciaEmUso, ErrReferenciaEmUso.Error(), op)
+		}
+		return existing, false, nil
+	}
+
+	now := time.Now().UTC()
+	payment := entities.Payment{
+		Id:        uuid.New(),
+		Reference: reference,
+		Amount:    value,
+		Status:    entities.PaymentPending,
+		Provider:  s.gateway.Name(),
+		CreatedBy: login,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = s.paymentRepository.CreatePayment(ctx, payment)
+	if err != nil {
+		return entities.Payment{}, false, entities.NewInternalServerErrorError(err, op)
+	}
+
+	gatewayCtx, cancel := context.WithTimeout(ctx, GatewayTimeout)
+	defer cancel()
+	providerReference, gatewayErr := s.gateway.Authorize(gatewayCtx, AuthorizationRequest{
+		PaymentId: payment.Id,
+		Reference: reference,
+		Amount:    value,
+	})
+	if isTimeout(gatewayErr) {
+		return payment, true, entities.NewGatewayTimeoutError(gatewayErr, ErrOperadoraSemResposta.Error(), op)
+	}
+
+	updated := payment
+	updated.UpdatedAt = time.Now().UTC()
+	switch {
+	case gatewayErr == nil:
+		updated.Status = entities.PaymentAuthorized
+		updated.ProviderReference = providerReference
+	case errors.Is(gatewayErr, ErrPaymentDeclined):
+		updated.Status = entities.PaymentDeclined
+		updated.FailureReason = gatewayErr.Error()
+	default:
+		updated.Status = entities.PaymentFailed
+		updated.FailureReason = gatewayErr.Error()
+	}
+	_, err = s.paymentRepository.UpdatePayment(ctx, updated, payment.Status)
+	if err != nil {
+		return payment, true, entities.NewInternalServerErrorError(err, op)
+	}
+	if gatewayErr != nil {
+		return updated, true, s.gatewayError(gatewayErr, op)
+	}
+	return updated, true, nil
+}
+
+// GetPayment returns the payment when it was created by login or role may
+// manage payments; other payments are reported as not found.
+func (s PaymentService) GetPayment(ctx context.Context, id uuid.UUID, login string, role entities.Role) (entities.Payment, error) {
+	op := "PaymentService.GetPayment()"
+	payment, err := s.paymentRepository.GetPaymentById(ctx, id)
+	if err != nil {
+		return entities.Payment{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if payment.IsEmpty() || (payment.CreatedBy != login && !role.HasPermission(entities.PermissionPaymentManage)) {
+		return entities.Payment{}, entities.NewNotFoundError(ErrPagamentoNaoEncontrado, ErrPagamentoNaoEncontrado.Error(), op)
+	}
+	return payment, nil
+}
+
+// ApplyAction captures, voids or refunds the whole amount of the payment. The
+// payment is claimed before the gateway is called, so concurrent actions get
+// a conflict instead of reaching the gateway too. A refused action moves the
+// payment back, while a timeout leaves it claimed until a webhook reports the
+// outcome.
+func (s PaymentService) ApplyAction(ctx context.Context, id uuid.UUID, action string, login string, role entities.Role) (entities.Payment, error) {
+	op := "PaymentService.ApplyAction()"
+	payment, err := s.GetPayment(ctx, id, login, role)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	claim, exists := actionClaim[action]
+	if !exists || !payment.Status.CanMoveTo(claim) {
+		return entities.Payment{}, entities.NewConflictError(ErrAcaoInvalida, ErrAcaoInvalida.Error(), op)
+	}
+	claimed, err := s.moveTo(ctx, payment, claim, payment.ProviderReference, payment.FailureReason, ErrStatusAlterado, op)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+
+	gatewayCtx, cancel := context.WithTimeout(ctx, GatewayTimeout)
+	defer cancel()
+	switch action {
+	case ActionCapture:
+		err = s.gateway.Capture(gatewayCtx, payment.ProviderReference, payment.Amount)
+	case ActionVoid:
+		err = s.gateway.Void(gatewayCtx, payment.ProviderReference)
+	case ActionRefund:
+		err = s.gateway.Refund(gatewayCtx, payment.ProviderReference, payment.Amount)
+	}
+	// the answer of the gateway is stored even when the request was canceled
+	// meanwhile
+	storeCtx := context.WithoutCancel(ctx)
+	if err != nil {
+		if !isTimeout(err) {
+			restored := payment
+			restored.UpdatedAt = time.Now().UTC()
+			_, restoreErr := s.paymentRepository.UpdatePayment(storeCtx, restored, claim)
+			if restoreErr != nil {
+				return entities.Payment{}, entities.NewInternalServerErrorError(restoreErr, op)
+			}
+		}
+		return entities.Payment{}, s.gatewayError(err, op)
+	}
+
+	return s.moveTo(storeCtx, claimed, actionStatus[action], payment.ProviderReference, "", ErrStatusAlterado, op)
+}
+
+// HandleWebhookEvent applies the status reported by the provider. Events
+// repeating the current status are accepted without changes, so redeliveries
+// are harmless.
+func (s PaymentService) HandleWebhookEvent(ctx context.Context, event WebhookEvent) (entities.Payment, error) {
+	op := "PaymentService.HandleWebhookEvent()"
+	status, found := strings.CutPrefix(event.Type, "payment.")
+	next := entities.PaymentStatus(status)
+	if !found || !next.IsValid() || next.IsTransient() {
+		return entities.Payment{}, entities.NewBadRequestError(ErrEventoInvalido, ErrEventoInvalido.Error(), op,
+			entities.FieldError{Field: "type", Message: ErrEventoInvalido.Error()})
+	}
+
+	payment, err := s.paymentRepository.GetPaymentById(ctx, event.PaymentId)
+	if err != nil {
+		return entities.Payment{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if payment.IsEmpty() {
+		return entities.Payment{}, entities.NewNotFoundError(ErrPagamentoNaoEncontrado, ErrPagamentoNaoEncontrado.Error(), op)
+	}
+	if payment.Provider != s.gateway.Name() {
+		return entities.Payment{}, entities.NewConflictError(ErrPagamentoOutraOperadora, ErrPagamentoOutraOperadora.Error(), op)
+	}
+	if payment.Status == next {
+		return payment, nil
+	}
+	if !payment.Status.CanMoveTo(next) {
+		return entities.Payment{}, entities.NewConflictError(ErrEventoForaDeOrdem, ErrEventoForaDeOrdem.Error(), op)
+	}
+
+	providerReference := payment.ProviderReference
+	if event.ProviderReference != "" {
+		providerReference = event.ProviderReference
+	}
+	return s.moveTo(ctx, payment, next, providerReference, event.FailureReason, ErrEventoForaDeOrdem, op)
+}
+
+func (s PaymentService) moveTo(ctx context.Context, payment entities.Payment, next entities.PaymentStatus, providerReference string, failureReason string, errChanged error, op string) (entities.Payment, error) {
+	updated := payment
+	updated.Status = next
+	updated.ProviderReference = providerReference
+	updated.FailureReason = failureReason
+	updated.UpdatedAt = time.Now().UTC()
+	changed, err := s.paymentRepository.UpdatePayment(ctx, updated, payment.Status)
+	if err != nil {
+		return entities.Payment{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if !changed {
+		return entities.Payment{}, entities.NewConflictError(errChanged, errChanged.Error(), op)
+	}
+	return updated, nil
+}
+
+func (s PaymentService) gatewayError(err error, op string) error {
+	switch {
+	case errors.Is(err, ErrPaymentDeclined):
+		return entities.NewPaymentRequiredError(err, ErrPagamentoRecusado.Error(), op)
+	case isTimeout(err):
+		return entities.NewGatewayTimeoutError(err, ErrOperadoraSemResposta.Error(), op)
+	}
+	return entities.NewInternalServerErrorError(err, op)
+}
+
+func isTimeout(err error) bool {
+	return errors.Is(err, ErrGatewayTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
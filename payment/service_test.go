@@ -0,0 +1,122 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+	"testing"
+)
+
+// stubGateway authorizes as the fake gateway and answers Capture with the
+// function of the test.
+type stubGateway struct {
+	PaymentGateway
+	capture func(ctx context.Context) error
+}
+
+func (g stubGateway) Capture(ctx context.Context, providerReference string, amount entities.Money) error {
+	return g.capture(ctx)
+}
+
+func newTestPayment(t *testing.T, capture func(ctx context.Context) error) (PaymentService, entities.Payment) {
+	t.Helper()
+	fake, err := NewFakeGateway(FakeSucceed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewPaymentService(NewPaymentRepositoryMemory(memory.NewStore()), stubGateway{PaymentGateway: fake, capture: capture})
+	payment, _, err := service.CreatePayment(context.Background(), "ana", "10.50", "BRL", "pedido-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service, payment
+}
+
+func TestApplyAction(t *testing.T) {
+	tests := []struct {
+		name    string
+		capture func(ctx context.Context) error
+		// code is empty for the accepted captures
+		code   string
+		status entities.PaymentStatus
+	}{
+		{name: "accepted", capture: func(ctx context.Context) error { return nil }, status: entities.PaymentCaptured},
+		{name: "declined moves back", capture: func(ctx context.Context) error { return ErrPaymentDeclined }, code: entities.PAYMENT_REQUIRED, status: entities.PaymentAuthorized},
+		{name: "failed moves back", capture: func(ctx context.Context) error { return errors.New("boom") }, code: entities.INTERNAL_SERVER_ERROR, status: entities.PaymentAuthorized},
+		{name: "timeout stays claimed", capture: func(ctx context.Context) error { return ErrGatewayTimeout }, code: entities.GATEWAY_TIMEOUT, status: entities.PaymentCapturing},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, payment := newTestPayment(t, test.capture)
+
+			_, err := service.ApplyAction(ctx, payment.Id, ActionCapture, "ana", entities.RoleAdmin)
+			checkErrorCode(t, err, test.code)
+			stored, err := service.GetPayment(ctx, payment.Id, "ana", entities.RoleAdmin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != test.status {
+				t.Errorf("status = %q, want %q", stored.Status, test.status)
+			}
+		})
+	}
+}
+
+func TestApplyActionClaimsThePayment(t *testing.T) {
+	ctx := context.Background()
+	var service PaymentService
+	var payment entities.Payment
+	var concurrent error
+	calls := 0
+	service, payment = newTestPayment(t, func(gatewayCtx context.Context) error {
+		calls++
+		// another request arrives while the gateway processes the capture
+		_, concurrent = service.ApplyAction(ctx, payment.Id, ActionVoid, "ana", entities.RoleAdmin)
+		return nil
+	})
+
+	captured, err := service.ApplyAction(ctx, payment.Id, ActionCapture, "ana", entities.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if captured.Status != entities.PaymentCaptured {
+		t.Errorf("status = %q, want %q", captured.Status, entities.PaymentCaptured)
+	}
+	checkErrorCode(t, concurrent, entities.CONFLICT)
+	if calls != 1 {
+		t.Errorf("gateway called %d times, want 1", calls)
+	}
+}
+
+func TestWebhookSettlesAClaim(t *testing.T) {
+	ctx := context.Background()
+	service, payment := newTestPayment(t, func(ctx context.Context) error { return ErrGatewayTimeout })
+	_, err := service.ApplyAction(ctx, payment.Id, ActionCapture, "ana", entities.RoleAdmin)
+	checkErrorCode(t, err, entities.GATEWAY_TIMEOUT)
+
+	_, err = service.HandleWebhookEvent(ctx, WebhookEvent{Type: "payment.capturing", PaymentId: payment.Id})
+	checkErrorCode(t, err, entities.BAD_REQUEST)
+	settled, err := service.HandleWebhookEvent(ctx, WebhookEvent{Type: "payment.captured", PaymentId: payment.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settled.Status != entities.PaymentCaptured {
+		t.Errorf("status = %q, want %q", settled.Status, entities.PaymentCaptured)
+	}
+}
+
+func checkErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+	if code == "" {
+		if err != nil {
+			t.Errorf("error = %v, want nil", err)
+		}
+		return
+	}
+	var apiError *entities.Error
+	if !errors.As(err, &apiError) || apiError.Code != code {
+		t.Errorf("error = %v, want code %q", err, code)
+	}
+}
@@ -0,0 +1,70 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>",
+	// the HMAC being computed over "<unix seconds>.<body>".
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookTolerance is how old a signature may be, limiting replays.
+	WebhookTolerance = 5 * time.Minute
+)
+
+var (
+	ErrWebhookSignatureMissing = errors.New("webhook signature missing or malformed")
+	ErrWebhookSignatureInvalid = errors.New("webhook signature does not match")
+	ErrWebhookSignatureExpired = errors.New("webhook signature outside the tolerance")
+)
+
+// SignWebhook returns the value of the signature header for the body sent at
+// timestamp.
+func SignWebhook(secret []byte, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unix, webhookHmac(secret, unix, body))
+}
+
+// VerifyWebhookSignature checks the signature header of the body against the
+// secret, rejecting signatures made more than WebhookTolerance away from now.
+func VerifyWebhookSignature(secret []byte, header string, body []byte, now time.Time) error {
+	var unix, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			signature = value
+		}
+	}
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || signature == "" {
+		return ErrWebhookSignatureMissing
+	}
+
+	expected := webhookHmac(secret, unix, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrWebhookSignatureInvalid
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > WebhookTolerance || age < -WebhookTolerance {
+		return ErrWebhookSignatureExpired
+	}
+	return nil
+}
+
+func webhookHmac(secret []byte, unix string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,68 @@
+package product
+
+import (
+	"rest-api-example/entities"
+	"rest-api-example/listing"
+	"rest-api-example/utils"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+// productSchema lists the fields GET /products may be filtered and sorted by,
+// with the price compared in currency.
+func productSchema(currency string) listing.Schema {
+	price := listing.Field{Name: "price", Column: "price", Kind: listing.Decimal, Currency: currency, Operators: listing.RangeOperators, Sortable: true}
+	if currency != entities.DefaultCurrency {
+		price.Column = listPriceSql
+		price.Args = []any{currency}
+	}
+	return listing.Schema{
+		Fields: []listing.Field{
+			{Name: "name", Column: "name", Kind: listing.Text, Operators: listing.TextOperators, Sortable: true},
+			{Name: "description", Column: "description", Kind: listing.Text, Operators: []listing.Operator{listing.Contains}},
+			price,
+			{Name: "active", Column: "active", Kind: listing.Bool, Operators: []listing.Operator{listing.Eq}},
+			{Name: "created_at", Column: "created_at", Kind: listing.Time, Operators: listing.RangeOperators, Sortable: true},
+			{Name: "updated_at", Column: "updated_at", Kind: listing.Time, Operators: listing.RangeOperators, Sortable: true},
+			{Name: "category", Kind: listing.UUID, Operators: listing.EqualityOperators, Condition: categoryCondition},
+		},
+		Reserved:    []string{"page", "limit", listing.CursorParam, listing.CountParam, utils.FieldsParam, utils.EmbedParam, "currency", "in_stock"},
+		DefaultSort: []listing.Sort{{Field: "created_at"}},
+		IdColumn:    "id",
+	}
+}
+
+// categoryCondition keeps the products in, or not in, the category.
+func categoryCondition(operator listing.Operator, value any) sq.Sqlizer {
+	exists := "EXISTS (SELECT 1 FROM products_categories WHERE products_categories.product_id = products.id AND products_categories.category_id = ?)"
+	if operator == listing.Ne {
+		exists = "NOT " + exists
+	}
+	return sq.Expr(exists, value.(uuid.UUID).String())
+}
+
+// productValues gives the fields of productSchema for the memory repository.
+func productValues(product entities.Product, categoriesId []uuid.UUID) listing.Values {
+	return func(field string) any {
+		switch field {
+		case "id":
+			return product.Id
+		case "name":
+			return product.Name
+		case "description":
+			return product.Description
+		case "price":
+			return product.Price
+		case "active":
+			return product.Active
+		case "created_at":
+			return product.CreatedAt
+		case "updated_at":
+			return product.UpdatedAt
+		case "category":
+			return categoriesId
+		}
+		return nil
+	}
+}
@@ -7,29 +7,40 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
 	"rest-api-example/entities"
+	"rest-api-example/listing"
+	"rest-api-example/patch"
 	"rest-api-example/utils"
-	"strconv"
 	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
+// embedCategories is the relation ?embed= may ask for.
+const embedCategories = "categories"
+
 var (
 	ErrIdDosProdutosObrigatorio = errors.New("id dos produtos a serem excluídos devem ser informados")
+	ErrFiltroEstoqueInvalido    = errors.New("in_stock deve ser true ou false")
 )
 
 type ProductHandler struct {
 	productService ProductService
+	requireIfMatch bool
+	cursors        listing.CursorCodec
 }
 
-func NewProductHandler(s ProductService) ProductHandler {
+// NewProductHandler builds the handler; with requireIfMatch set, PATCH and
+// DELETE without If-Match are rejected with 428. Cursors signs the cursors of
+// the list.
+func NewProductHandler(s ProductService, requireIfMatch bool, cursors listing.CursorCodec) ProductHandler {
 	return ProductHandler{
 		productService: s,
+		requireIfMatch: requireIfMatch,
+		cursors:        cursors,
 	}
 }
 
@@ -43,47 +54,65 @@ func (h ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
 	limit := utils.GetQueryInt(queryParams, "limit", 10)
 
 	var filtersUrl string
-	if value, exists := queryParams["active"]; exists {
-		isActive, err := strconv.Atoi(value[0])
-		if err != nil {
-			utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
-		}
-		filtersUrl += fmt.Sprintf("&active=%d", isActive)
+	inStock, filterInStock, err := inStockFilter(queryParams)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrFiltroEstoqueInvalido.Error(), op,
+			entities.FieldError{Field: "in_stock", Message: ErrFiltroEstoqueInvalido.Error()}))
+		return
+	}
+	if filterInStock {
+		filtersUrl += fmt.Sprintf("&in_stock=%t", inStock)
+	}
+	currency, ok := currencyParam(queryParams)
+	if !ok {
+		utils.JSONError(w, r, entities.NewBadRequestError(ErrMoedaInvalida, ErrMoedaInvalida.Error(), op,
+			entities.FieldError{Field: "currency", Message: ErrMoedaInvalida.Error()}))
+		return
+	}
+	if queryParams.Has("currency") {
+		queryParams.Set("currency", currency)
+		filtersUrl += fmt.Sprintf("&currency=%s", currency)
+	}
+	list, err := productSchema(currency).Parse(queryParams)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	filtersUrl += list.Encode()
+	representation, err := utils.ParseRepresentation(queryParams, entities.ProductResource{}, embedCategories)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	if queryParams.Has(listing.CursorParam) {
+		h.getProductsByCursor(ctx, w, r, queryParams, list, filtersUrl, representation)
+		return
 	}
 
 	products, totalCount, err := h.productService.GetAllProducts(ctx, queryParams)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
-
-	resources := make([]entities.ProductResource, len(products))
-	for index, product := range products {
-		links := entities.NewHateoasBuilder().
-			AddGet("self", fmt.Sprintf(entities.ProductGet, product.Id.String())).
-			AddDelete("delete", fmt.Sprintf(entities.ProductDelete, product.Id.String())).
-			AddPatch("update", fmt.Sprintf(entities.ProductUpdate, product.Id.String())).
-			Build()
-
-		resource := entities.ProductResource{
-			Product: product,
-			Links:   links,
-		}
-		resources[index] = resource
+	resources, err := h.representProducts(ctx, products, representation, productResource)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
 	}
 
 	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
 
+	linksUrl := filtersUrl + representation.Encode()
 	paginationLinksBuilder := entities.NewHateoasBuilder().
-		AddGet("self", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, page, limit, filtersUrl))
+		AddGet("self", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.ProductList, page, limit, linksUrl))
 	if page < totalPages {
-		paginationLinksBuilder.AddGet("last", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, totalPages, limit, filtersUrl))
+		paginationLinksBuilder.AddGet("last", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.ProductList, totalPages, limit, linksUrl))
 	}
 	if page+1 <= totalPages {
-		paginationLinksBuilder.AddGet("next", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, page+1, limit, filtersUrl))
+		paginationLinksBuilder.AddGet("next", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.ProductList, page+1, limit, linksUrl))
 	}
 	if page-1 > 0 {
-		paginationLinksBuilder.AddGet("prev", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.CategoryList, page-1, limit, filtersUrl))
+		paginationLinksBuilder.AddGet("prev", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.ProductList, page-1, limit, linksUrl))
 	}
 	links := paginationLinksBuilder.Build()
 
@@ -95,36 +124,156 @@ func (h ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
 		Hateoas:    links,
 	}
 
-	response := utils.Response{
-		Data: resources,
-		Meta: meta,
-	}
+	utils.Respond(w, r, resources, meta, http.StatusOK)
+}
 
-	//generate response with eTag
-	payload, err := json.Marshal(response)
+// getProductsByCursor answers GET /products?cursor=, the list paged by the
+// signed cursors of the next and prev links instead of page numbers.
+func (h ProductHandler) getProductsByCursor(ctx context.Context, w http.ResponseWriter, r *http.Request, queryParams url.Values, list listing.Query, filtersUrl string, representation utils.Representation) {
+	cursor, err := h.cursors.Parse(queryParams, filtersUrl)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	products, totalCount, err := h.productService.GetProductsByCursor(ctx, queryParams, cursor)
 	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
+		utils.JSONError(w, r, err)
 		return
 	}
+	products, next, prev := listing.Paginate(h.cursors, list, products, cursor, filtersUrl, func(product entities.Product) listing.Values {
+		return productValues(product, nil)
+	})
+	resources, err := h.representProducts(ctx, products, representation, productResource)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+
+	meta := utils.CursorMeta{
+		Limit:   cursor.Limit,
+		Results: len(products),
+		Hateoas: utils.CursorLinks(entities.NewHateoasBuilder(), entities.ProductList, queryParams.Get(listing.CursorParam), next, prev, cursor.Limit,
+			filtersUrl+representation.Encode()),
+	}
+	if totalCount >= 0 {
+		meta.TotalCount = &totalCount
+	}
+	utils.Respond(w, r, resources, meta, http.StatusOK)
+}
+
+// representProducts trims the resources of the products to the fields asked
+// for and embeds their categories, loaded for all the products at once.
+func (h ProductHandler) representProducts(ctx context.Context, products []entities.Product, representation utils.Representation, resource func(entities.Product) any) ([]any, error) {
+	op := "ProductHandler.representProducts()"
+	var categories map[uuid.UUID][]entities.Category
+	if representation.Embed[embedCategories] {
+		var err error
+		categories, err = h.productService.GetProductsCategories(ctx, products)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resources := make([]any, len(products))
+	for index, product := range products {
+		var embedded map[string]any
+		if categories != nil {
+			embedded = map[string]any{embedCategories: embeddedCategories(categories[product.Id])}
+		}
+		shaped, err := representation.Shape(resource(product), embedded)
+		if err != nil {
+			return nil, entities.NewInternalServerErrorError(err, op)
+		}
+		resources[index] = shaped
+	}
+	return resources, nil
+}
+
+func productResource(product entities.Product) any {
+	links := entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf(entities.ProductGet, product.Id.String())).
+		AddDelete("delete", fmt.Sprintf(entities.ProductDelete, product.Id.String())).
+		AddPatch("update", fmt.Sprintf(entities.ProductUpdate, product.Id.String())).
+		Build()
+
+	return entities.ProductResource{
+		Product: product,
+		Links:   links,
+	}
+}
+
+// embeddedCategories links each category to itself, the full set of links
+// being in GET /categories/{id}.
+func embeddedCategories(categories []entities.Category) []entities.CategoryResource {
+	resources := make([]entities.CategoryResource, len(categories))
+	for index, category := range categories {
+		resources[index] = entities.CategoryResource{
+			Category: category,
+			Links:    entities.NewHateoasBuilder().AddGet("self", fmt.Sprintf(entities.CategoryGet, category.Id.String())).Build(),
+		}
+	}
+	return resources
+}
 
-	eTag := utils.GenerateETag(payload)
+// SearchProducts answers GET /products/search?q= with the products ranked by
+// relevance, paginated like GetAllProducts.
+func (h ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	op := "ProductHandler.SearchProducts()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
 
-	ifNoneMatch := strings.TrimPrefix(strings.Trim(r.Header.Get("If-None-Match"), "\""), "W/")
-	actualEtag := strings.TrimPrefix(eTag, "W/")
-	if ifNoneMatch == strings.Trim(actualEtag, "\"") {
-		w.Header().Set("ETag", eTag)
-		w.WriteHeader(http.StatusNotModified)
+	queryParams := r.URL.Query()
+	query := queryParams.Get("q")
+	page := max(utils.GetQueryInt(queryParams, "page", 1), 1)
+	limit := min(max(utils.GetQueryInt(queryParams, "limit", 10), 1), maxSearchLimit)
+	currency, ok := currencyParam(queryParams)
+	if !ok {
+		utils.JSONError(w, r, entities.NewBadRequestError(ErrMoedaInvalida, ErrMoedaInvalida.Error(), op,
+			entities.FieldError{Field: "currency", Message: ErrMoedaInvalida.Error()}))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("ETag", eTag)
-	w.WriteHeader(http.StatusOK)
-	_, err = fmt.Fprint(w, string(payload))
+	results, totalCount, err := h.productService.SearchProducts(ctx, query, currency, page, limit)
 	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
+		utils.JSONError(w, r, err)
 		return
 	}
+
+	resources := make([]entities.ProductSearchResource, len(results))
+	for index, result := range results {
+		resources[index] = entities.ProductSearchResource{
+			ProductSearchResult: result,
+			Links: entities.NewHateoasBuilder().
+				AddGet("self", fmt.Sprintf(entities.ProductGet, result.Id.String())).
+				Build(),
+		}
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+	filtersUrl := "&q=" + url.QueryEscape(query)
+	if queryParams.Has("currency") {
+		filtersUrl += "&currency=" + currency
+	}
+	paginationLinksBuilder := entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.ProductSearch, page, limit, filtersUrl))
+	if page < totalPages {
+		paginationLinksBuilder.AddGet("last", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.ProductSearch, totalPages, limit, filtersUrl))
+	}
+	if page+1 <= totalPages {
+		paginationLinksBuilder.AddGet("next", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.ProductSearch, page+1, limit, filtersUrl))
+	}
+	if page-1 > 0 {
+		paginationLinksBuilder.AddGet("prev", fmt.Sprintf("%s?page=%d&limit=%d%s", entities.ProductSearch, page-1, limit, filtersUrl))
+	}
+
+	meta := utils.PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		Results:    len(results),
+		Hateoas:    paginationLinksBuilder.Build(),
+	}
+	utils.Respond(w, r, resources, meta, http.StatusOK)
 }
 
 func (h ProductHandler) GetProductById(w http.ResponseWriter, r *http.Request) {
@@ -136,52 +285,51 @@ func (h ProductHandler) GetProductById(w http.ResponseWriter, r *http.Request) {
 	idString := vars["id"]
 	id, err := uuid.Parse(idString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 		return
 	}
 
-	product, err := h.productService.GetProductById(ctx, id)
-	if err != nil {
-		utils.JSONError(w, err)
+	currency, ok := currencyParam(r.URL.Query())
+	if !ok {
+		utils.JSONError(w, r, entities.NewBadRequestError(ErrMoedaInvalida, ErrMoedaInvalida.Error(), op,
+			entities.FieldError{Field: "currency", Message: ErrMoedaInvalida.Error()}))
 		return
 	}
 
-	links := entities.NewHateoasBuilder().
-		AddGet("self", fmt.Sprintf(entities.ProductGet, product.Id.String())).
-		AddDelete("delete", fmt.Sprintf(entities.ProductDelete, product.Id.String())).
-		AddPatch("update", fmt.Sprintf(entities.ProductUpdate, product.Id.String())).
-		Build()
-
-	response := utils.Response{
-		Data: product,
-		Meta: links,
-	}
-
-	//generate response with eTag
-	payload, err := json.Marshal(response)
+	representation, err := utils.ParseRepresentation(r.URL.Query(), entities.Product{}, embedCategories)
 	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
+		utils.JSONError(w, r, err)
 		return
 	}
 
-	eTag := utils.GenerateETag(payload)
-
-	ifNoneMatch := strings.TrimPrefix(strings.Trim(r.Header.Get("If-None-Match"), "\""), "W/")
-	actualEtag := strings.TrimPrefix(eTag, "W/")
-	if ifNoneMatch == strings.Trim(actualEtag, "\"") {
-		w.Header().Set("ETag", eTag)
-		w.WriteHeader(http.StatusNotModified)
+	product, err := h.productService.GetProductInCurrency(ctx, id, currency)
+	if err != nil {
+		utils.JSONError(w, r, err)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("ETag", eTag)
-	w.WriteHeader(http.StatusOK)
-	_, err = fmt.Fprint(w, string(payload))
+	data, err := h.representProducts(ctx, []entities.Product{product}, representation, func(product entities.Product) any {
+		return product
+	})
 	if err != nil {
-		utils.JSONError(w, entities.NewInternalServerErrorError(err, op))
+		utils.JSONError(w, r, err)
 		return
 	}
+
+	links := entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf(entities.ProductGet, product.Id.String())).
+		AddGet("prices", fmt.Sprintf(entities.ProductPrices, product.Id.String())).
+		AddDelete("delete", fmt.Sprintf(entities.ProductDelete, product.Id.String())).
+		AddPatch("update", fmt.Sprintf(entities.ProductUpdate, product.Id.String())).
+		Build()
+
+	// the version ETag is the one PATCH and DELETE accept in If-Match; other
+	// representations, whose embedded resources change on their own, get
+	// the digest of their content from the ConditionalGet middleware
+	if representation.IsEmpty() {
+		w.Header().Set("ETag", utils.VersionETag(product.Version))
+		utils.SetLastModified(w, product.CreatedAt, product.UpdatedAt)
+	}
+	utils.Respond(w, r, data[0], links, http.StatusOK)
 }
 
 func (h ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
@@ -190,16 +338,15 @@ func (h ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var product entities.Product
-	log.Println(r.Body)
 	err := json.NewDecoder(r.Body).Decode(&product)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
 		return
 	}
 
 	product, err = h.productService.CreateProduct(ctx, product)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 
@@ -209,7 +356,7 @@ func (h ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		AddPatch("update", fmt.Sprintf(entities.ProductUpdate, product.Id.String())).
 		Build()
 
-	utils.JSONResponse(w, product, links, http.StatusCreated)
+	utils.Respond(w, r, product, links, http.StatusCreated)
 }
 
 func (h ProductHandler) DeleteProducts(w http.ResponseWriter, r *http.Request) {
@@ -220,7 +367,7 @@ func (h ProductHandler) DeleteProducts(w http.ResponseWriter, r *http.Request) {
 	var idsString []string
 	err := json.NewDecoder(r.Body).Decode(&idsString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
 		return
 	}
 
@@ -228,20 +375,20 @@ func (h ProductHandler) DeleteProducts(w http.ResponseWriter, r *http.Request) {
 	for _, idString := range idsString {
 		id, err := uuid.Parse(idString)
 		if err != nil {
-			utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+			utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 			return
 		}
 		ids = append(ids, id)
 	}
 
 	if len(ids) == 0 {
-		utils.JSONError(w, entities.NewBadRequestError(ErrIdDosProdutosObrigatorio, ErrIdDosProdutosObrigatorio.Error(), op))
+		utils.JSONError(w, r, entities.NewBadRequestError(ErrIdDosProdutosObrigatorio, ErrIdDosProdutosObrigatorio.Error(), op))
 		return
 	}
 
 	err = h.productService.DeleteProducts(ctx, ids)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -256,21 +403,47 @@ func (h ProductHandler) UpdateProductsFields(w http.ResponseWriter, r *http.Requ
 	idString := vars["id"]
 	id, err := uuid.Parse(idString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 		return
 	}
 
-	var jsonBody map[string]any
-	err = json.NewDecoder(r.Body).Decode(&jsonBody)
+	versions, err := utils.IfMatchVersions(r, h.requireIfMatch)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+		utils.JSONError(w, r, err)
 		return
 	}
 
-	product, err := h.productService.UpdateProductFields(ctx, id, jsonBody)
-	if err != nil {
-		utils.JSONError(w, err)
-		return
+	var product entities.Product
+	// application/json-patch+json also starts with application/json
+	if strings.HasPrefix(r.Header.Get("Content-Type"), patch.MediaTypeJSONPatch) {
+		operations, err := patch.ReadJSONPatch(r.Body)
+		if err != nil {
+			utils.JSONError(w, r, entities.NewBadRequestError(err, err.Error(), op))
+			return
+		}
+		product, err = h.productService.PatchProductFields(ctx, id, operations, versions)
+		if err != nil {
+			utils.JSONError(w, r, err)
+			return
+		}
+	} else {
+		document, err := patch.ReadMergePatch(r.Body)
+		if err != nil {
+			utils.JSONError(w, r, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+			return
+		}
+
+		update, err := ParseProductMergePatch(document)
+		if err != nil {
+			utils.JSONError(w, r, err)
+			return
+		}
+
+		product, err = h.productService.UpdateProductFields(ctx, id, update, versions)
+		if err != nil {
+			utils.JSONError(w, r, err)
+			return
+		}
 	}
 
 	links := entities.NewHateoasBuilder().
@@ -279,7 +452,8 @@ func (h ProductHandler) UpdateProductsFields(w http.ResponseWriter, r *http.Requ
 		AddPatch("update", fmt.Sprintf(entities.ProductUpdate, product.Id.String())).
 		Build()
 
-	utils.JSONResponse(w, product, links, http.StatusOK)
+	w.Header().Set("ETag", utils.VersionETag(product.Version))
+	utils.Respond(w, r, product, links, http.StatusOK)
 }
 
 func (h ProductHandler) DeleteProductById(w http.ResponseWriter, r *http.Request) {
@@ -291,14 +465,108 @@ func (h ProductHandler) DeleteProductById(w http.ResponseWriter, r *http.Request
 	idString := vars["id"]
 	id, err := uuid.Parse(idString)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, "UUID inválido", op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
 		return
 	}
 
-	err = h.productService.DeleteProductById(ctx, id)
+	versions, err := utils.IfMatchVersions(r, h.requireIfMatch)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
+		return
+	}
+
+	err = h.productService.DeleteProductById(ctx, id, versions)
+	if err != nil {
+		utils.JSONError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h ProductHandler) GetProductPrices(w http.ResponseWriter, r *http.Request) {
+	op := "ProductHandler.GetProductPrices()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+
+	prices, err := h.productService.GetProductPrices(ctx, id)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, prices, priceListLinks(id), http.StatusOK)
+}
+
+type setProductPriceRequest struct {
+	Amount json.Number `json:"amount"`
+}
+
+// SetProductPrice creates or replaces the price of the product in the
+// currency of the path.
+func (h ProductHandler) SetProductPrice(w http.ResponseWriter, r *http.Request) {
+	op := "ProductHandler.SetProductPrice()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+	var request setProductPriceRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "Verifique o formato do JSON e tente novamente", op))
+		return
+	}
+
+	price, err := h.productService.SetProductPrice(ctx, id, strings.ToUpper(vars["currency"]), request.Amount.String())
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	utils.Respond(w, r, price, priceListLinks(id), http.StatusOK)
+}
+
+func (h ProductHandler) DeleteProductPrice(w http.ResponseWriter, r *http.Request) {
+	op := "ProductHandler.DeleteProductPrice()"
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, "UUID inválido", op))
+		return
+	}
+
+	err = h.productService.DeleteProductPrice(ctx, id, strings.ToUpper(vars["currency"]))
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// currencyParam reads the "currency" query parameter, DefaultCurrency when it
+// is absent. The second return is false for unsupported currencies.
+func currencyParam(queryParams url.Values) (string, bool) {
+	if !queryParams.Has("currency") {
+		return entities.DefaultCurrency, true
+	}
+	currency := strings.ToUpper(queryParams.Get("currency"))
+	return currency, entities.IsCurrency(currency)
+}
+
+func priceListLinks(id uuid.UUID) entities.Hateoas {
+	return entities.NewHateoasBuilder().
+		AddGet("self", fmt.Sprintf(entities.ProductPrices, id.String())).
+		AddGet("product", fmt.Sprintf(entities.ProductGet, id.String())).
+		Build()
+}
@@ -0,0 +1,98 @@
+package product
+
+import (
+	"net/http"
+	"rest-api-example/entities"
+	"rest-api-example/openapi"
+	"rest-api-example/patch"
+	"rest-api-example/utils"
+)
+
+// Operations documents the routes of SetupProductsRoutes.
+func Operations() []openapi.Operation {
+	const tag = "Produtos"
+	currency := openapi.Parameter{Name: "currency", Value: "", Description: "moeda dos preços, ISO 4217; o padrão é " + entities.DefaultCurrency}
+	productMeta := []any{entities.Hateoas{}}
+
+	listParameters := openapi.ListParameters(productSchema(entities.DefaultCurrency))
+	listParameters = append(listParameters, openapi.RepresentationParameters(embedCategories)...)
+	listParameters = append(listParameters, currency,
+		openapi.Parameter{Name: "in_stock", Value: false, Description: "true mantém só os produtos com estoque disponível, false só os sem estoque"})
+
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/products", Tag: tag, Summary: "Lista os produtos",
+			Parameters:    listParameters,
+			Response:      []entities.ProductResource{},
+			Meta:          []any{utils.PaginationMeta{}, utils.CursorMeta{}},
+			ResponseTypes: utils.ListMediaTypes,
+		},
+		{
+			Method: http.MethodGet, Path: "/products/search", Tag: tag, Summary: "Busca produtos por texto, os mais relevantes primeiro",
+			Parameters: append([]openapi.Parameter{
+				{Name: "q", Value: "", Required: true, Description: "palavras buscadas no nome e na descrição"},
+				currency,
+			}, openapi.PageParameters...),
+			Response:      []entities.ProductSearchResource{},
+			Meta:          []any{utils.PaginationMeta{}},
+			ResponseTypes: utils.ListMediaTypes,
+		},
+		{
+			Method: http.MethodGet, Path: "/products/{id}", Tag: tag, Summary: "Obtém um produto",
+			Parameters:    append(openapi.RepresentationParameters(embedCategories), currency, openapi.IfNoneMatch),
+			Response:      entities.Product{},
+			Meta:          productMeta,
+			ResponseTypes: utils.ItemMediaTypes,
+		},
+		{
+			Method: http.MethodGet, Path: "/products/{id}/prices", Tag: tag, Summary: "Lista os preços do produto em outras moedas",
+			Response:      []entities.Money{},
+			Meta:          productMeta,
+			ResponseTypes: utils.ListMediaTypes,
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/products", Tag: tag, Summary: "Cria um produto",
+			Permission: entities.PermissionProductCreate,
+			Request:    entities.Product{},
+			Status:     http.StatusCreated,
+			Response:   entities.Product{},
+			Meta:       productMeta,
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/products/_delete", Tag: tag, Summary: "Exclui os produtos dos ids informados",
+			Permission: entities.PermissionProductDelete,
+			Request:    []string{},
+			Status:     http.StatusNoContent,
+		},
+		{
+			Method: http.MethodPatch, Path: "/admin/products/{id}", Tag: tag, Summary: "Altera os campos informados do produto",
+			Permission: entities.PermissionProductUpdate,
+			Parameters: []openapi.Parameter{openapi.IfMatch},
+			Request: openapi.Bodies{
+				utils.MediaTypeJSON:       entities.Product{},
+				patch.MediaTypeMergePatch: entities.Product{},
+				patch.MediaTypeJSONPatch:  []patch.Operation{},
+			},
+			Response: entities.Product{},
+			Meta:     productMeta,
+		},
+		{
+			Method: http.MethodDelete, Path: "/admin/products/{id}", Tag: tag, Summary: "Exclui um produto",
+			Permission: entities.PermissionProductDelete,
+			Parameters: []openapi.Parameter{openapi.IfMatch},
+			Status:     http.StatusNoContent,
+		},
+		{
+			Method: http.MethodPut, Path: "/admin/products/{id}/prices/{currency}", Tag: tag, Summary: "Define o preço do produto em uma moeda",
+			Permission: entities.PermissionProductUpdate,
+			Request:    setProductPriceRequest{},
+			Response:   entities.Money{},
+			Meta:       productMeta,
+		},
+		{
+			Method: http.MethodDelete, Path: "/admin/products/{id}/prices/{currency}", Tag: tag, Summary: "Remove o preço do produto em uma moeda",
+			Permission: entities.PermissionProductUpdate,
+			Status:     http.StatusNoContent,
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package product
+
+import (
+	"encoding/json"
+	"errors"
+	"rest-api-example/entities"
+	"rest-api-example/patch"
+	"rest-api-example/validation"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// productPatchFields is the allowlist of what a PATCH may change on a product.
+func productPatchFields(update *entities.ProductFieldsUpdate) []patch.Field {
+	return []patch.Field{
+		{Name: "name", Apply: patch.String(&update.Name, 1, maxNameLength)},
+		{Name: "description", Apply: patch.String(&update.Description, 1, maxDescriptionLength)},
+		{Name: "price", Apply: basePrice(&update.Price)},
+		{Name: "active", Apply: patch.Bool(&update.Active)},
+		{Name: "CategoriesId", Apply: patch.UUIDList(&update.CategoriesId, 1)},
+		{Name: "id", ReadOnly: true},
+		{Name: "created_at", ReadOnly: true},
+		{Name: "updated_at", ReadOnly: true},
+	}
+}
+
+// basePrice accepts the forms entities.Money reads from JSON, checked by the
+// rules of validateProduct.
+func basePrice(target **entities.Money) func(json.RawMessage) error {
+	return func(raw json.RawMessage) error {
+		var price entities.Money
+		if err := json.Unmarshal(raw, &price); err != nil {
+			return errors.New("deve ser um valor monetário")
+		}
+		if err := baseCurrency(price.Currency); err != nil {
+			return err
+		}
+		if err := validation.Min[int64](0)(price.Amount); err != nil {
+			return err
+		}
+		*target = &price
+		return nil
+	}
+}
+
+func ParseProductMergePatch(document map[string]json.RawMessage) (entities.ProductFieldsUpdate, error) {
+	op := "product.ParseProductMergePatch()"
+	var update entities.ProductFieldsUpdate
+	fieldErrors := patch.ApplyMergePatch(document, productPatchFields(&update))
+	if len(fieldErrors) > 0 {
+		return entities.ProductFieldsUpdate{}, entities.NewUnprocessableEntityError(patch.ErrInvalidFields, patch.ErrInvalidFields.Error(), op, fieldErrors...)
+	}
+	return update, nil
+}
+
+// productDocument is the JSON document JSON Patch operations are applied to.
+func productDocument(product entities.Product) (map[string]json.RawMessage, error) {
+	if product.CategoriesId == nil {
+		product.CategoriesId = []uuid.UUID{}
+	}
+	content, err := json.Marshal(product)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]json.RawMessage
+	err = json.Unmarshal(content, &document)
+	if err != nil {
+		return nil, err
+	}
+	document["id"], err = json.Marshal(product.Id)
+	return document, err
+}
+
+func ParseProductJSONPatch(current entities.Product, operations []patch.Operation) (entities.ProductFieldsUpdate, error) {
+	op := "product.ParseProductJSONPatch()"
+	document, err := productDocument(current)
+	if err != nil {
+		return entities.ProductFieldsUpdate{}, entities.NewInternalServerErrorError(err, op)
+	}
+	changes, err := patch.ApplyJSONPatch(document, operations)
+	if err != nil {
+		return entities.ProductFieldsUpdate{}, patch.ApplyError(err, op)
+	}
+	return ParseProductMergePatch(changes)
+}
+
+// addedCategoriesId lists the category ids that add and replace operations
+// put in CategoriesId. Invalid values are left to the allowlist.
+func addedCategoriesId(operations []patch.Operation) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, operation := range operations {
+		if operation.Op != "add" && operation.Op != "replace" {
+			continue
+		}
+		if operation.Path != "/CategoriesId" && !strings.HasPrefix(operation.Path, "/CategoriesId/") {
+			continue
+		}
+		var values []string
+		var value string
+		if json.Unmarshal(operation.Value, &value) == nil {
+			values = []string{value}
+		} else {
+			json.Unmarshal(operation.Value, &values)
+		}
+		for _, value := range values {
+			if id, err := uuid.Parse(value); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"rest-api-example/entities"
+	"rest-api-example/listing"
+	"rest-api-example/utils"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/lib/pq"
 
@@ -25,18 +29,9 @@ func NewProductRepositoryPostgres(db *sql.DB) entities.ProductInterface {
 func (r ProductRepositoryPostgres) GetAllProducts(ctx context.Context, filters map[string][]string) ([]entities.Product, int, error) {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
-	countSql := psql.Select("COUNT(*)").
-		FromSelect(
-			psql.Select("id", "active").
-				From("categories"), "subquery",
-		)
-
-	if value, exists := filters["active"]; exists {
-		isActive, err := strconv.Atoi(value[0])
-		if err != nil {
-			return nil, 0, err
-		}
-		countSql = countSql.Where("subquery.active = ?", isActive)
+	countSql, productSql, list, err := productListSql(psql, filters)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	countQuery, countArgs, err := countSql.ToSql()
@@ -51,14 +46,6 @@ func (r ProductRepositoryPostgres) GetAllProducts(ctx context.Context, filters m
 		return nil, 0, err
 	}
 
-	productSql := psql.Select("id", "name", "description", "price", "active", "created_at", "updated_at").From("products")
-	if value, exists := filters["active"]; exists {
-		isActive, err := strconv.Atoi(value[0])
-		if err != nil {
-			return nil, 0, err
-		}
-		productSql = productSql.Where("active = ?", isActive)
-	}
 	page := 1
 	limit := 10
 	if value, exists := filters["page"]; exists {
@@ -68,7 +55,7 @@ func (r ProductRepositoryPostgres) GetAllProducts(ctx context.Context, filters m
 		limit, _ = strconv.Atoi(value[0])
 	}
 	offset := (page - 1) * limit
-	productSql = productSql.Limit(uint64(limit)).Offset(uint64(offset))
+	productSql = productSql.OrderBy(list.OrderBy()...).Limit(uint64(limit)).Offset(uint64(offset))
 
 	query, args, err := productSql.ToSql()
 	if err != nil {
@@ -84,21 +71,225 @@ func (r ProductRepositoryPostgres) GetAllProducts(ctx context.Context, filters m
 		return nil, 0, err
 	}
 
+	products, err := scanProducts(rows, priceCurrency(filters))
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, totalCount, nil
+}
+
+func (r ProductRepositoryPostgres) GetProductsByCursor(ctx context.Context, filters map[string][]string, cursor entities.Cursor) ([]entities.Product, int, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+	countSql, productSql, list, err := productListSql(psql, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	seek, err := list.Seek(cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	totalCount := -1
+	if !cursor.SkipCount {
+		countQuery, countArgs, err := countSql.ToSql()
+		if err != nil {
+			return nil, 0, err
+		}
+		err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// one more row than the limit tells whether there is a next page
+	productSql = productSql.Where(seek).
+		OrderBy(list.CursorOrderBy(cursor)...).
+		Limit(uint64(cursor.Limit + 1))
+	query, args, err := productSql.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	products, err := scanProducts(rows, priceCurrency(filters))
+	if err != nil {
+		return nil, 0, err
+	}
+	if cursor.Backward {
+		slices.Reverse(products)
+	}
+	return products, totalCount, nil
+}
+
+// productListSql builds the count and the select of the list of products
+// with the filters applied, neither ordered nor paged. It serves both
+// databases, through the placeholders of builder.
+func productListSql(builder sq.StatementBuilderType, filters map[string][]string) (sq.SelectBuilder, sq.SelectBuilder, listing.Query, error) {
+	currency := priceCurrency(filters)
+	list, err := productSchema(currency).Parse(filters)
+	if err != nil {
+		return sq.SelectBuilder{}, sq.SelectBuilder{}, listing.Query{}, err
+	}
+	countSql := builder.Select("COUNT(*)").From("products").Where(list.Where())
+	productSql := builder.Select("id", "name", "description").
+		Column(sq.Alias(listPrice(currency), "price")).
+		Columns("active", "created_at", "updated_at", "version").
+		From("products").
+		Where(list.Where())
+	if currency != entities.DefaultCurrency {
+		countSql = countSql.Where(hasListPrice(currency))
+		productSql = productSql.Where(hasListPrice(currency))
+	}
+	inStock, filterInStock, err := inStockFilter(filters)
+	if err != nil {
+		return sq.SelectBuilder{}, sq.SelectBuilder{}, listing.Query{}, err
+	}
+	if filterInStock {
+		condition := inStockCondition(inStock, time.Now().UTC())
+		countSql = countSql.Where(condition)
+		productSql = productSql.Where(condition)
+	}
+	return countSql, productSql, list, nil
+}
+
+// scanProducts reads the rows of productListSql, closing them, with the
+// price in currency.
+func scanProducts(rows *sql.Rows, currency string) ([]entities.Product, error) {
+	defer rows.Close()
 	var products []entities.Product
 	for rows.Next() {
-		var product = entities.Product{}
-		err = rows.Scan(&product.Id, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt)
+		var product = entities.Product{Price: entities.NewMoney(0, currency)}
+		err := rows.Scan(&product.Id, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt, &product.Version)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		products = append(products, product)
 	}
-	return products, totalCount, nil
+	return products, rows.Err()
+}
+
+// SearchProducts matches the query, written as in a web search engine, against
+// the search vector of the products, stemmed as Portuguese.
+func (r ProductRepositoryPostgres) SearchProducts(ctx context.Context, query string, currency string, page int, limit int) ([]entities.ProductSearchResult, int, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	searchQuery := sq.Expr("CROSS JOIN websearch_to_tsquery('portuguese', ?) AS query", query)
+
+	countSql := psql.Select("COUNT(*)").
+		From("products").
+		JoinClause(searchQuery).
+		Where("search_vector @@ query")
+	if currency != entities.DefaultCurrency {
+		countSql = countSql.Where(hasListPrice(currency))
+	}
+	countQuery, countArgs, err := countSql.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	productSql := psql.Select("id", "name", "description").
+		Column(sq.Alias(listPrice(currency), "price")).
+		Columns("active", "created_at", "updated_at", "version").
+		Column("ts_rank_cd(search_vector, query) AS rank").
+		Column("ts_headline('portuguese', "+escapedHtmlSql("name")+", query, ?)", headlineOptions+", HighlightAll=true").
+		Column("ts_headline('portuguese', "+escapedHtmlSql("description")+", query, ?)", headlineOptions).
+		From("products").
+		JoinClause(searchQuery).
+		Where("search_vector @@ query").
+		OrderBy("rank DESC", "created_at", "id").
+		Limit(uint64(limit)).
+		Offset(uint64((page - 1) * limit))
+	if currency != entities.DefaultCurrency {
+		productSql = productSql.Where(hasListPrice(currency))
+	}
+	sqlQuery, args, err := productSql.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []entities.ProductSearchResult
+	for rows.Next() {
+		result := entities.ProductSearchResult{Product: entities.Product{Price: entities.NewMoney(0, currency)}}
+		err = rows.Scan(&result.Id, &result.Name, &result.Description, &result.Price, &result.Active, &result.CreatedAt, &result.UpdatedAt, &result.Version,
+			&result.Rank, &result.Highlight.Name, &result.Highlight.Description)
+		if err != nil {
+			return nil, 0, err
+		}
+		results = append(results, result)
+	}
+	return results, totalCount, rows.Err()
+}
+
+// inStockFilter parses the "in_stock" query parameter. The second return is
+// false when no filter was given.
+func inStockFilter(filters map[string][]string) (bool, bool, error) {
+	value, exists := filters["in_stock"]
+	if !exists {
+		return false, false, nil
+	}
+	inStock, err := strconv.ParseBool(value[0])
+	if err != nil {
+		return false, false, err
+	}
+	return inStock, true, nil
+}
+
+// priceCurrency returns the currency of the "currency" query parameter,
+// already validated by the handler, or DefaultCurrency.
+func priceCurrency(filters map[string][]string) string {
+	if value, exists := filters["currency"]; exists && value[0] != "" {
+		return value[0]
+	}
+	return entities.DefaultCurrency
+}
+
+// listPriceSql selects the entry of the price list of the product in the
+// currency bound to it.
+const listPriceSql = "(SELECT product_prices.price FROM product_prices WHERE product_prices.product_id = products.id AND product_prices.currency = ?)"
+
+// listPrice selects the price of the product in currency: the base price in
+// DefaultCurrency, the entry of its price list otherwise.
+func listPrice(currency string) sq.Sqlizer {
+	if currency == entities.DefaultCurrency {
+		return sq.Expr("price")
+	}
+	return sq.Expr(listPriceSql, currency)
+}
+
+// hasListPrice leaves out the products without a price in currency, which
+// cannot be sold in it.
+func hasListPrice(currency string) sq.Sqlizer {
+	return sq.Expr("EXISTS (SELECT 1 FROM product_prices WHERE product_prices.product_id = products.id AND product_prices.currency = ?)", currency)
+}
+
+// inStockCondition compares the stock available for sale, on hand minus the
+// reservations that did not expire at now, with zero. Products never adjusted
+// have no stock row and are out of stock.
+func inStockCondition(inStock bool, now time.Time) sq.Sqlizer {
+	available := "COALESCE((SELECT on_hand FROM product_stock WHERE product_stock.product_id = products.id), 0) - " +
+		"COALESCE((SELECT SUM(quantity) FROM stock_reservations WHERE stock_reservations.product_id = products.id AND stock_reservations.expires_at > ?), 0)"
+	if inStock {
+		return sq.Expr(available+" > 0", now)
+	}
+	return sq.Expr(available+" <= 0", now)
 }
 
 func (r ProductRepositoryPostgres) GetProductById(ctx context.Context, id uuid.UUID) (entities.Product, error) {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	productSql := psql.Select("id", "name", "description", "price", "active", "created_at", "updated_at").From("products")
+	productSql := psql.Select("id", "name", "description", "price", "active", "created_at", "updated_at", "version").From("products")
 	productSql = productSql.Where("id = ?", id)
 
 	query, args, err := productSql.ToSql()
@@ -115,22 +306,61 @@ func (r ProductRepositoryPostgres) GetProductById(ctx context.Context, id uuid.U
 		return entities.Product{}, nil
 	}
 	product := entities.Product{}
-	row.Scan(&product.Id, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt)
-	return product, err
+	row.Scan(&product.Id, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt, &product.Version)
+	if product.IsEmpty() {
+		return product, nil
+	}
+	categoriesId, err := r.GetCategoriesIdByProducts(ctx, []uuid.UUID{id})
+	if err != nil {
+		return entities.Product{}, err
+	}
+	product.CategoriesId = categoriesId[id]
+	return product, nil
+}
+
+func (r ProductRepositoryPostgres) GetCategoriesIdByProducts(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Select("product_id", "category_id").
+		From("products_categories").
+		Where(sq.Eq{"product_id": ids}).
+		OrderBy("product_id", "category_id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categoriesId := make(map[uuid.UUID][]uuid.UUID)
+	for rows.Next() {
+		var productId, categoryId uuid.UUID
+		err = rows.Scan(&productId, &categoryId)
+		if err != nil {
+			return nil, err
+		}
+		categoriesId[productId] = append(categoriesId[productId], categoryId)
+	}
+	return categoriesId, rows.Err()
 }
 
-func (r ProductRepositoryPostgres) DeleteProductById(ctx context.Context, id uuid.UUID) error {
+func (r ProductRepositoryPostgres) DeleteProductById(ctx context.Context, id uuid.UUID, versions []int64) error {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 	deleteSql := psql.Delete("products").Where("id = ?", id)
+	if len(versions) > 0 {
+		deleteSql = deleteSql.Where(sq.Eq{"version": versions})
+	}
 	query, args, err := deleteSql.ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = r.db.ExecContext(ctx, query, args...)
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
-	return nil
+	return utils.CheckVersionedWrite(result, versions)
 }
 
 func (r ProductRepositoryPostgres) DeleteProducts(ctx context.Context, ids []uuid.UUID) error {
@@ -191,24 +421,209 @@ func (r ProductRepositoryPostgres) CreateProduct(ctx context.Context, product en
 	return product, nil
 }
 
-func (r ProductRepositoryPostgres) UpdateProductFields(ctx context.Context, id uuid.UUID, fields map[string]interface{}) (entities.Product, error) {
-	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	updateSql := psql.Update("products")
-	for key, value := range fields {
-		updateSql = updateSql.Set(key, value)
+func (r ProductRepositoryPostgres) UpdateProductFields(ctx context.Context, id uuid.UUID, update entities.ProductFieldsUpdate, versions []int64) (entities.Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Product{}, err
 	}
-	updateSql = updateSql.Where("id = ?", id)
-	query, args, err := updateSql.ToSql()
+	err = updateProductFields(ctx, tx, id, update, versions)
 	if err != nil {
+		tx.Rollback()
 		return entities.Product{}, err
 	}
-	_, err = r.db.ExecContext(ctx, query, args...)
+	err = tx.Commit()
 	if err != nil {
 		return entities.Product{}, err
 	}
-	product, err := r.GetProductById(ctx, id)
+	return r.GetProductById(ctx, id)
+}
+
+// PatchProductFields locks the row so that the update built from the current
+// state, categories included, is applied before anyone else changes it.
+func (r ProductRepositoryPostgres) PatchProductFields(ctx context.Context, id uuid.UUID, build func(current entities.Product) (entities.ProductFieldsUpdate, error)) (entities.Product, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
+		return entities.Product{}, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := psql.Select("id", "name", "description", "price", "active", "created_at", "updated_at", "version").
+		From("products").
+		Where("id = ?", id).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return entities.Product{}, err
+	}
+	current := entities.Product{}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&current.Id, &current.Name, &current.Description, &current.Price, &current.Active, &current.CreatedAt, &current.UpdatedAt, &current.Version)
+	if err == sql.ErrNoRows {
 		return entities.Product{}, nil
 	}
-	return product, nil
+	if err != nil {
+		return entities.Product{}, err
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT category_id FROM products_categories WHERE product_id = $1", id)
+	if err != nil {
+		return entities.Product{}, err
+	}
+	for rows.Next() {
+		var categoryId uuid.UUID
+		err = rows.Scan(&categoryId)
+		if err != nil {
+			rows.Close()
+			return entities.Product{}, err
+		}
+		current.CategoriesId = append(current.CategoriesId, categoryId)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return entities.Product{}, rows.Err()
+	}
+
+	update, err := build(current)
+	if err != nil {
+		return entities.Product{}, err
+	}
+	if !update.IsEmpty() {
+		err = updateProductFields(ctx, tx, id, update, nil)
+		if err != nil {
+			return entities.Product{}, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entities.Product{}, err
+	}
+	return r.GetProductById(ctx, id)
+}
+
+func (r ProductRepositoryPostgres) GetProductPrices(ctx context.Context, id uuid.UUID) ([]entities.Money, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT currency, price FROM product_prices WHERE product_id = $1 ORDER BY currency", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []entities.Money
+	for rows.Next() {
+		price, err := scanListPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
+// SetProductPrice bumps the version of the product along with the price
+// list, since the representations of the product in that currency change.
+func (r ProductRepositoryPostgres) SetProductPrice(ctx context.Context, id uuid.UUID, price entities.Money, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO product_prices (product_id, currency, price, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (product_id, currency) DO UPDATE SET price = EXCLUDED.price, updated_at = EXCLUDED.updated_at`,
+		id, price.Currency, price, now)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE products SET version = version + 1, updated_at = $1 WHERE id = $2", now, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r ProductRepositoryPostgres) DeleteProductPrice(ctx context.Context, id uuid.UUID, currency string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM product_prices WHERE product_id = $1 AND currency = $2", id, currency)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE products SET version = version + 1, updated_at = $1 WHERE id = $2", time.Now().UTC(), id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// scanListPrice reads the currency first, so the price is parsed in it.
+func scanListPrice(row utils.RowScanner) (entities.Money, error) {
+	var currency, price string
+	err := row.Scan(&currency, &price)
+	if err != nil {
+		return entities.Money{}, err
+	}
+	return entities.ParseMoney(price, currency)
+}
+
+// updateProductFields bumps the version on every update and, when versions
+// are given, only matches the row at one of them.
+func updateProductFields(ctx context.Context, tx *sql.Tx, id uuid.UUID, update entities.ProductFieldsUpdate, versions []int64) error {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	updateSql := psql.Update("products").
+		Set("updated_at", time.Now().UTC()).
+		Set("version", sq.Expr("version + 1"))
+	if update.Name != nil {
+		updateSql = updateSql.Set("name", *update.Name)
+	}
+	if update.Description != nil {
+		updateSql = updateSql.Set("description", *update.Description)
+	}
+	if update.Price != nil {
+		updateSql = updateSql.Set("price", *update.Price)
+	}
+	if update.Active != nil {
+		updateSql = updateSql.Set("active", *update.Active)
+	}
+	updateSql = updateSql.Where("id = ?", id)
+	if len(versions) > 0 {
+		updateSql = updateSql.Where(sq.Eq{"version": versions})
+	}
+	query, args, err := updateSql.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	err = utils.CheckVersionedWrite(result, versions)
+	if err != nil {
+		return err
+	}
+	if update.CategoriesId == nil {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM products_categories WHERE product_id = $1", id)
+	if err != nil {
+		return err
+	}
+	categoriesSql := psql.Insert("products_categories").Columns("product_id", "category_id")
+	for _, categoryId := range *update.CategoriesId {
+		categoriesSql = categoriesSql.Values(id, categoryId)
+	}
+	query, args, err = categoriesSql.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
 }
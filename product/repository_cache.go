@@ -0,0 +1,167 @@
+package product
+
+import (
+	"context"
+	"rest-api-example/cache"
+	"rest-api-example/entities"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The keys of the reads are grouped by prefix, so a write invalidates the
+// lists it may change without knowing their filters.
+//
+// cacheCategoriesId holds the categories of the products, which change with
+// the writes of the products and the deletion of categories, cleared along
+// with every "products:" key by CategoryRepositoryCache.
+const (
+	cacheItem         = "products:item:"
+	cachePrices       = "products:prices:"
+	cacheList         = "products:list:"
+	cacheCategoriesId = "products:categories:"
+	cacheByCategory   = "categories:products:"
+)
+
+type ProductRepositoryCache struct {
+	repository entities.ProductInterface
+	cache      *cache.Layer
+}
+
+// productsPage holds the results of the reads returning a total as well.
+type productsPage struct {
+	Products []entities.Product
+	Total    int
+}
+
+type searchPage struct {
+	Results []entities.ProductSearchResult
+	Total   int
+}
+
+// NewProductRepositoryCache serves the reads of repository from c until a
+// write through it succeeds. The lists filtered by in_stock are not cached,
+// since the stock changes without going through the products.
+func NewProductRepositoryCache(repository entities.ProductInterface, c *cache.Layer) entities.ProductInterface {
+	return ProductRepositoryCache{
+		repository: repository,
+		cache:      c,
+	}
+}
+
+func (r ProductRepositoryCache) GetAllProducts(ctx context.Context, filters map[string][]string) ([]entities.Product, int, error) {
+	page, err := cache.Load(ctx, r.cache, listKey("all", filters), func() (productsPage, error) {
+		products, total, err := r.repository.GetAllProducts(ctx, filters)
+		return productsPage{Products: products, Total: total}, err
+	})
+	return page.Products, page.Total, err
+}
+
+func (r ProductRepositoryCache) GetProductsByCursor(ctx context.Context, filters map[string][]string, cursor entities.Cursor) ([]entities.Product, int, error) {
+	page, err := cache.Load(ctx, r.cache, listKey("cursor", filters, cursor), func() (productsPage, error) {
+		products, total, err := r.repository.GetProductsByCursor(ctx, filters, cursor)
+		return productsPage{Products: products, Total: total}, err
+	})
+	return page.Products, page.Total, err
+}
+
+func (r ProductRepositoryCache) GetProductById(ctx context.Context, id uuid.UUID) (entities.Product, error) {
+	return cache.Load(ctx, r.cache, cacheItem+id.String(), func() (entities.Product, error) {
+		return r.repository.GetProductById(ctx, id)
+	})
+}
+
+func (r ProductRepositoryCache) GetCategoriesIdByProducts(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	return cache.Load(ctx, r.cache, cache.Key(cacheCategoriesId, ids), func() (map[uuid.UUID][]uuid.UUID, error) {
+		return r.repository.GetCategoriesIdByProducts(ctx, ids)
+	})
+}
+
+func (r ProductRepositoryCache) GetProductPrices(ctx context.Context, id uuid.UUID) ([]entities.Money, error) {
+	return cache.Load(ctx, r.cache, cachePrices+id.String(), func() ([]entities.Money, error) {
+		return r.repository.GetProductPrices(ctx, id)
+	})
+}
+
+func (r ProductRepositoryCache) SearchProducts(ctx context.Context, query string, currency string, page int, limit int) ([]entities.ProductSearchResult, int, error) {
+	results, err := cache.Load(ctx, r.cache, cache.Key(cacheList+"search:", query, currency, page, limit), func() (searchPage, error) {
+		results, total, err := r.repository.SearchProducts(ctx, query, currency, page, limit)
+		return searchPage{Results: results, Total: total}, err
+	})
+	return results.Results, results.Total, err
+}
+
+func (r ProductRepositoryCache) DeleteProductById(ctx context.Context, id uuid.UUID, versions []int64) error {
+	err := r.repository.DeleteProductById(ctx, id, versions)
+	if err == nil {
+		r.invalidate(ctx, id)
+	}
+	return err
+}
+
+func (r ProductRepositoryCache) DeleteProducts(ctx context.Context, ids []uuid.UUID) error {
+	err := r.repository.DeleteProducts(ctx, ids)
+	if err == nil {
+		r.invalidate(ctx, ids...)
+	}
+	return err
+}
+
+func (r ProductRepositoryCache) CreateProduct(ctx context.Context, product entities.Product) (entities.Product, error) {
+	created, err := r.repository.CreateProduct(ctx, product)
+	if err == nil {
+		r.invalidate(ctx, created.Id)
+	}
+	return created, err
+}
+
+func (r ProductRepositoryCache) UpdateProductFields(ctx context.Context, id uuid.UUID, update entities.ProductFieldsUpdate, versions []int64) (entities.Product, error) {
+	product, err := r.repository.UpdateProductFields(ctx, id, update, versions)
+	if err == nil {
+		r.invalidate(ctx, id)
+	}
+	return product, err
+}
+
+func (r ProductRepositoryCache) PatchProductFields(ctx context.Context, id uuid.UUID, build func(current entities.Product) (entities.ProductFieldsUpdate, error)) (entities.Product, error) {
+	product, err := r.repository.PatchProductFields(ctx, id, build)
+	if err == nil {
+		r.invalidate(ctx, id)
+	}
+	return product, err
+}
+
+func (r ProductRepositoryCache) SetProductPrice(ctx context.Context, id uuid.UUID, price entities.Money, now time.Time) error {
+	err := r.repository.SetProductPrice(ctx, id, price, now)
+	if err == nil {
+		r.invalidate(ctx, id)
+	}
+	return err
+}
+
+func (r ProductRepositoryCache) DeleteProductPrice(ctx context.Context, id uuid.UUID, currency string) (bool, error) {
+	deleted, err := r.repository.DeleteProductPrice(ctx, id, currency)
+	if err == nil && deleted {
+		r.invalidate(ctx, id)
+	}
+	return deleted, err
+}
+
+// invalidate drops the products and their prices, and every list, since a
+// change to one product may move it in or out of any of them.
+func (r ProductRepositoryCache) invalidate(ctx context.Context, ids ...uuid.UUID) {
+	keys := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		keys = append(keys, cacheItem+id.String(), cachePrices+id.String())
+	}
+	r.cache.Invalidate(ctx, keys, cacheList, cacheCategoriesId, cacheByCategory)
+}
+
+// listKey returns no key for the lists filtered by stock, which are not
+// cached.
+func listKey(kind string, filters map[string][]string, parts ...any) string {
+	if _, filterInStock := filters["in_stock"]; filterInStock {
+		return ""
+	}
+	return cache.Key(cacheList+kind+":", append([]any{filters}, parts...)...)
+}
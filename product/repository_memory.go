@@ -0,0 +1,336 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"rest-api-example/entities"
+	"rest-api-example/listing"
+	"rest-api-example/memory"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProductRepositoryMemory struct {
+	store *memory.Store
+}
+
+func NewProductRepositoryMemory(store *memory.Store) entities.ProductInterface {
+	return ProductRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r ProductRepositoryMemory) GetAllProducts(ctx context.Context, filters map[string][]string) ([]entities.Product, int, error) {
+	page := 1
+	limit := 10
+	if value, exists := filters["page"]; exists {
+		page, _ = strconv.Atoi(value[0])
+	}
+	if value, exists := filters["limit"]; exists {
+		limit, _ = strconv.Atoi(value[0])
+	}
+
+	products, list, err := r.listProducts(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return list.Compare(productValues(products[i], nil), productValues(products[j], nil)) < 0
+	})
+	return memory.Page(products, page, limit), len(products), nil
+}
+
+func (r ProductRepositoryMemory) GetProductsByCursor(ctx context.Context, filters map[string][]string, cursor entities.Cursor) ([]entities.Product, int, error) {
+	products, list, err := r.listProducts(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	window, err := listing.Window(list, products, func(product entities.Product) listing.Values {
+		return productValues(product, nil)
+	}, cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+	totalCount := len(products)
+	if cursor.SkipCount {
+		totalCount = -1
+	}
+	return window, totalCount, nil
+}
+
+// listProducts returns the products passing the filters, in no order.
+func (r ProductRepositoryMemory) listProducts(filters map[string][]string) ([]entities.Product, listing.Query, error) {
+	currency := priceCurrency(filters)
+	list, err := productSchema(currency).Parse(filters)
+	if err != nil {
+		return nil, listing.Query{}, err
+	}
+	inStock, filterInStock, err := inStockFilter(filters)
+	if err != nil {
+		return nil, listing.Query{}, err
+	}
+
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	now := time.Now().UTC()
+	var products []entities.Product
+	for _, product := range r.store.Products {
+		available := r.store.Stock[product.Id].OnHand - r.store.ReservedStock(product.Id, now)
+		if filterInStock && (available > 0) != inStock {
+			continue
+		}
+		if currency != entities.DefaultCurrency {
+			price, exists := r.store.ProductPrices[product.Id][currency]
+			if !exists {
+				continue
+			}
+			product.Price = price
+		}
+		if !list.Matches(productValues(product, r.store.ProductsCategories[product.Id])) {
+			continue
+		}
+		products = append(products, product)
+	}
+	return products, list, nil
+}
+
+func (r ProductRepositoryMemory) SearchProducts(ctx context.Context, query string, currency string, page int, limit int) ([]entities.ProductSearchResult, int, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	var results []entities.ProductSearchResult
+	for _, product := range r.store.Products {
+		rank := searchRank(terms, product.Name, product.Description)
+		if rank == 0 {
+			continue
+		}
+		if currency != entities.DefaultCurrency {
+			price, exists := r.store.ProductPrices[product.Id][currency]
+			if !exists {
+				continue
+			}
+			product.Price = price
+		}
+		results = append(results, entities.ProductSearchResult{
+			Product: product,
+			Rank:    rank,
+			Highlight: entities.ProductHighlight{
+				Name:        highlight(terms, product.Name),
+				Description: snippet(terms, product.Description),
+			},
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		if results[i].CreatedAt != results[j].CreatedAt {
+			return results[i].CreatedAt < results[j].CreatedAt
+		}
+		return results[i].Id.String() < results[j].Id.String()
+	})
+	return memory.Page(results, page, limit), len(results), nil
+}
+
+func (r ProductRepositoryMemory) GetProductById(ctx context.Context, id uuid.UUID) (entities.Product, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	product, exists := r.store.Products[id]
+	if !exists {
+		return entities.Product{}, nil
+	}
+	product.CategoriesId = slices.Clone(r.store.ProductsCategories[id])
+	return product, nil
+}
+
+func (r ProductRepositoryMemory) GetCategoriesIdByProducts(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	categoriesId := make(map[uuid.UUID][]uuid.UUID)
+	for _, id := range ids {
+		if productCategoriesId := r.store.ProductsCategories[id]; len(productCategoriesId) > 0 {
+			categoriesId[id] = slices.Clone(productCategoriesId)
+		}
+	}
+	return categoriesId, nil
+}
+
+func (r ProductRepositoryMemory) DeleteProductById(ctx context.Context, id uuid.UUID, versions []int64) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	product, exists := r.store.Products[id]
+	if exists && !entities.VersionMatches(versions, product.Version) {
+		return entities.ErrVersionMismatch
+	}
+	delete(r.store.Products, id)
+	delete(r.store.ProductsCategories, id)
+	delete(r.store.ProductPrices, id)
+	r.store.DeleteInventory(id)
+	r.store.RemoveProductFromCarts(id)
+	return nil
+}
+
+func (r ProductRepositoryMemory) DeleteProducts(ctx context.Context, ids []uuid.UUID) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for _, id := range ids {
+		delete(r.store.Products, id)
+		delete(r.store.ProductsCategories, id)
+		delete(r.store.ProductPrices, id)
+		r.store.DeleteInventory(id)
+		r.store.RemoveProductFromCarts(id)
+	}
+	return nil
+}
+
+func (r ProductRepositoryMemory) CreateProduct(ctx context.Context, product entities.Product) (entities.Product, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	if _, exists := r.store.Products[product.Id]; exists {
+		return entities.Product{}, fmt.Errorf("duplicate key value violates unique constraint: %s", product.Id)
+	}
+	for _, categoryId := range product.CategoriesId {
+		if _, exists := r.store.Categories[categoryId]; !exists {
+			return entities.Product{}, fmt.Errorf("category %s violates foreign key constraint", categoryId)
+		}
+	}
+
+	// the DEFAULT now() of the tables
+	if product.CreatedAt == "" {
+		product.CreatedAt = memory.Now()
+		product.UpdatedAt = product.CreatedAt
+	}
+	// the relation lives apart from the product, as in products_categories
+	stored := product
+	stored.CategoriesId = nil
+	r.store.Products[product.Id] = stored
+	r.store.ProductsCategories[product.Id] = append([]uuid.UUID(nil), product.CategoriesId...)
+	return product, nil
+}
+
+func (r ProductRepositoryMemory) UpdateProductFields(ctx context.Context, id uuid.UUID, update entities.ProductFieldsUpdate, versions []int64) (entities.Product, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	product, exists := r.store.Products[id]
+	if !exists {
+		return entities.Product{}, nil
+	}
+	if !entities.VersionMatches(versions, product.Version) {
+		return entities.Product{}, entities.ErrVersionMismatch
+	}
+	return r.updateProduct(product, update)
+}
+
+func (r ProductRepositoryMemory) PatchProductFields(ctx context.Context, id uuid.UUID, build func(current entities.Product) (entities.ProductFieldsUpdate, error)) (entities.Product, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	product, exists := r.store.Products[id]
+	if !exists {
+		return entities.Product{}, nil
+	}
+	current := product
+	current.CategoriesId = slices.Clone(r.store.ProductsCategories[id])
+	update, err := build(current)
+	if err != nil {
+		return entities.Product{}, err
+	}
+	if update.IsEmpty() {
+		return current, nil
+	}
+	return r.updateProduct(product, update)
+}
+
+func (r ProductRepositoryMemory) GetProductPrices(ctx context.Context, id uuid.UUID) ([]entities.Money, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+
+	var prices []entities.Money
+	for _, price := range r.store.ProductPrices[id] {
+		prices = append(prices, price)
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Currency < prices[j].Currency
+	})
+	return prices, nil
+}
+
+func (r ProductRepositoryMemory) SetProductPrice(ctx context.Context, id uuid.UUID, price entities.Money, now time.Time) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	product, exists := r.store.Products[id]
+	if !exists {
+		return fmt.Errorf("product %s violates foreign key constraint", id)
+	}
+	if r.store.ProductPrices[id] == nil {
+		r.store.ProductPrices[id] = make(map[string]entities.Money)
+	}
+	r.store.ProductPrices[id][price.Currency] = price
+	r.touchProduct(product)
+	return nil
+}
+
+func (r ProductRepositoryMemory) DeleteProductPrice(ctx context.Context, id uuid.UUID, currency string) (bool, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	if _, exists := r.store.ProductPrices[id][currency]; !exists {
+		return false, nil
+	}
+	delete(r.store.ProductPrices[id], currency)
+	r.touchProduct(r.store.Products[id])
+	return true, nil
+}
+
+// touchProduct bumps the version of the product when its price list
+// changes. It must be called with the store locked.
+func (r ProductRepositoryMemory) touchProduct(product entities.Product) {
+	product.UpdatedAt = memory.Now()
+	product.Version++
+	r.store.Products[product.Id] = product
+}
+
+// updateProduct must be called with the store locked.
+func (r ProductRepositoryMemory) updateProduct(product entities.Product, update entities.ProductFieldsUpdate) (entities.Product, error) {
+	if update.CategoriesId != nil {
+		for _, categoryId := range *update.CategoriesId {
+			if _, exists := r.store.Categories[categoryId]; !exists {
+				return entities.Product{}, fmt.Errorf("category %s violates foreign key constraint", categoryId)
+			}
+		}
+		r.store.ProductsCategories[product.Id] = append([]uuid.UUID(nil), *update.CategoriesId...)
+	}
+	if update.Name != nil {
+		product.Name = *update.Name
+	}
+	if update.Description != nil {
+		product.Description = *update.Description
+	}
+	if update.Price != nil {
+		product.Price = *update.Price
+	}
+	if update.Active != nil {
+		product.Active = *update.Active
+	}
+	product.UpdatedAt = memory.Now()
+	product.Version++
+	r.store.Products[product.Id] = product
+	product.CategoriesId = slices.Clone(r.store.ProductsCategories[product.Id])
+	return product, nil
+}
@@ -0,0 +1,533 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"rest-api-example/entities"
+	"rest-api-example/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/google/uuid"
+)
+
+type ProductRepositorySqlServer struct {
+	db *sql.DB
+}
+
+func NewProductRepositorySqlServer(db *sql.DB) entities.ProductInterface {
+	return ProductRepositorySqlServer{
+		db: db,
+	}
+}
+
+func (r ProductRepositorySqlServer) GetAllProducts(ctx context.Context, filters map[string][]string) ([]entities.Product, int, error) {
+	countSql, productSql, list, err := productListSql(sq.StatementBuilder, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	countQuery, countArgs, err := countSql.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page := 1
+	limit := 10
+	if value, exists := filters["page"]; exists {
+		page, _ = strconv.Atoi(value[0])
+	}
+	if value, exists := filters["limit"]; exists {
+		limit, _ = strconv.Atoi(value[0])
+	}
+	// SQL Server only pages with OFFSET/FETCH, which requires an ORDER BY
+	offset := (page - 1) * limit
+	productSql = productSql.OrderBy(list.OrderBy()...).
+		Suffix("OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", offset, limit)
+
+	query, args, err := productSql.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var products []entities.Product
+	for rows.Next() {
+		product, err := scanProductSqlServer(rows, priceCurrency(filters))
+		if err != nil {
+			return nil, 0, err
+		}
+		products = append(products, product)
+	}
+	return products, totalCount, rows.Err()
+}
+
+func (r ProductRepositorySqlServer) GetProductsByCursor(ctx context.Context, filters map[string][]string, cursor entities.Cursor) ([]entities.Product, int, error) {
+	countSql, productSql, list, err := productListSql(sq.StatementBuilder, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	seek, err := list.Seek(cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	totalCount := -1
+	if !cursor.SkipCount {
+		countQuery, countArgs, err := countSql.ToSql()
+		if err != nil {
+			return nil, 0, err
+		}
+		err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// one more row than the limit tells whether there is a next page
+	productSql = productSql.Where(seek).
+		OrderBy(list.CursorOrderBy(cursor)...).
+		Suffix("OFFSET 0 ROWS FETCH NEXT ? ROWS ONLY", cursor.Limit+1)
+	query, args, err := productSql.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var products []entities.Product
+	for rows.Next() {
+		product, err := scanProductSqlServer(rows, priceCurrency(filters))
+		if err != nil {
+			return nil, 0, err
+		}
+		products = append(products, product)
+	}
+	if cursor.Backward {
+		slices.Reverse(products)
+	}
+	return products, totalCount, rows.Err()
+}
+
+// SearchProducts has no full-text index to use on SQL Server, whose indexes
+// cannot be created within the transaction of a migration: every word of the
+// query must appear in the name or in the description, matched with LIKE and
+// ranked with the weights of searchRank.
+func (r ProductRepositorySqlServer) SearchProducts(ctx context.Context, query string, currency string, page int, limit int) ([]entities.ProductSearchResult, int, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	matches := sq.And{}
+	var rankSql []string
+	var rankArgs []any
+	for _, term := range terms {
+		// terms only hold letters and digits, never the wildcards of LIKE
+		pattern := "%" + term + "%"
+		matches = append(matches, sq.Expr("(name LIKE ? OR description LIKE ?)", pattern, pattern))
+		rankSql = append(rankSql, fmt.Sprintf("CASE WHEN name LIKE ? THEN %g ELSE 0 END + CASE WHEN description LIKE ? THEN %g ELSE 0 END",
+			nameWeight, descriptionWeight))
+		rankArgs = append(rankArgs, pattern, pattern)
+	}
+	if currency != entities.DefaultCurrency {
+		matches = append(matches, hasListPrice(currency))
+	}
+
+	countQuery, countArgs, err := sq.Select("COUNT(*)").From("products").Where(matches).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	productSql := sq.Select("id", "name", "description").
+		Column(sq.Alias(listPrice(currency), "price")).
+		Columns("active", "created_at", "updated_at", "version").
+		Column(sq.Alias(sq.Expr("CAST("+strings.Join(rankSql, " + ")+" AS FLOAT)", rankArgs...), "rank")).
+		From("products").
+		Where(matches).
+		OrderBy("rank DESC", "created_at", "id").
+		Suffix("OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", (page-1)*limit, limit)
+	sqlQuery, args, err := productSql.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []entities.ProductSearchResult
+	for rows.Next() {
+		var id mssql.UniqueIdentifier
+		result := entities.ProductSearchResult{Product: entities.Product{Price: entities.NewMoney(0, currency)}}
+		err = rows.Scan(&id, &result.Name, &result.Description, &result.Price, &result.Active, &result.CreatedAt, &result.UpdatedAt, &result.Version, &result.Rank)
+		if err != nil {
+			return nil, 0, err
+		}
+		result.Id = uuid.UUID(id)
+		result.Highlight = entities.ProductHighlight{
+			Name:        highlight(terms, result.Name),
+			Description: snippet(terms, result.Description),
+		}
+		results = append(results, result)
+	}
+	return results, totalCount, rows.Err()
+}
+
+func (r ProductRepositorySqlServer) GetProductById(ctx context.Context, id uuid.UUID) (entities.Product, error) {
+	productSql := sq.Select("id", "name", "description", "price", "active", "created_at", "updated_at", "version").
+		From("products").
+		Where("id = ?", id.String())
+	query, args, err := productSql.ToSql()
+	if err != nil {
+		return entities.Product{}, err
+	}
+
+	product, err := scanProductSqlServer(r.db.QueryRowContext(ctx, query, args...), entities.DefaultCurrency)
+	if err == sql.ErrNoRows {
+		return entities.Product{}, nil
+	}
+	if err != nil {
+		return entities.Product{}, err
+	}
+	categoriesId, err := r.GetCategoriesIdByProducts(ctx, []uuid.UUID{id})
+	if err != nil {
+		return entities.Product{}, err
+	}
+	product.CategoriesId = categoriesId[id]
+	return product, nil
+}
+
+func (r ProductRepositorySqlServer) GetCategoriesIdByProducts(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	productsId := make([]string, len(ids))
+	for index, id := range ids {
+		productsId[index] = id.String()
+	}
+	query, args, err := sq.Select("product_id", "category_id").
+		From("products_categories").
+		Where(sq.Eq{"product_id": productsId}).
+		OrderBy("product_id", "category_id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categoriesId := make(map[uuid.UUID][]uuid.UUID)
+	for rows.Next() {
+		var productId, categoryId mssql.UniqueIdentifier
+		err = rows.Scan(&productId, &categoryId)
+		if err != nil {
+			return nil, err
+		}
+		categoriesId[uuid.UUID(productId)] = append(categoriesId[uuid.UUID(productId)], uuid.UUID(categoryId))
+	}
+	return categoriesId, rows.Err()
+}
+
+func (r ProductRepositorySqlServer) DeleteProductById(ctx context.Context, id uuid.UUID, versions []int64) error {
+	deleteSql := sq.Delete("products").Where("id = ?", id.String())
+	if len(versions) > 0 {
+		deleteSql = deleteSql.Where(sq.Eq{"version": versions})
+	}
+	query, args, err := deleteSql.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return utils.CheckVersionedWrite(result, versions)
+}
+
+func (r ProductRepositorySqlServer) DeleteProducts(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query, args, err := sq.Delete("products").Where(sq.Eq{"id": utils.UUIDsToStrings(ids)}).ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r ProductRepositorySqlServer) CreateProduct(ctx context.Context, product entities.Product) (entities.Product, error) {
+	productSql := sq.Insert("products").
+		Columns("id", "name", "description", "price", "active", "created_at", "updated_at").
+		Values(product.Id.String(), product.Name, product.Description, product.Price, product.Active, product.CreatedAt, product.UpdatedAt)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Product{}, err
+	}
+
+	query, args, err := productSql.ToSql()
+	if err != nil {
+		tx.Rollback()
+		return entities.Product{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return entities.Product{}, err
+	}
+
+	categoriesSql := sq.Insert("products_categories").Columns("product_id", "category_id")
+	for _, categoryId := range product.CategoriesId {
+		categoriesSql = categoriesSql.Values(product.Id.String(), categoryId.String())
+	}
+	query, args, err = categoriesSql.ToSql()
+	if err != nil {
+		tx.Rollback()
+		return entities.Product{}, err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return entities.Product{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return entities.Product{}, err
+	}
+	return product, nil
+}
+
+func (r ProductRepositorySqlServer) UpdateProductFields(ctx context.Context, id uuid.UUID, update entities.ProductFieldsUpdate, versions []int64) (entities.Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Product{}, err
+	}
+	err = updateProductFieldsSqlServer(ctx, tx, id, update, versions)
+	if err != nil {
+		tx.Rollback()
+		return entities.Product{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return entities.Product{}, err
+	}
+	return r.GetProductById(ctx, id)
+}
+
+// PatchProductFields holds an update lock on the row so that the update built
+// from the current state, categories included, is applied before anyone else
+// changes it.
+func (r ProductRepositorySqlServer) PatchProductFields(ctx context.Context, id uuid.UUID, build func(current entities.Product) (entities.ProductFieldsUpdate, error)) (entities.Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entities.Product{}, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := sq.Select("id", "name", "description", "price", "active", "created_at", "updated_at", "version").
+		From("products WITH (UPDLOCK, ROWLOCK)").
+		Where("id = ?", id.String()).
+		ToSql()
+	if err != nil {
+		return entities.Product{}, err
+	}
+	current, err := scanProductSqlServer(tx.QueryRowContext(ctx, query, args...), entities.DefaultCurrency)
+	if err == sql.ErrNoRows {
+		return entities.Product{}, nil
+	}
+	if err != nil {
+		return entities.Product{}, err
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT category_id FROM products_categories WHERE product_id = ?", id.String())
+	if err != nil {
+		return entities.Product{}, err
+	}
+	for rows.Next() {
+		var categoryId mssql.UniqueIdentifier
+		err = rows.Scan(&categoryId)
+		if err != nil {
+			rows.Close()
+			return entities.Product{}, err
+		}
+		current.CategoriesId = append(current.CategoriesId, uuid.UUID(categoryId))
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return entities.Product{}, rows.Err()
+	}
+
+	update, err := build(current)
+	if err != nil {
+		return entities.Product{}, err
+	}
+	if !update.IsEmpty() {
+		err = updateProductFieldsSqlServer(ctx, tx, id, update, nil)
+		if err != nil {
+			return entities.Product{}, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entities.Product{}, err
+	}
+	return r.GetProductById(ctx, id)
+}
+
+func (r ProductRepositorySqlServer) GetProductPrices(ctx context.Context, id uuid.UUID) ([]entities.Money, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT currency, price FROM product_prices WHERE product_id = ? ORDER BY currency", id.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []entities.Money
+	for rows.Next() {
+		price, err := scanListPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
+// SetProductPrice bumps the version of the product along with the price
+// list, since the representations of the product in that currency change.
+func (r ProductRepositorySqlServer) SetProductPrice(ctx context.Context, id uuid.UUID, price entities.Money, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `MERGE product_prices WITH (HOLDLOCK) AS target
+		USING (SELECT ? AS product_id, ? AS currency, ? AS price, ? AS updated_at) AS source
+		ON target.product_id = source.product_id AND target.currency = source.currency
+		WHEN MATCHED THEN UPDATE SET price = source.price, updated_at = source.updated_at
+		WHEN NOT MATCHED THEN INSERT (product_id, currency, price, updated_at)
+			VALUES (source.product_id, source.currency, source.price, source.updated_at);`,
+		id.String(), price.Currency, price, now)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE products SET version = version + 1, updated_at = ? WHERE id = ?", now, id.String())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r ProductRepositorySqlServer) DeleteProductPrice(ctx context.Context, id uuid.UUID, currency string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM product_prices WHERE product_id = ? AND currency = ?", id.String(), currency)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE products SET version = version + 1, updated_at = ? WHERE id = ?", time.Now().UTC(), id.String())
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func updateProductFieldsSqlServer(ctx context.Context, tx *sql.Tx, id uuid.UUID, update entities.ProductFieldsUpdate, versions []int64) error {
+	updateSql := sq.Update("products").
+		Set("updated_at", time.Now().UTC()).
+		Set("version", sq.Expr("version + 1"))
+	if update.Name != nil {
+		updateSql = updateSql.Set("name", *update.Name)
+	}
+	if update.Description != nil {
+		updateSql = updateSql.Set("description", *update.Description)
+	}
+	if update.Price != nil {
+		updateSql = updateSql.Set("price", *update.Price)
+	}
+	if update.Active != nil {
+		updateSql = updateSql.Set("active", *update.Active)
+	}
+	updateSql = updateSql.Where("id = ?", id.String())
+	if len(versions) > 0 {
+		updateSql = updateSql.Where(sq.Eq{"version": versions})
+	}
+	query, args, err := updateSql.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	err = utils.CheckVersionedWrite(result, versions)
+	if err != nil {
+		return err
+	}
+	if update.CategoriesId == nil {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM products_categories WHERE product_id = ?", id.String())
+	if err != nil {
+		return err
+	}
+	categoriesSql := sq.Insert("products_categories").Columns("product_id", "category_id")
+	for _, categoryId := range *update.CategoriesId {
+		categoriesSql = categoriesSql.Values(id.String(), categoryId.String())
+	}
+	query, args, err = categoriesSql.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// scanProductSqlServer reads the UNIQUEIDENTIFIER through the driver type,
+// since SQL Server stores it with a different byte order than uuid.UUID, and
+// the price in currency.
+func scanProductSqlServer(row utils.RowScanner, currency string) (entities.Product, error) {
+	var id mssql.UniqueIdentifier
+	product := entities.Product{Price: entities.NewMoney(0, currency)}
+	err := row.Scan(&id, &product.Name, &product.Description, &product.Price, &product.Active, &product.CreatedAt, &product.UpdatedAt, &product.Version)
+	if err != nil {
+		return entities.Product{}, err
+	}
+	product.Id = uuid.UUID(id)
+	return product, nil
+}
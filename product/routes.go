@@ -3,7 +3,10 @@ package product
 import (
 	"net/http"
 	"rest-api-example/auth"
+	"rest-api-example/entities"
 	"rest-api-example/middlewares"
+	"rest-api-example/patch"
+	"rest-api-example/utils"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -13,22 +16,35 @@ func SetupProductsRoutes(mux *mux.Router, h ProductHandler, authService auth.Aut
 	admin := mux.PathPrefix("/admin/products").Subrouter()
 	admin.Use(cors.New(cors.Options{
 		AllowedOrigins: []string{"http://127.0.0.1:5500"},
-		AllowedMethods: []string{"POST", "DELETE", "PATCH", "OPTIONS"},
+		AllowedMethods: []string{"POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
 		AllowedHeaders: []string{"Authorization", "Content-Type"},
 	}).Handler)
 	admin.Use(authService.AuthenticationMiddleware)
 	admin.HandleFunc("",
-		middlewares.ValidateSupportedMediaTypes(([]string{"application/json"}),
-			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.CreateProduct))).Methods(http.MethodOptions,
+		authService.RequirePermission(entities.PermissionProductCreate,
+			middlewares.ValidateSupportedMediaTypes(([]string{"application/json"}),
+				middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.CreateProduct)))).Methods(http.MethodOptions,
 		http.MethodPost)
 	admin.HandleFunc("/_delete",
-		middlewares.ValidateSupportedMediaTypes([]string{"application/json"}, h.DeleteProducts)).Methods(http.MethodOptions,
+		authService.RequirePermission(entities.PermissionProductDelete,
+			middlewares.ValidateSupportedMediaTypes([]string{"application/json"}, h.DeleteProducts))).Methods(http.MethodOptions,
 		http.MethodPost)
 	admin.HandleFunc("/{id}",
-		middlewares.ValidateSupportedMediaTypes(([]string{"application/json"}),
-			middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.UpdateProductsFields))).Methods(http.MethodOptions,
+		authService.RequirePermission(entities.PermissionProductUpdate,
+			middlewares.ValidateSupportedMediaTypes([]string{"application/json", patch.MediaTypeMergePatch, patch.MediaTypeJSONPatch},
+				middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.UpdateProductsFields)))).Methods(http.MethodOptions,
 		http.MethodPatch)
-	admin.HandleFunc("/{id}", h.DeleteProductById).Methods(http.MethodOptions, http.MethodDelete)
+	admin.HandleFunc("/{id}",
+		authService.RequirePermission(entities.PermissionProductDelete, h.DeleteProductById)).Methods(http.MethodOptions,
+		http.MethodDelete)
+	admin.HandleFunc("/{id}/prices/{currency}",
+		authService.RequirePermission(entities.PermissionProductUpdate,
+			middlewares.ValidateSupportedMediaTypes([]string{"application/json"},
+				middlewares.ValidadeAcceptHeader([]string{"application/json"}, h.SetProductPrice)))).Methods(http.MethodOptions,
+		http.MethodPut)
+	admin.HandleFunc("/{id}/prices/{currency}",
+		authService.RequirePermission(entities.PermissionProductUpdate, h.DeleteProductPrice)).Methods(http.MethodOptions,
+		http.MethodDelete)
 
 	r := mux.PathPrefix("/products").Subrouter()
 	r.Use(cors.New(cors.Options{
@@ -36,9 +52,15 @@ func SetupProductsRoutes(mux *mux.Router, h ProductHandler, authService auth.Aut
 		AllowedMethods: []string{"GET", "OPTIONS"},
 		AllowedHeaders: []string{"Authorization", "Content-Type"},
 	}).Handler)
-	r.HandleFunc("", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+	r.HandleFunc("", middlewares.ValidadeAcceptHeader(utils.ListMediaTypes,
 		h.GetAllProducts)).Methods(http.MethodOptions, http.MethodGet)
 
-	r.HandleFunc("/{id}", middlewares.ValidadeAcceptHeader([]string{"application/json"},
+	// registered before /{id}, which would match it too
+	r.HandleFunc("/search", middlewares.ValidadeAcceptHeader(utils.ListMediaTypes,
+		h.SearchProducts)).Methods(http.MethodOptions, http.MethodGet)
+
+	r.HandleFunc("/{id}", middlewares.ValidadeAcceptHeader(utils.ItemMediaTypes,
 		h.GetProductById)).Methods(http.MethodOptions, http.MethodGet)
+	r.HandleFunc("/{id}/prices", middlewares.ValidadeAcceptHeader(utils.ListMediaTypes,
+		h.GetProductPrices)).Methods(http.MethodOptions, http.MethodGet)
 }
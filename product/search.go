@@ -0,0 +1,142 @@
+package product
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// highlightStart and highlightStop surround the matched words, as in the
+	// ts_headline options of the Postgres search.
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+	// snippetWords bounds the description snippet, close to the MaxWords of
+	// ts_headline.
+	snippetWords = 35
+	// nameWeight and descriptionWeight rank a match in the name above one in
+	// the description, like the A and B weights of the search vector.
+	nameWeight        = 1.0
+	descriptionWeight = 0.4
+)
+
+// headlineOptions are the ts_headline options of the Postgres search.
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15"
+
+// escapedHtmlSql escapes column as html.EscapeString does, so ts_headline
+// inserts its marks into text the clients can render as HTML.
+func escapedHtmlSql(column string) string {
+	escaped := column
+	for _, replacement := range [][2]string{{"&", "&amp;"}, {"<", "&lt;"}, {">", "&gt;"}, {`"`, "&#34;"}, {"'", "&#39;"}} {
+		escaped = fmt.Sprintf("REPLACE(%s, '%s', '%s')", escaped, strings.ReplaceAll(replacement[0], "'", "''"), replacement[1])
+	}
+	return escaped
+}
+
+// searchTerms splits a search into its distinct lower case words. Databases
+// without full-text search match these words as substrings, with no stemming.
+func searchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var terms []string
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// searchRank scores name and description against the terms, zero when any
+// term is in neither of them.
+func searchRank(terms []string, name string, description string) float64 {
+	name = strings.ToLower(name)
+	description = strings.ToLower(description)
+	var rank float64
+	for _, term := range terms {
+		inName := strings.Contains(name, term)
+		inDescription := strings.Contains(description, term)
+		if !inName && !inDescription {
+			return 0
+		}
+		if inName {
+			rank += nameWeight
+		}
+		if inDescription {
+			rank += descriptionWeight
+		}
+	}
+	return rank
+}
+
+// highlight marks every occurrence of the terms in text, ignoring case. The
+// text is HTML escaped, as the marks make it HTML.
+func highlight(terms []string, text string) string {
+	lower := strings.ToLower(text)
+	// lower casing may change the length of some runes, which would misplace
+	// the marks
+	if len(lower) != len(text) {
+		return html.EscapeString(text)
+	}
+	type match struct{ start, end int }
+	var matches []match
+	for _, term := range terms {
+		for offset := 0; ; {
+			index := strings.Index(lower[offset:], term)
+			if index < 0 {
+				break
+			}
+			start := offset + index
+			matches = append(matches, match{start, start + len(term)})
+			offset = start + len(term)
+		}
+	}
+	if len(matches) == 0 {
+		return html.EscapeString(text)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	var builder strings.Builder
+	position := 0
+	for _, m := range matches {
+		if m.end <= position {
+			continue
+		}
+		if m.start < position {
+			m.start = position
+		}
+		builder.WriteString(html.EscapeString(text[position:m.start]))
+		builder.WriteString(highlightStart)
+		builder.WriteString(html.EscapeString(text[m.start:m.end]))
+		builder.WriteString(highlightStop)
+		position = m.end
+	}
+	builder.WriteString(html.EscapeString(text[position:]))
+	return builder.String()
+}
+
+// snippet marks the terms in text and keeps at most snippetWords words,
+// starting a few words before the first match.
+func snippet(terms []string, text string) string {
+	words := strings.Fields(highlight(terms, text))
+	if len(words) <= snippetWords {
+		return strings.Join(words, " ")
+	}
+	start := 0
+	for index, word := range words {
+		if strings.Contains(word, highlightStart) {
+			start = max(index-5, 0)
+			break
+		}
+	}
+	end := min(start+snippetWords, len(words))
+	start = max(end-snippetWords, 0)
+	return strings.Join(words[start:end], " ")
+}
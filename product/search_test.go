@@ -0,0 +1,47 @@
+package product
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		text     string
+		expected string
+	}{
+		{name: "marks the terms", query: "caneca azul", text: "Caneca Azul de louça", expected: "<mark>Caneca</mark> <mark>Azul</mark> de louça"},
+		{name: "escapes the text", query: "caneca", text: "<b>Caneca</b> & Cia", expected: "&lt;b&gt;<mark>Caneca</mark>&lt;/b&gt; &amp; Cia"},
+		{name: "escapes a script", query: "alert", text: `<script>alert("x")</script>`, expected: "&lt;script&gt;<mark>alert</mark>(&#34;x&#34;)&lt;/script&gt;"},
+		{name: "escapes without matches", query: "prato", text: "Copo & <i>Pires</i>", expected: "Copo &amp; &lt;i&gt;Pires&lt;/i&gt;"},
+		{name: "term containing markup", query: "b", text: "<b>", expected: "&lt;<mark>b</mark>&gt;"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if highlighted := highlight(searchTerms(test.query), test.text); highlighted != test.expected {
+				t.Errorf("highlight() = %q, want %q", highlighted, test.expected)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("palavra ", 50) + "<b>caneca</b> & " + strings.Repeat("fim ", 50)
+	result := snippet(searchTerms("caneca"), text)
+
+	if !strings.Contains(result, "&lt;b&gt;<mark>caneca</mark>&lt;/b&gt; &amp;") {
+		t.Errorf("snippet() = %q, want the escaped match", result)
+	}
+	if words := len(strings.Fields(result)); words != snippetWords {
+		t.Errorf("snippet() has %d words, want %d", words, snippetWords)
+	}
+}
+
+func TestEscapedHtmlSql(t *testing.T) {
+	expected := `REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(name, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+	if escaped := escapedHtmlSql("name"); escaped != expected {
+		t.Errorf("escapedHtmlSql() = %s, want %s", escaped, expected)
+	}
+}
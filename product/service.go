@@ -5,16 +5,31 @@ import (
 	"errors"
 	"rest-api-example/category"
 	"rest-api-example/entities"
+	"rest-api-example/patch"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // erros do produto
 var (
-	ErrProdutoNaoCdastrado             = errors.New("produto não cadastrada")
-	ErrCategoriaDoProdutoEhObrigatoria = errors.New("produto deve ter ao menos 1 categoria")
-	ErrNomeProdutoEhObrigatorio        = errors.New("nome do produto deve ser informado")
-	ErrDescricaoProdutoEhObrigatorio   = errors.New("descricao do produto deve ser informada")
+	ErrProdutoNaoCdastrado = errors.New("produto não cadastrada")
+	ErrProdutoAlterado     = errors.New("produto alterado por outra requisição, obtenha o ETag atual e tente novamente")
+	ErrPrecoNegativo       = errors.New("preço do produto não pode ser negativo")
+	ErrMoedaDoPrecoBase    = errors.New("preço do produto deve estar na moeda padrão, as outras moedas ficam na lista de preços")
+	ErrMoedaInvalida       = errors.New("moeda deve ser um código ISO 4217 suportado")
+	ErrPrecoInvalido       = errors.New("preço deve ser um número decimal com as casas decimais da moeda")
+	ErrPrecoNaoCadastrado  = errors.New("produto sem preço nesta moeda")
+	ErrBuscaInvalida       = errors.New("a busca deve ter ao menos uma palavra e até 200 caracteres")
+)
+
+const (
+	// maxSearchLength bounds the text of a search, parsed on every request.
+	maxSearchLength = 200
+	// maxSearchLimit bounds the page size of a search.
+	maxSearchLimit = 100
 )
 
 type ProductService struct {
@@ -38,6 +53,32 @@ func (s ProductService) GetAllProducts(ctx context.Context, filters map[string][
 	return products, totalCount, nil
 }
 
+// GetProductsByCursor returns the page of products of the cursor, with the
+// extra one GetProductsByCursor of the repository reads past the limit.
+func (s ProductService) GetProductsByCursor(ctx context.Context, filters map[string][]string, cursor entities.Cursor) ([]entities.Product, int, error) {
+	op := "ProductService.GetProductsByCursor()"
+	products, totalCount, err := s.productRepository.GetProductsByCursor(ctx, filters, cursor)
+	if err != nil {
+		return nil, 0, entities.NewInternalServerErrorError(err, op)
+	}
+	return products, totalCount, nil
+}
+
+// SearchProducts returns a page of the products matching query, the most
+// relevant first, priced in currency.
+func (s ProductService) SearchProducts(ctx context.Context, query string, currency string, page int, limit int) ([]entities.ProductSearchResult, int, error) {
+	op := "ProductService.SearchProducts()"
+	if len(searchTerms(query)) == 0 || len(query) > maxSearchLength {
+		return nil, 0, entities.NewBadRequestError(ErrBuscaInvalida, ErrBuscaInvalida.Error(), op,
+			entities.FieldError{Field: "q", Message: ErrBuscaInvalida.Error()})
+	}
+	results, totalCount, err := s.productRepository.SearchProducts(ctx, query, currency, page, limit)
+	if err != nil {
+		return nil, 0, entities.NewInternalServerErrorError(err, op)
+	}
+	return results, totalCount, nil
+}
+
 func (s ProductService) GetProductById(ctx context.Context, id uuid.UUID) (entities.Product, error) {
 	op := "ProductService.GetProductById()"
 	product, err := s.productRepository.GetProductById(ctx, id)
@@ -50,7 +91,134 @@ func (s ProductService) GetProductById(ctx context.Context, id uuid.UUID) (entit
 	return product, nil
 }
 
-func (s ProductService) DeleteProductById(ctx context.Context, id uuid.UUID) error {
+// GetProductsCategories returns the categories of each of the products with
+// two queries, whatever the number of products, and fills their
+// CategoriesId along the way.
+func (s ProductService) GetProductsCategories(ctx context.Context, products []entities.Product) (map[uuid.UUID][]entities.Category, error) {
+	op := "ProductService.GetProductsCategories()"
+	ids := make([]uuid.UUID, len(products))
+	for index, product := range products {
+		ids[index] = product.Id
+	}
+	categoriesId, err := s.productRepository.GetCategoriesIdByProducts(ctx, ids)
+	if err != nil {
+		return nil, entities.NewInternalServerErrorError(err, op)
+	}
+
+	var allCategoriesId []uuid.UUID
+	for _, productCategoriesId := range categoriesId {
+		allCategoriesId = append(allCategoriesId, productCategoriesId...)
+	}
+	slices.SortFunc(allCategoriesId, func(a uuid.UUID, b uuid.UUID) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	categories, err := s.categoryRepository.GetCategoriesByIds(ctx, slices.Compact(allCategoriesId))
+	if err != nil {
+		return nil, entities.NewInternalServerErrorError(err, op)
+	}
+	categoriesById := make(map[uuid.UUID]entities.Category, len(categories))
+	for _, category := range categories {
+		categoriesById[category.Id] = category
+	}
+
+	productsCategories := make(map[uuid.UUID][]entities.Category, len(products))
+	for index := range products {
+		products[index].CategoriesId = categoriesId[products[index].Id]
+		productCategories := []entities.Category{}
+		for _, categoryId := range products[index].CategoriesId {
+			if category, exists := categoriesById[categoryId]; exists {
+				productCategories = append(productCategories, category)
+			}
+		}
+		productsCategories[products[index].Id] = productCategories
+	}
+	return productsCategories, nil
+}
+
+// GetProductInCurrency returns the product priced in currency, from its price
+// list when the currency is not DefaultCurrency.
+func (s ProductService) GetProductInCurrency(ctx context.Context, id uuid.UUID, currency string) (entities.Product, error) {
+	op := "ProductService.GetProductInCurrency()"
+	product, err := s.GetProductById(ctx, id)
+	if err != nil || currency == entities.DefaultCurrency {
+		return product, err
+	}
+	prices, err := s.productRepository.GetProductPrices(ctx, id)
+	if err != nil {
+		return entities.Product{}, entities.NewInternalServerErrorError(err, op)
+	}
+	for _, price := range prices {
+		if price.Currency == currency {
+			product.Price = price
+			return product, nil
+		}
+	}
+	return entities.Product{}, entities.NewNotFoundError(ErrPrecoNaoCadastrado, ErrPrecoNaoCadastrado.Error(), op)
+}
+
+// GetProductPrices lists the base price of the product followed by its price
+// list.
+func (s ProductService) GetProductPrices(ctx context.Context, id uuid.UUID) ([]entities.Money, error) {
+	op := "ProductService.GetProductPrices()"
+	product, err := s.GetProductById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := s.productRepository.GetProductPrices(ctx, id)
+	if err != nil {
+		return nil, entities.NewInternalServerErrorError(err, op)
+	}
+	return append([]entities.Money{product.Price}, prices...), nil
+}
+
+// SetProductPrice sets the price of the product in a currency other than
+// DefaultCurrency, which is changed through the price field of the product.
+func (s ProductService) SetProductPrice(ctx context.Context, id uuid.UUID, currency string, amount string) (entities.Money, error) {
+	op := "ProductService.SetProductPrice()"
+	if !entities.IsCurrency(currency) {
+		return entities.Money{}, entities.NewBadRequestError(ErrMoedaInvalida, ErrMoedaInvalida.Error(), op,
+			entities.FieldError{Field: "currency", Message: ErrMoedaInvalida.Error()})
+	}
+	if currency == entities.DefaultCurrency {
+		return entities.Money{}, entities.NewUnprocessableEntityError(ErrMoedaDoPrecoBase, ErrMoedaDoPrecoBase.Error(), op,
+			entities.FieldError{Field: "currency", Message: ErrMoedaDoPrecoBase.Error()})
+	}
+	price, err := entities.ParseMoney(amount, currency)
+	if err != nil {
+		return entities.Money{}, entities.NewUnprocessableEntityError(ErrPrecoInvalido, ErrPrecoInvalido.Error(), op,
+			entities.FieldError{Field: "amount", Message: ErrPrecoInvalido.Error()})
+	}
+	if price.Amount < 0 {
+		return entities.Money{}, entities.NewUnprocessableEntityError(ErrPrecoNegativo, ErrPrecoNegativo.Error(), op,
+			entities.FieldError{Field: "amount", Message: ErrPrecoNegativo.Error()})
+	}
+
+	_, err = s.GetProductById(ctx, id)
+	if err != nil {
+		return entities.Money{}, err
+	}
+	err = s.productRepository.SetProductPrice(ctx, id, price, time.Now().UTC())
+	if err != nil {
+		return entities.Money{}, entities.NewInternalServerErrorError(err, op)
+	}
+	return price, nil
+}
+
+func (s ProductService) DeleteProductPrice(ctx context.Context, id uuid.UUID, currency string) error {
+	op := "ProductService.DeleteProductPrice()"
+	deleted, err := s.productRepository.DeleteProductPrice(ctx, id, currency)
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	if !deleted {
+		return entities.NewNotFoundError(ErrPrecoNaoCadastrado, ErrPrecoNaoCadastrado.Error(), op)
+	}
+	return nil
+}
+
+// DeleteProductById only deletes the product at one of the given versions,
+// when there are any.
+func (s ProductService) DeleteProductById(ctx context.Context, id uuid.UUID, versions []int64) error {
 	op := "ProductService.DeleteProductById()"
 	product, err := s.productRepository.GetProductById(ctx, id)
 	if err != nil {
@@ -59,7 +227,10 @@ func (s ProductService) DeleteProductById(ctx context.Context, id uuid.UUID) err
 	if product.IsEmpty() {
 		return entities.NewNotFoundError(ErrProdutoNaoCdastrado, ErrProdutoNaoCdastrado.Error(), op)
 	}
-	err = s.productRepository.DeleteProductById(ctx, id)
+	err = s.productRepository.DeleteProductById(ctx, id, versions)
+	if errors.Is(err, entities.ErrVersionMismatch) {
+		return entities.NewPreconditionFailedError(err, ErrProdutoAlterado.Error(), op)
+	}
 	if err != nil {
 		return entities.NewInternalServerErrorError(err, op)
 	}
@@ -77,25 +248,26 @@ func (s ProductService) DeleteProducts(ctx context.Context, ids []uuid.UUID) err
 
 func (s ProductService) CreateProduct(ctx context.Context, product entities.Product) (entities.Product, error) {
 	op := "ProductService.CreateProcut()"
-	if len(product.CategoriesId) == 0 {
-		return entities.Product{}, entities.NewBadRequestError(ErrCategoriaDoProdutoEhObrigatoria, ErrCategoriaDoProdutoEhObrigatoria.Error(), op)
-	}
-	categories, err := s.categoryRepository.GetCategoriesByIds(ctx, product.CategoriesId)
-	if err != nil {
-		return entities.Product{}, entities.NewInternalServerErrorError(err, op)
+	if product.Price.Currency == "" {
+		product.Price.Currency = entities.DefaultCurrency
 	}
-	if len(categories) < len(product.CategoriesId) {
-		return entities.Product{}, entities.NewBadRequestError(category.ErrCategoriaNaoCadastrada, category.ErrCategoriaNaoCadastrada.Error(), op)
-
+	validator := validateProduct(product)
+	// the categories are looked up only when the ids themselves are valid
+	if !validator.Failed("CategoriesId") {
+		categories, err := s.categoryRepository.GetCategoriesByIds(ctx, product.CategoriesId)
+		if err != nil {
+			return entities.Product{}, entities.NewInternalServerErrorError(err, op)
+		}
+		if len(categories) < len(product.CategoriesId) {
+			validator.Add("CategoriesId", category.ErrCategoriaNaoCadastrada.Error())
+		}
 	}
-	if product.Name == "" {
-		return entities.Product{}, entities.NewBadRequestError(ErrNomeProdutoEhObrigatorio, ErrNomeProdutoEhObrigatorio.Error(), op)
-
-	}
-	if product.Description == "" {
-		return entities.Product{}, entities.NewBadRequestError(ErrDescricaoProdutoEhObrigatorio, ErrDescricaoProdutoEhObrigatorio.Error(), op)
+	err := validator.Error(op)
+	if err != nil {
+		return entities.Product{}, err
 	}
 	product.Id = uuid.New()
+	product.Version = 1
 	_, err = s.productRepository.CreateProduct(ctx, product)
 	if err != nil {
 		return entities.Product{}, entities.NewInternalServerErrorError(err, op)
@@ -103,7 +275,9 @@ func (s ProductService) CreateProduct(ctx context.Context, product entities.Prod
 	return product, nil
 }
 
-func (s ProductService) UpdateProductFields(ctx context.Context, id uuid.UUID, fields map[string]interface{}) (entities.Product, error) {
+// UpdateProductFields only updates the product at one of the given versions,
+// when there are any; the check is part of the UPDATE itself.
+func (s ProductService) UpdateProductFields(ctx context.Context, id uuid.UUID, update entities.ProductFieldsUpdate, versions []int64) (entities.Product, error) {
 	op := "ProductService.UpdateProductFields()"
 
 	productDatabase, err := s.productRepository.GetProductById(ctx, id)
@@ -113,10 +287,71 @@ func (s ProductService) UpdateProductFields(ctx context.Context, id uuid.UUID, f
 	if productDatabase.IsEmpty() {
 		return entities.Product{}, entities.NewNotFoundError(ErrProdutoNaoCdastrado, ErrProdutoNaoCdastrado.Error(), op)
 	}
+	if !entities.VersionMatches(versions, productDatabase.Version) {
+		return entities.Product{}, entities.NewPreconditionFailedError(entities.ErrVersionMismatch, ErrProdutoAlterado.Error(), op)
+	}
+	if update.IsEmpty() {
+		return productDatabase, nil
+	}
+
+	err = s.validateUpdateCategories(ctx, update, op)
+	if err != nil {
+		return entities.Product{}, err
+	}
 
-	product, err := s.productRepository.UpdateProductFields(ctx, id, fields)
+	product, err := s.productRepository.UpdateProductFields(ctx, id, update, versions)
+	if errors.Is(err, entities.ErrVersionMismatch) {
+		return entities.Product{}, entities.NewPreconditionFailedError(err, ErrProdutoAlterado.Error(), op)
+	}
 	if err != nil {
 		return entities.Product{}, entities.NewInternalServerErrorError(err, op)
 	}
 	return product, nil
 }
+
+// PatchProductFields applies a JSON Patch to the current state of the product,
+// within the same transaction that stores the result.
+func (s ProductService) PatchProductFields(ctx context.Context, id uuid.UUID, operations []patch.Operation, versions []int64) (entities.Product, error) {
+	op := "ProductService.PatchProductFields()"
+
+	// categories are checked up front, the callback runs while the row is locked
+	if added := addedCategoriesId(operations); len(added) > 0 {
+		err := s.validateUpdateCategories(ctx, entities.ProductFieldsUpdate{CategoriesId: &added}, op)
+		if err != nil {
+			return entities.Product{}, err
+		}
+	}
+
+	product, err := s.productRepository.PatchProductFields(ctx, id, func(current entities.Product) (entities.ProductFieldsUpdate, error) {
+		if !entities.VersionMatches(versions, current.Version) {
+			return entities.ProductFieldsUpdate{}, entities.NewPreconditionFailedError(entities.ErrVersionMismatch, ErrProdutoAlterado.Error(), op)
+		}
+		return ParseProductJSONPatch(current, operations)
+	})
+	if err != nil {
+		var apiError *entities.Error
+		if errors.As(err, &apiError) {
+			return entities.Product{}, err
+		}
+		return entities.Product{}, entities.NewInternalServerErrorError(err, op)
+	}
+	if product.IsEmpty() {
+		return entities.Product{}, entities.NewNotFoundError(ErrProdutoNaoCdastrado, ErrProdutoNaoCdastrado.Error(), op)
+	}
+	return product, nil
+}
+
+func (s ProductService) validateUpdateCategories(ctx context.Context, update entities.ProductFieldsUpdate, op string) error {
+	if update.CategoriesId == nil {
+		return nil
+	}
+	categories, err := s.categoryRepository.GetCategoriesByIds(ctx, *update.CategoriesId)
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	if len(categories) < len(*update.CategoriesId) {
+		return entities.NewBadRequestError(category.ErrCategoriaNaoCadastrada, category.ErrCategoriaNaoCadastrada.Error(), op,
+			entities.FieldError{Field: "CategoriesId", Message: category.ErrCategoriaNaoCadastrada.Error()})
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package product
+
+import (
+	"rest-api-example/entities"
+	"rest-api-example/validation"
+
+	"github.com/google/uuid"
+)
+
+// the limits of the fields, shared by validateProduct and productPatchFields
+const (
+	maxNameLength        = 255
+	maxDescriptionLength = 5000
+)
+
+// baseCurrency rejects the currencies other than DefaultCurrency, which are
+// set in the price list.
+func baseCurrency(currency string) error {
+	if currency != entities.DefaultCurrency {
+		return ErrMoedaDoPrecoBase
+	}
+	return nil
+}
+
+// validateProduct checks the fields of a new product. Price.Currency must be
+// set already.
+func validateProduct(product entities.Product) *validation.Validator {
+	validator := validation.New()
+	validation.Field(validator, "name", product.Name, validation.Required[string](), validation.Length(1, maxNameLength))
+	validation.Field(validator, "description", product.Description, validation.Required[string](), validation.Length(1, maxDescriptionLength))
+	validation.Field(validator, "price.currency", product.Price.Currency, baseCurrency)
+	validation.Field(validator, "price.amount", product.Price.Amount, validation.Min[int64](0))
+	validation.Field(validator, "CategoriesId", product.CategoriesId, validation.MinItems[uuid.UUID](1), validation.Each(validation.UUID()))
+	return validator
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"rest-api-example/auth"
+	"rest-api-example/cart"
+	"rest-api-example/category"
+	"rest-api-example/config"
+	"rest-api-example/entities"
+	"rest-api-example/inventory"
+	"rest-api-example/memory"
+	"rest-api-example/migration"
+	"rest-api-example/order"
+	"rest-api-example/payment"
+	"rest-api-example/product"
+	"rest-api-example/user"
+)
+
+type repositories struct {
+	category     entities.CategoryInterface
+	product      entities.ProductInterface
+	user         entities.UserInterface
+	refreshToken entities.RefreshTokenInterface
+	inventory    entities.InventoryInterface
+	cart         entities.CartInterface
+	order        entities.OrderInterface
+	payment      entities.PaymentInterface
+}
+
+func openDatabase(cfg *config.Config) (*sql.DB, migration.Dialect, error) {
+	switch cfg.Driver {
+	case config.DriverPostgres:
+		db, err := config.NewDatabaseConnectionPostgreSQL(cfg.PostgresServerDatabase)
+		return db, migration.Postgres, err
+	case config.DriverSqlServer:
+		db, err := config.NewDatabaseConnectionSqlServer(cfg.SqlServerDatabase)
+		return db, migration.SqlServer, err
+	default:
+		return nil, migration.Dialect{}, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+func newRepositories(driver string, db *sql.DB) repositories {
+	if driver == config.DriverSqlServer {
+		return repositories{
+			category:     category.NewCategoryRepositorySqlServer(db),
+			product:      product.NewProductRepositorySqlServer(db),
+			user:         user.NewUserRepositorySqlServer(db),
+			refreshToken: auth.NewRefreshTokenRepositorySqlServer(db),
+			inventory:    inventory.NewInventoryRepositorySqlServer(db),
+			cart:         cart.NewCartRepositorySqlServer(db),
+			order:        order.NewOrderRepositorySqlServer(db),
+			payment:      payment.NewPaymentRepositorySqlServer(db),
+		}
+	}
+	return repositories{
+		category:     category.NewCategoryRepositoryPostgres(db),
+		product:      product.NewProductRepositoryPostgres(db),
+		user:         user.NewUserRepository(db),
+		refreshToken: auth.NewRefreshTokenRepositoryPostgres(db),
+		inventory:    inventory.NewInventoryRepositoryPostgres(db),
+		cart:         cart.NewCartRepositoryPostgres(db),
+		order:        order.NewOrderRepositoryPostgres(db),
+		payment:      payment.NewPaymentRepositoryPostgres(db),
+	}
+}
+
+func newMemoryRepositories() repositories {
+	store := memory.NewStore()
+	return repositories{
+		category:     category.NewCategoryRepositoryMemory(store),
+		product:      product.NewProductRepositoryMemory(store),
+		user:         user.NewUserRepositoryMemory(store),
+		refreshToken: auth.NewRefreshTokenRepositoryMemory(store),
+		inventory:    inventory.NewInventoryRepositoryMemory(store),
+		cart:         cart.NewCartRepositoryMemory(store),
+		order:        order.NewOrderRepositoryMemory(store),
+		payment:      payment.NewPaymentRepositoryMemory(store),
+	}
+}
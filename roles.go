@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rest-api-example/entities"
+	"rest-api-example/user"
+	"time"
+)
+
+// grantRole handles "-action grant-role <login> <role>", the way to create the
+// first admin since self registered users are always customers.
+func grantRole(userService user.UserService, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: -action grant-role <login> <role>")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := userService.UpdateRole(ctx, args[0], entities.Role(args[1]))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("role %s granted to %s\n", args[1], args[0])
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"rest-api-example/entities"
 	"rest-api-example/utils"
 	"time"
+
+	"github.com/gorilla/mux"
 )
 
 var (
@@ -30,15 +32,40 @@ func (h UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	var credentials entities.Credentials
 	err := json.NewDecoder(r.Body).Decode(&credentials)
 	if err != nil {
-		utils.JSONError(w, entities.NewBadRequestError(err, ErrInvalidJsonFormat.Error(), op))
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrInvalidJsonFormat.Error(), op))
 		return
 	}
 
 	err = h.userService.Registry(ctx, credentials)
 	if err != nil {
-		utils.JSONError(w, err)
+		utils.JSONError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
+
+type UpdateRoleRequest struct {
+	Role entities.Role `json:"role"`
+}
+
+func (h UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	op := "UserHandler.UpdateUserRole()"
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var request UpdateRoleRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utils.JSONError(w, r, entities.NewBadRequestError(err, ErrInvalidJsonFormat.Error(), op))
+		return
+	}
+
+	err = h.userService.UpdateRole(ctx, mux.Vars(r)["login"], request.Role)
+	if err != nil {
+		utils.JSONError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
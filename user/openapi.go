@@ -0,0 +1,18 @@
+package user
+
+import (
+	"net/http"
+	"rest-api-example/entities"
+	"rest-api-example/openapi"
+)
+
+// Operations documents the routes of SetupUserRoutes.
+func Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodPost, Path: "/users", Tag: "Usuários", Summary: "Cadastra um usuário com o papel customer",
+			Request: entities.Credentials{},
+			Status:  http.StatusCreated,
+		},
+	}
+}
@@ -22,7 +22,7 @@ func NewUserRepository(db *sql.DB) entities.UserInterface {
 func (r UserRepository) GetCredentialsByLogin(ctx context.Context, login string) (entities.Credentials, error) {
 	op := "UserRepository.GetCredentials()"
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	categorySql := psql.Select("login", "password").From("users").Where(sq.Eq{"login": login})
+	categorySql := psql.Select("login", "password", "role").From("users").Where(sq.Eq{"login": login})
 	query, args, err := categorySql.ToSql()
 	if err != nil {
 		return entities.Credentials{}, entities.NewInternalServerErrorError(err, op)
@@ -36,10 +36,10 @@ func (r UserRepository) GetCredentialsByLogin(ctx context.Context, login string)
 
 	credentials := entities.Credentials{}
 	row := stmt.QueryRowContext(ctx, args...)
-	if errors.Is(row.Err(), sql.ErrNoRows) {
+	err = row.Scan(&credentials.Login, &credentials.Password, &credentials.Role)
+	if errors.Is(err, sql.ErrNoRows) {
 		return entities.Credentials{}, nil
 	}
-	err = row.Scan(&credentials.Login, &credentials.Password)
 	if err != nil {
 		return entities.Credentials{}, entities.NewInternalServerErrorError(err, op)
 	}
@@ -49,8 +49,8 @@ func (r UserRepository) GetCredentialsByLogin(ctx context.Context, login string)
 func (r UserRepository) InsertUser(ctx context.Context, credentials entities.Credentials) error {
 	op := "UserRepository.InsertUser()"
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	usersSql := psql.Insert("users").Columns("login", "password")
-	usersSql = usersSql.Values(credentials.Login, credentials.Password)
+	usersSql := psql.Insert("users").Columns("login", "password", "role")
+	usersSql = usersSql.Values(credentials.Login, credentials.Password, credentials.Role)
 
 	query, args, err := usersSql.ToSql()
 	if err != nil {
@@ -62,3 +62,17 @@ func (r UserRepository) InsertUser(ctx context.Context, credentials entities.Cre
 	}
 	return nil
 }
+
+func (r UserRepository) UpdateUserRole(ctx context.Context, login string, role entities.Role) error {
+	op := "UserRepository.UpdateUserRole()"
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query, args, err := psql.Update("users").Set("role", role).Where(sq.Eq{"login": login}).ToSql()
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"rest-api-example/entities"
+	"rest-api-example/memory"
+)
+
+var (
+	ErrLoginAlreadyRegistered = errors.New("login already registered")
+)
+
+type UserRepositoryMemory struct {
+	store *memory.Store
+}
+
+func NewUserRepositoryMemory(store *memory.Store) entities.UserInterface {
+	return &UserRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r UserRepositoryMemory) GetCredentialsByLogin(ctx context.Context, login string) (entities.Credentials, error) {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	return r.store.Users[login], nil
+}
+
+func (r UserRepositoryMemory) InsertUser(ctx context.Context, credentials entities.Credentials) error {
+	op := "UserRepositoryMemory.InsertUser()"
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	if _, exists := r.store.Users[credentials.Login]; exists {
+		return entities.NewConflictError(ErrLoginAlreadyRegistered, ErrLoginAlreadyRegistered.Error(), op)
+	}
+	r.store.Users[credentials.Login] = credentials
+	return nil
+}
+
+func (r UserRepositoryMemory) UpdateUserRole(ctx context.Context, login string, role entities.Role) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	credentials, exists := r.store.Users[login]
+	if !exists {
+		return nil
+	}
+	credentials.Role = role
+	r.store.Users[login] = credentials
+	return nil
+}
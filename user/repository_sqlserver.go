@@ -0,0 +1,65 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rest-api-example/entities"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type UserRepositorySqlServer struct {
+	db *sql.DB
+}
+
+func NewUserRepositorySqlServer(db *sql.DB) entities.UserInterface {
+	return &UserRepositorySqlServer{
+		db: db,
+	}
+}
+
+func (r UserRepositorySqlServer) GetCredentialsByLogin(ctx context.Context, login string) (entities.Credentials, error) {
+	op := "UserRepositorySqlServer.GetCredentials()"
+	query, args, err := sq.Select("login", "password", "role").From("users").Where(sq.Eq{"login": login}).ToSql()
+	if err != nil {
+		return entities.Credentials{}, entities.NewInternalServerErrorError(err, op)
+	}
+
+	credentials := entities.Credentials{}
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&credentials.Login, &credentials.Password, &credentials.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Credentials{}, nil
+	}
+	if err != nil {
+		return entities.Credentials{}, entities.NewInternalServerErrorError(err, op)
+	}
+	return credentials, nil
+}
+
+func (r UserRepositorySqlServer) InsertUser(ctx context.Context, credentials entities.Credentials) error {
+	op := "UserRepositorySqlServer.InsertUser()"
+	query, args, err := sq.Insert("users").Columns("login", "password", "role").
+		Values(credentials.Login, credentials.Password, credentials.Role).ToSql()
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	return nil
+}
+
+func (r UserRepositorySqlServer) UpdateUserRole(ctx context.Context, login string, role entities.Role) error {
+	op := "UserRepositorySqlServer.UpdateUserRole()"
+	query, args, err := sq.Update("users").Set("role", role).Where(sq.Eq{"login": login}).ToSql()
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return entities.NewInternalServerErrorError(err, op)
+	}
+	return nil
+}
@@ -2,11 +2,17 @@ package user
 
 import (
 	"context"
+	"errors"
 	"rest-api-example/entities"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrInvalidRole  = errors.New("invalid role")
+)
+
 type UserService struct {
 	userRepository entities.UserInterface
 }
@@ -17,10 +23,31 @@ func NewUserService(u entities.UserInterface) UserService {
 
 func (u UserService) Registry(ctx context.Context, credentials entities.Credentials) error {
 	op := "UserService.Registry()"
+	err := validateCredentials(credentials).Error(op)
+	if err != nil {
+		return err
+	}
 	hashedPass, err := bcrypt.GenerateFromPassword([]byte(credentials.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return entities.NewInternalServerErrorError(err, op)
 	}
 	credentials.Password = string(hashedPass)
+	// self registered users never get more than the customer role
+	credentials.Role = entities.RoleCustomer
 	return u.userRepository.InsertUser(ctx, credentials)
 }
+
+func (u UserService) UpdateRole(ctx context.Context, login string, role entities.Role) error {
+	op := "UserService.UpdateRole()"
+	if !role.IsValid() {
+		return entities.NewBadRequestError(ErrInvalidRole, ErrInvalidRole.Error(), op)
+	}
+	credentials, err := u.userRepository.GetCredentialsByLogin(ctx, login)
+	if err != nil {
+		return err
+	}
+	if credentials.IsEmpty() {
+		return entities.NewNotFoundError(ErrUserNotFound, ErrUserNotFound.Error(), op)
+	}
+	return u.userRepository.UpdateUserRole(ctx, login, role)
+}
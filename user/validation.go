@@ -0,0 +1,15 @@
+package user
+
+import (
+	"rest-api-example/entities"
+	"rest-api-example/validation"
+)
+
+// validateCredentials checks the credentials of a new user; the password
+// strength is only required here, the login accepts the older passwords.
+func validateCredentials(credentials entities.Credentials) *validation.Validator {
+	validator := validation.New()
+	validation.Field(validator, "login", credentials.Login, validation.Required[string](), validation.Length(1, 255))
+	validation.Field(validator, "password", credentials.Password, validation.Required[string](), validation.Password())
+	return validator
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"database/sql"
+	"rest-api-example/entities"
+
+	"github.com/google/uuid"
+)
+
+// RowScanner is satisfied by both *sql.Row and *sql.Rows.
+type RowScanner interface {
+	Scan(dest ...any) error
+}
+
+func UUIDsToStrings(ids []uuid.UUID) []string {
+	values := make([]string, len(ids))
+	for index, id := range ids {
+		values[index] = id.String()
+	}
+	return values
+}
+
+// CheckVersionedWrite turns a conditional write that touched no row into
+// entities.ErrVersionMismatch. Writes without expected versions always pass.
+func CheckVersionedWrite(result sql.Result, expected []int64) error {
+	if len(expected) == 0 {
+		return nil
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entities.ErrVersionMismatch
+	}
+	return nil
+}
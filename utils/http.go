@@ -11,10 +11,16 @@ import (
 	"rest-api-example/entities"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
+var (
+	ErrIfMatchRequired = errors.New("informe o cabeçalho If-Match com o ETag atual do recurso")
+	ErrIfMatchFailed   = errors.New("o recurso foi alterado, obtenha o ETag atual e tente novamente")
+)
+
 type Response struct {
 	Data any `json:"data"`
 	Meta any `json:"_meta"`
@@ -28,6 +34,33 @@ type PaginationMeta struct {
 	entities.Hateoas
 }
 
+// CursorMeta describes a page of a cursor paginated list. TotalCount is left
+// out when the client asked to skip the count.
+type CursorMeta struct {
+	Limit      int  `json:"limit"`
+	Results    int  `json:"results"`
+	TotalCount *int `json:"totalCount,omitempty"`
+	entities.Hateoas
+}
+
+// CursorLinks adds to builder the links of a page of a cursor paginated
+// list: self, first, and next and prev when there are such pages. Query holds
+// the other parameters of the list, each preceded by "&".
+func CursorLinks(builder *entities.HateoasBuilder, path string, cursor string, next string, prev string, limit int, query string) entities.Hateoas {
+	pageUrl := func(cursor string) string {
+		return fmt.Sprintf("%s?cursor=%s&limit=%d%s", path, url.QueryEscape(cursor), limit, query)
+	}
+	builder.AddGet("self", pageUrl(cursor)).
+		AddGet("first", pageUrl(""))
+	if next != "" {
+		builder.AddGet("next", pageUrl(next))
+	}
+	if prev != "" {
+		builder.AddGet("prev", pageUrl(prev))
+	}
+	return builder.Build()
+}
+
 func GetBearerToken(r *http.Request) (string, error) {
 	op := "utils.GetBearerToken()"
 	token := strings.TrimSpace(strings.ReplaceAll(r.Header.Get("Authorization"), "Bearer ", ""))
@@ -44,6 +77,64 @@ func GenerateETag(payload []byte) string {
 	return fmt.Sprintf("\"%s\"", hash)
 }
 
+// VersionETag is the strong ETag of a versioned resource, the value clients
+// send back in If-Match.
+func VersionETag(version int64) string {
+	return fmt.Sprintf("\"v%d\"", version)
+}
+
+// SetLastModified sets the Last-Modified header to the latest of the
+// timestamps, as the databases return them. Empty and unparsable timestamps
+// are skipped.
+func SetLastModified(w http.ResponseWriter, timestamps ...string) {
+	var lastModified time.Time
+	for _, timestamp := range timestamps {
+		parsed, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err == nil && parsed.After(lastModified) {
+			lastModified = parsed
+		}
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// IfMatchVersions reads the versions accepted by the If-Match header. No
+// header (when not required) and "*" place no condition and return nil. Weak
+// tags never match, since If-Match uses the strong comparison. The name of the
+// representation Respond appends to the ETag, as in "v3-hal", is ignored: every
+// representation of a version carries the same state.
+func IfMatchVersions(r *http.Request, required bool) ([]int64, error) {
+	op := "utils.IfMatchVersions()"
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		if required {
+			return nil, entities.NewPreconditionRequiredError(ErrIfMatchRequired, ErrIfMatchRequired.Error(), op)
+		}
+		return nil, nil
+	}
+	if header == "*" {
+		return nil, nil
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if !strings.HasPrefix(tag, "\"v") || !strings.HasSuffix(tag, "\"") {
+			continue
+		}
+		number, _, _ := strings.Cut(tag[2:len(tag)-1], "-")
+		version, err := strconv.ParseInt(number, 10, 64)
+		if err == nil {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, entities.NewPreconditionFailedError(ErrIfMatchFailed, ErrIfMatchFailed.Error(), op)
+	}
+	return versions, nil
+}
+
 func GetQueryInt(query url.Values, key string, defaultValue int) int {
 	if value := query.Get(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -53,19 +144,21 @@ func GetQueryInt(query url.Values, key string, defaultValue int) int {
 	return defaultValue
 }
 
-func JSONError(w http.ResponseWriter, err error) {
-	op := "utils.JSONError()"
-
+// JSONError writes err as application/problem+json. Errors that are not an
+// *entities.Error are reported as an internal server error.
+func JSONError(w http.ResponseWriter, r *http.Request, err error) {
 	e, ok := err.(*entities.Error)
 	if !ok {
 		e = entities.NewInternalServerErrorError(err, "Unhandled error")
 	}
+	problem := NewProblem(r, e)
 
 	entry := log.WithFields(log.Fields{
-		"code":      e.Code,
-		"error":     e.Err.Error(),
-		"operation": e.Operation,
-		"message":   e.Message,
+		"code":           e.Code,
+		"error":          e.Err.Error(),
+		"operation":      e.Operation,
+		"message":        e.Message,
+		"correlation_id": problem.CorrelationId,
 	})
 
 	if e.Code != entities.INTERNAL_SERVER_ERROR {
@@ -74,45 +167,10 @@ func JSONError(w http.ResponseWriter, err error) {
 		entry.Error()
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	switch e.Code {
-	case entities.BAD_REQUEST:
-		w.WriteHeader(http.StatusBadRequest)
-	case entities.INTERNAL_SERVER_ERROR:
-		w.WriteHeader(http.StatusInternalServerError)
-	case entities.NOT_FOUND:
-		w.WriteHeader(http.StatusNotFound)
-	case entities.CONFLICT:
-		w.WriteHeader(http.StatusConflict)
-	case entities.UNAUTHORIZED:
-		w.WriteHeader(http.StatusUnauthorized)
-	case entities.FORBIDDEN:
-		w.WriteHeader(http.StatusForbidden)
-	case entities.NOT_IMPLEMENTED:
-		w.WriteHeader(http.StatusNotImplemented)
-	case entities.UNSUPPORTED_MEDIA_TYPE:
-		w.WriteHeader(http.StatusUnsupportedMediaType)
-	case entities.NOT_ACCEPTABLE:
-		w.WriteHeader(http.StatusNotAcceptable)
-	}
-	err = json.NewEncoder(w).Encode(err)
-	if err != nil {
-		JSONError(w, entities.NewInternalServerErrorError(err, op))
-		return
-	}
-}
-
-func JSONResponse(w http.ResponseWriter, data any, meta any, statusCode int) {
-	op := "utils.JSONResponse()"
-	r := Response{
-		Data: data,
-		Meta: meta,
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	err := json.NewEncoder(w).Encode(r)
+	w.Header().Set("Content-Type", MediaTypeProblem)
+	w.WriteHeader(problem.Status)
+	err = json.NewEncoder(w).Encode(problem)
 	if err != nil {
-		JSONError(w, entities.NewInternalServerErrorError(err, op))
-		return
+		entry.WithError(err).Error("could not write problem details")
 	}
 }
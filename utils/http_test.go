@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"rest-api-example/entities"
+	"slices"
+	"testing"
+)
+
+func TestIfMatchVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		required bool
+		versions []int64
+		code     string
+	}{
+		{name: "no header", header: ""},
+		{name: "no header when required", header: "", required: true, code: entities.PRECONDITION_REQUIRED},
+		{name: "any version", header: "*"},
+		{name: "one version", header: `"v3"`, versions: []int64{3}},
+		{name: "several versions", header: `"v3", "v4"`, versions: []int64{3, 4}},
+		{name: "representation suffix", header: `"v3-hal"`, versions: []int64{3}},
+		{name: "weak tag", header: `W/"v3"`, code: entities.PRECONDITION_FAILED},
+		{name: "content hash", header: `"5d41402abc4b2a76"`, code: entities.PRECONDITION_FAILED},
+		{name: "valid tags among invalid", header: `W/"v2", "v5-xml", "x"`, versions: []int64{5}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/", nil)
+			if test.header != "" {
+				r.Header.Set("If-Match", test.header)
+			}
+			versions, err := IfMatchVersions(r, test.required)
+			if code := errorCode(err); code != test.code {
+				t.Fatalf("error code = %q, want %q", code, test.code)
+			}
+			if !slices.Equal(versions, test.versions) {
+				t.Errorf("versions = %v, want %v", versions, test.versions)
+			}
+		})
+	}
+}
+
+// TestIfMatchRepresentationRoundTrip sends back in If-Match the ETag of each
+// representation, as a client of that media type does.
+func TestIfMatchRepresentationRoundTrip(t *testing.T) {
+	for _, mediaType := range ItemMediaTypes {
+		t.Run(mediaType, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r = r.WithContext(WithMediaType(r.Context(), mediaType))
+			w := httptest.NewRecorder()
+			w.Header().Set("ETag", VersionETag(7))
+			Respond(w, r, map[string]string{"name": "a"}, nil, http.StatusOK)
+
+			eTag := w.Header().Get("ETag")
+			patch := httptest.NewRequest(http.MethodPatch, "/", nil)
+			patch.Header.Set("If-Match", eTag)
+			versions, err := IfMatchVersions(patch, true)
+			if err != nil {
+				t.Fatalf("If-Match %s: %v", eTag, err)
+			}
+			if !slices.Equal(versions, []int64{7}) {
+				t.Errorf("If-Match %s: versions = %v, want [7]", eTag, versions)
+			}
+		})
+	}
+}
+
+func errorCode(err error) string {
+	var apiError *entities.Error
+	if errors.As(err, &apiError) {
+		return apiError.Code
+	}
+	if err != nil {
+		return "unexpected"
+	}
+	return ""
+}
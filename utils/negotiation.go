@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"rest-api-example/entities"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	MediaTypeJSON = "application/json"
+	MediaTypeHAL  = "application/hal+json"
+	MediaTypeXML  = "application/xml"
+	MediaTypeCSV  = "text/csv"
+)
+
+var (
+	// ItemMediaTypes are the representations of single resources.
+	ItemMediaTypes = []string{MediaTypeJSON, MediaTypeHAL, MediaTypeXML}
+	// ListMediaTypes are the representations of lists, CSV included.
+	ListMediaTypes = []string{MediaTypeJSON, MediaTypeHAL, MediaTypeXML, MediaTypeCSV}
+)
+
+type mediaTypeKey struct{}
+
+// Renderer writes a response in MediaType. Name tells the representations
+// apart in their ETags.
+type Renderer struct {
+	MediaType   string
+	ContentType string
+	Name        string
+	Render      func(w io.Writer, response Response) error
+}
+
+var renderers = map[string]Renderer{}
+
+// RegisterRenderer makes the media type of renderer available to Respond,
+// replacing the renderer registered for it before.
+func RegisterRenderer(renderer Renderer) {
+	renderers[renderer.MediaType] = renderer
+}
+
+func init() {
+	RegisterRenderer(Renderer{MediaType: MediaTypeJSON, ContentType: MediaTypeJSON, Name: "json", Render: renderJSON})
+	RegisterRenderer(Renderer{MediaType: MediaTypeHAL, ContentType: MediaTypeHAL, Name: "hal", Render: renderHAL})
+	RegisterRenderer(Renderer{MediaType: MediaTypeXML, ContentType: MediaTypeXML + "; charset=utf-8", Name: "xml", Render: renderXML})
+	RegisterRenderer(Renderer{MediaType: MediaTypeCSV, ContentType: MediaTypeCSV + "; charset=utf-8", Name: "csv", Render: renderCSV})
+}
+
+func WithMediaType(ctx context.Context, mediaType string) context.Context {
+	return context.WithValue(ctx, mediaTypeKey{}, mediaType)
+}
+
+// MediaType returns the media type chosen by middlewares.ValidadeAcceptHeader,
+// or an empty string outside of it.
+func MediaType(ctx context.Context) string {
+	mediaType, _ := ctx.Value(mediaTypeKey{}).(string)
+	return mediaType
+}
+
+// mediaRange is an element of the Accept header, such as "text/*;q=0.5".
+type mediaRange struct {
+	mediaType string
+	subtype   string
+	params    int
+	quality   float64
+}
+
+// parseAccept reads the media ranges of an Accept header as RFC 9110 defines
+// them, skipping the malformed ones. The parameters after q are extensions
+// and are ignored.
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, element := range strings.Split(header, ",") {
+		parts := strings.Split(element, ";")
+		mediaType, subtype, found := strings.Cut(strings.ToLower(strings.TrimSpace(parts[0])), "/")
+		if !found || mediaType == "" || subtype == "" || (mediaType == "*" && subtype != "*") {
+			continue
+		}
+		accepted := mediaRange{mediaType: mediaType, subtype: subtype, quality: 1}
+		valid := true
+		for _, param := range parts[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if !strings.EqualFold(strings.TrimSpace(name), "q") {
+				accepted.params++
+				continue
+			}
+			quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || quality < 0 || quality > 1 {
+				valid = false
+			}
+			accepted.quality = quality
+			break
+		}
+		if valid {
+			ranges = append(ranges, accepted)
+		}
+	}
+	return ranges
+}
+
+// specificity ranks how closely the range matches mediaType, -1 when it does
+// not match at all.
+func (m mediaRange) specificity(mediaType string) int {
+	main, subtype, _ := strings.Cut(mediaType, "/")
+	switch {
+	case m.mediaType == "*":
+		return 0
+	case m.mediaType != main:
+		return -1
+	case m.subtype == "*":
+		return 1
+	case m.subtype != subtype:
+		return -1
+	}
+	return 2 + m.params
+}
+
+// NegotiateMediaType chooses among offered the media type the Accept header
+// prefers. Each offer takes the quality of the most specific range matching
+// it; ties go to the offer matched by the most specific range and then to
+// the first offered, which is also the choice without an Accept header.
+// False means that every offer is unacceptable.
+func NegotiateMediaType(header string, offered []string) (string, bool) {
+	if len(offered) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(header) == "" {
+		return offered[0], true
+	}
+	ranges := parseAccept(header)
+
+	chosen, chosenQuality, chosenSpecificity := "", 0.0, -1
+	for _, offer := range offered {
+		quality, specificity := 0.0, -1
+		for _, accepted := range ranges {
+			if rank := accepted.specificity(offer); rank > specificity {
+				quality, specificity = accepted.quality, rank
+			}
+		}
+		if quality > chosenQuality || (quality == chosenQuality && quality > 0 && specificity > chosenSpecificity) {
+			chosen, chosenQuality, chosenSpecificity = offer, quality, specificity
+		}
+	}
+	return chosen, chosen != ""
+}
+
+// Respond writes data and meta in the media type negotiated for the request,
+// JSON when there was no negotiation. The ETag set by the handler is made
+// distinct for the representations other than JSON, since they do not share
+// the same bytes.
+func Respond(w http.ResponseWriter, r *http.Request, data any, meta any, statusCode int) {
+	op := "utils.Respond()"
+	renderer, exists := renderers[MediaType(r.Context())]
+	if !exists {
+		renderer = renderers[MediaTypeJSON]
+	}
+
+	var body bytes.Buffer
+	err := renderer.Render(&body, Response{Data: data, Meta: meta})
+	if err != nil {
+		JSONError(w, r, entities.NewInternalServerErrorError(err, op))
+		return
+	}
+
+	if eTag := w.Header().Get("ETag"); eTag != "" && renderer.MediaType != MediaTypeJSON {
+		w.Header().Set("ETag", strings.TrimSuffix(eTag, "\"")+"-"+renderer.Name+"\"")
+	}
+	w.Header().Set("Content-Type", renderer.ContentType)
+	w.WriteHeader(statusCode)
+	_, err = w.Write(body.Bytes())
+	if err != nil {
+		log.WithError(err).WithField("operation", op).Error("could not write response")
+	}
+}
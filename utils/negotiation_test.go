@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	tests := []struct {
+		name       string
+		accept     string
+		offered    []string
+		mediaType  string
+		acceptable bool
+	}{
+		{name: "no header", accept: "", offered: ListMediaTypes, mediaType: MediaTypeJSON, acceptable: true},
+		{name: "exact type", accept: "text/csv", offered: ListMediaTypes, mediaType: MediaTypeCSV, acceptable: true},
+		{name: "case insensitive", accept: "Application/XML", offered: ListMediaTypes, mediaType: MediaTypeXML, acceptable: true},
+		{name: "any type", accept: "*/*", offered: ListMediaTypes, mediaType: MediaTypeJSON, acceptable: true},
+		{name: "highest quality", accept: "application/json;q=0.5, application/xml", offered: ListMediaTypes, mediaType: MediaTypeXML, acceptable: true},
+		{name: "quality ties go to the first offered", accept: "application/xml, application/hal+json", offered: ListMediaTypes, mediaType: MediaTypeHAL, acceptable: true},
+		{name: "subtype range", accept: "text/*", offered: ListMediaTypes, mediaType: MediaTypeCSV, acceptable: true},
+		{name: "specific range wins over a wildcard", accept: "*/*;q=0.8, application/json;q=0.1", offered: ListMediaTypes, mediaType: MediaTypeHAL, acceptable: true},
+		{name: "explicit refusal", accept: "application/json;q=0, */*;q=0.1", offered: []string{MediaTypeJSON}, acceptable: false},
+		{name: "CSV is not offered for items", accept: "text/csv", offered: ItemMediaTypes, acceptable: false},
+		{name: "browser header", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", offered: ItemMediaTypes, mediaType: MediaTypeXML, acceptable: true},
+		{name: "malformed ranges are skipped", accept: "json, */json, application/xml;q=2, application/hal+json", offered: ItemMediaTypes, mediaType: MediaTypeHAL, acceptable: true},
+		{name: "extension parameters", accept: "application/json;charset=utf-8;q=0.4;foo=bar, application/xml;q=0.3", offered: ItemMediaTypes, mediaType: MediaTypeJSON, acceptable: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mediaType, acceptable := NegotiateMediaType(test.accept, test.offered)
+			if mediaType != test.mediaType || acceptable != test.acceptable {
+				t.Errorf("NegotiateMediaType(%q) = %q, %v, want %q, %v", test.accept, mediaType, acceptable, test.mediaType, test.acceptable)
+			}
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	tests := []struct {
+		mediaType   string
+		contentType string
+		eTag        string
+	}{
+		{mediaType: "", contentType: MediaTypeJSON, eTag: `"v7"`},
+		{mediaType: MediaTypeJSON, contentType: MediaTypeJSON, eTag: `"v7"`},
+		{mediaType: MediaTypeHAL, contentType: MediaTypeHAL, eTag: `"v7-hal"`},
+		{mediaType: MediaTypeXML, contentType: "application/xml; charset=utf-8", eTag: `"v7-xml"`},
+		{mediaType: MediaTypeCSV, contentType: "text/csv; charset=utf-8", eTag: `"v7-csv"`},
+	}
+	for _, test := range tests {
+		t.Run(test.contentType, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.mediaType != "" {
+				r = r.WithContext(WithMediaType(r.Context(), test.mediaType))
+			}
+			w := httptest.NewRecorder()
+			w.Header().Set("ETag", VersionETag(7))
+			Respond(w, r, map[string]string{"name": "a"}, nil, http.StatusCreated)
+
+			if w.Code != http.StatusCreated {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusCreated)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != test.contentType {
+				t.Errorf("Content-Type = %q, want %q", contentType, test.contentType)
+			}
+			if eTag := w.Header().Get("ETag"); eTag != test.eTag {
+				t.Errorf("ETag = %q, want %q", eTag, test.eTag)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"rest-api-example/entities"
+)
+
+const (
+	MediaTypeProblem    = "application/problem+json"
+	CorrelationIdHeader = "X-Correlation-Id"
+	// ProblemTypeValidation identifies problems carrying field errors.
+	ProblemTypeValidation = "/problems/validation"
+)
+
+type correlationIdKey struct{}
+
+// Problem is an RFC 9457 problem details document.
+type Problem struct {
+	Type          string                `json:"type"`
+	Title         string                `json:"title"`
+	Status        int                   `json:"status"`
+	Detail        string                `json:"detail,omitempty"`
+	Instance      string                `json:"instance,omitempty"`
+	CorrelationId string                `json:"correlation_id,omitempty"`
+	Errors        []entities.FieldError `json:"errors,omitempty"`
+}
+
+func WithCorrelationId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIdKey{}, id)
+}
+
+// CorrelationId returns the id set by middlewares.CorrelationId, or an empty
+// string outside of it.
+func CorrelationId(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIdKey{}).(string)
+	return id
+}
+
+func NewProblem(r *http.Request, e *entities.Error) Problem {
+	status := StatusCode(e.Code)
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: e.Message,
+		Errors: e.Fields,
+	}
+	if len(e.Fields) > 0 {
+		problem.Type = ProblemTypeValidation
+	}
+	if r != nil {
+		problem.Instance = r.URL.Path
+		problem.CorrelationId = CorrelationId(r.Context())
+	}
+	return problem
+}
+
+func StatusCode(code string) int {
+	switch code {
+	case entities.BAD_REQUEST:
+		return http.StatusBadRequest
+	case entities.NOT_FOUND:
+		return http.StatusNotFound
+	case entities.CONFLICT:
+		return http.StatusConflict
+	case entities.UNAUTHORIZED:
+		return http.StatusUnauthorized
+	case entities.FORBIDDEN:
+		return http.StatusForbidden
+	case entities.NOT_IMPLEMENTED:
+		return http.StatusNotImplemented
+	case entities.NO_CONTENT:
+		return http.StatusNoContent
+	case entities.UNSUPPORTED_MEDIA_TYPE:
+		return http.StatusUnsupportedMediaType
+	case entities.NOT_ACCEPTABLE:
+		return http.StatusNotAcceptable
+	case entities.UNPROCESSABLE_ENTITY:
+		return http.StatusUnprocessableEntity
+	case entities.PRECONDITION_FAILED:
+		return http.StatusPreconditionFailed
+	case entities.PRECONDITION_REQUIRED:
+		return http.StatusPreconditionRequired
+	case entities.PAYMENT_REQUIRED:
+		return http.StatusPaymentRequired
+	case entities.GATEWAY_TIMEOUT:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
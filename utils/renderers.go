@@ -0,0 +1,361 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// field is a member of a JSON object decoded by decodeOrdered.
+type field struct {
+	name  string
+	value any
+}
+
+// object is a JSON object keeping the order of its members, so the XML, CSV
+// and HAL representations list the fields as the JSON one does.
+type object []field
+
+func (o object) get(name string) (any, bool) {
+	for _, field := range o {
+		if field.name == name {
+			return field.value, true
+		}
+	}
+	return nil, false
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for index, field := range o {
+		if index > 0 {
+			buffer.WriteByte(',')
+		}
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(name)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// decodeOrdered converts value to the JSON types, objects becoming object,
+// arrays []any and numbers json.Number.
+func decodeOrdered(value any) (any, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	return decodeValue(decoder)
+}
+
+func decodeValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		members := object{}
+		for decoder.More() {
+			name, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, field{name: name.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return members, err
+	case json.Delim('['):
+		items := []any{}
+		for decoder.More() {
+			item, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err = decoder.Token()
+		return items, err
+	}
+	return token, nil
+}
+
+func renderJSON(w io.Writer, response Response) error {
+	return json.NewEncoder(w).Encode(response)
+}
+
+// renderHAL writes the response as a HAL document: the links under _links,
+// the other members of _meta at the top level, and the items of the lists
+// under _embedded.items.
+func renderHAL(w io.Writer, response Response) error {
+	data, err := decodeOrdered(response.Data)
+	if err != nil {
+		return err
+	}
+	meta, err := decodeOrdered(response.Meta)
+	if err != nil {
+		return err
+	}
+
+	document := object{}
+	if meta, isObject := meta.(object); isObject {
+		document = append(document, meta...)
+	}
+	switch data := data.(type) {
+	case object:
+		resource := halResource(data)
+		if _, hasLinks := document.get("_links"); hasLinks {
+			resource = without(resource, "_links")
+		}
+		document = append(document, resource...)
+	case []any:
+		items := make([]any, len(data))
+		for index, item := range data {
+			items[index] = halItem(item)
+		}
+		document = append(document, field{name: "_embedded", value: object{{name: "items", value: items}}})
+	}
+	return json.NewEncoder(w).Encode(moveFirst(document, "_links"))
+}
+
+// halResource moves the links of the resource out of _meta and converts its
+// embedded resources as well.
+func halResource(resource object) object {
+	converted := make(object, 0, len(resource))
+	for _, member := range resource {
+		switch member.name {
+		case "_meta":
+			if meta, isObject := member.value.(object); isObject {
+				converted = append(converted, meta...)
+			}
+		case "_embedded":
+			embedded, _ := member.value.(object)
+			relations := make(object, len(embedded))
+			for index, relation := range embedded {
+				relations[index] = field{name: relation.name, value: halItem(relation.value)}
+			}
+			converted = append(converted, field{name: "_embedded", value: relations})
+		default:
+			converted = append(converted, member)
+		}
+	}
+	return moveFirst(converted, "_links")
+}
+
+func halItem(item any) any {
+	switch item := item.(type) {
+	case object:
+		return halResource(item)
+	case []any:
+		items := make([]any, len(item))
+		for index, element := range item {
+			items[index] = halItem(element)
+		}
+		return items
+	}
+	return item
+}
+
+func without(o object, name string) object {
+	kept := make(object, 0, len(o))
+	for _, member := range o {
+		if member.name != name {
+			kept = append(kept, member)
+		}
+	}
+	return kept
+}
+
+func moveFirst(o object, name string) object {
+	value, exists := o.get(name)
+	if !exists {
+		return o
+	}
+	return append(object{{name: name, value: value}}, without(o, name)...)
+}
+
+// xmlName matches the member names usable as element names; the others are
+// written as <entry key="name">.
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// renderXML writes the response as the JSON one under a <response> element,
+// the items of the arrays as <item> elements.
+func renderXML(w io.Writer, response Response) error {
+	document, err := decodeOrdered(response)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	err = encodeXML(encoder, "response", document)
+	if err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+func encodeXML(encoder *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !xmlName.MatchString(name) {
+		start = xml.StartElement{Name: xml.Name{Local: "entry"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}}}
+	}
+	err := encoder.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case object:
+		for _, member := range value {
+			err = encodeXML(encoder, member.name, member.value)
+			if err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range value {
+			err = encodeXML(encoder, "item", item)
+			if err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		err = encoder.EncodeToken(xml.CharData(scalarText(value)))
+		if err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// renderCSV writes the items of a list, one per row, with the nested objects
+// flattened into columns such as price.amount. The members starting with "_",
+// the links and the embedded resources, are left out.
+func renderCSV(w io.Writer, response Response) error {
+	data, err := decodeOrdered(response.Data)
+	if err != nil {
+		return err
+	}
+	items, isList := data.([]any)
+	if !isList {
+		items = []any{data}
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	rows := make([]map[string]string, len(items))
+	for index, item := range items {
+		rows[index] = make(map[string]string)
+		for _, column := range flatten(rows[index], "", item) {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	err = writer.Write(columns)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for index, column := range columns {
+			record[index] = row[column]
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// flatten sets in row the cells of value, returning their columns in the
+// order of its members.
+func flatten(row map[string]string, prefix string, value any) []string {
+	members, isObject := value.(object)
+	if !isObject {
+		column := columnName(prefix)
+		row[column] = cellText(value)
+		return []string{column}
+	}
+	var columns []string
+	for _, member := range members {
+		if !strings.HasPrefix(member.name, "_") {
+			columns = append(columns, flatten(row, prefix+member.name+".", member.value)...)
+		}
+	}
+	return columns
+}
+
+// columnName names the column of a flattened value, "value" for the lists of
+// scalars.
+func columnName(prefix string) string {
+	if prefix == "" {
+		return "value"
+	}
+	return strings.TrimSuffix(prefix, ".")
+}
+
+// cellText writes the arrays of scalars, such as the ids of the categories of
+// a product, separated by ";" and the other arrays as JSON.
+func cellText(value any) string {
+	items, isList := value.([]any)
+	if !isList {
+		return scalarText(value)
+	}
+	texts := make([]string, len(items))
+	for index, item := range items {
+		switch item.(type) {
+		case object, []any:
+			encoded, _ := json.Marshal(items)
+			return string(encoded)
+		}
+		texts[index] = scalarText(item)
+	}
+	return strings.Join(texts, ";")
+}
+
+func scalarText(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		if value {
+			return "true"
+		}
+		return "false"
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
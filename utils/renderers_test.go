@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+type testPrice struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type testProduct struct {
+	Name         string         `json:"name"`
+	Price        testPrice      `json:"price"`
+	CategoriesId []string       `json:"categoriesId"`
+	Meta         map[string]any `json:"_meta,omitempty"`
+}
+
+func TestRenderers(t *testing.T) {
+	product := testProduct{
+		Name:         "Caneca, azul",
+		Price:        testPrice{Amount: "10.50", Currency: "BRL"},
+		CategoriesId: []string{"a", "b"},
+		Meta:         map[string]any{"_links": map[string]any{"self": map[string]string{"href": "/products/1"}}},
+	}
+	list := Response{
+		Data: []testProduct{product},
+		Meta: map[string]any{"_links": map[string]any{"self": map[string]string{"href": "/products"}}, "total": 1},
+	}
+
+	tests := []struct {
+		name     string
+		render   func(w io.Writer, response Response) error
+		response Response
+		expected string
+	}{
+		{
+			name:     "JSON list",
+			render:   renderJSON,
+			response: list,
+			expected: `{"data":[{"name":"Caneca, azul","price":{"amount":"10.50","currency":"BRL"},"categoriesId":["a","b"],"_meta":{"_links":{"self":{"href":"/products/1"}}}}],"_meta":{"_links":{"self":{"href":"/products"}},"total":1}}`,
+		},
+		{
+			name:     "HAL list",
+			render:   renderHAL,
+			response: list,
+			expected: `{"_links":{"self":{"href":"/products"}},"total":1,"_embedded":{"items":[{"_links":{"self":{"href":"/products/1"}},"name":"Caneca, azul","price":{"amount":"10.50","currency":"BRL"},"categoriesId":["a","b"]}]}}`,
+		},
+		{
+			name:     "HAL item",
+			render:   renderHAL,
+			response: Response{Data: product},
+			expected: `{"_links":{"self":{"href":"/products/1"}},"name":"Caneca, azul","price":{"amount":"10.50","currency":"BRL"},"categoriesId":["a","b"]}`,
+		},
+		{
+			name:     "XML list",
+			render:   renderXML,
+			response: list,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<response><data><item><name>Caneca, azul</name><price><amount>10.50</amount><currency>BRL</currency></price>` +
+				`<categoriesId><item>a</item><item>b</item></categoriesId><_meta><_links><self><href>/products/1</href></self></_links></_meta></item></data>` +
+				`<_meta><_links><self><href>/products</href></self></_links><total>1</total></_meta></response>`,
+		},
+		{
+			name:     "XML names that are not elements",
+			render:   renderXML,
+			response: Response{Data: map[string]string{"1st": "<a>"}},
+			expected: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<response><data><entry key="1st">&lt;a&gt;</entry></data><_meta></_meta></response>`,
+		},
+		{
+			name:     "CSV list",
+			render:   renderCSV,
+			response: list,
+			expected: "name,price.amount,price.currency,categoriesId\n\"Caneca, azul\",10.50,BRL,a;b",
+		},
+		{
+			name:     "CSV of nested lists",
+			render:   renderCSV,
+			response: Response{Data: []map[string]any{{"items": []map[string]int{{"quantity": 2}}}}},
+			expected: "items\n\"[{\"\"quantity\"\":2}]\"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body bytes.Buffer
+			err := test.render(&body, test.response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rendered := strings.TrimSpace(body.String()); rendered != test.expected {
+				t.Errorf("rendered\n%s\nwant\n%s", rendered, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"reflect"
+	"rest-api-example/entities"
+	"slices"
+	"strings"
+)
+
+const (
+	FieldsParam = "fields"
+	EmbedParam  = "embed"
+)
+
+var (
+	ErrCamposInvalidos     = errors.New("fields ou embed inválidos")
+	ErrCampoDesconhecido   = errors.New("campo desconhecido")
+	ErrRelacaoDesconhecida = errors.New("relação desconhecida")
+)
+
+// Representation holds the fields a client asked for with ?fields=name,price,
+// nil meaning every field, and the relations asked for with ?embed=.
+type Representation struct {
+	Fields map[string]bool
+	Embed  map[string]bool
+}
+
+// ParseRepresentation reads the fields parameter, which may only name the
+// JSON fields of resource, and the embed parameter, which may only name the
+// relations given. Both are comma separated lists; every unknown name is
+// reported in one 400 error.
+func ParseRepresentation(params url.Values, resource any, relations ...string) (Representation, error) {
+	op := "utils.ParseRepresentation()"
+	var fieldErrors []entities.FieldError
+	representation := Representation{Embed: make(map[string]bool)}
+
+	if params.Has(FieldsParam) {
+		known := jsonFields(reflect.TypeOf(resource))
+		representation.Fields = make(map[string]bool)
+		for _, name := range splitList(params[FieldsParam]) {
+			if !slices.Contains(known, name) {
+				fieldErrors = append(fieldErrors, entities.FieldError{Field: FieldsParam, Message: fmt.Sprintf("%s: %s", name, ErrCampoDesconhecido.Error())})
+				continue
+			}
+			representation.Fields[name] = true
+		}
+	}
+	for _, name := range splitList(params[EmbedParam]) {
+		if !slices.Contains(relations, name) {
+			fieldErrors = append(fieldErrors, entities.FieldError{Field: EmbedParam, Message: fmt.Sprintf("%s: %s", name, ErrRelacaoDesconhecida.Error())})
+			continue
+		}
+		representation.Embed[name] = true
+	}
+
+	if len(fieldErrors) > 0 {
+		return Representation{}, entities.NewBadRequestError(ErrCamposInvalidos, ErrCamposInvalidos.Error(), op, fieldErrors...)
+	}
+	return representation, nil
+}
+
+// IsEmpty tells whether the default representation was asked for, with every
+// field and nothing embedded.
+func (r Representation) IsEmpty() bool {
+	return r.Fields == nil && len(r.Embed) == 0
+}
+
+// Encode writes the representation back as query parameters, each one
+// preceded by "&", for the pagination links.
+func (r Representation) Encode() string {
+	var query string
+	if r.Fields != nil {
+		query += fmt.Sprintf("&%s=%s", FieldsParam, url.QueryEscape(strings.Join(slices.Sorted(maps.Keys(r.Fields)), ",")))
+	}
+	if len(r.Embed) > 0 {
+		query += fmt.Sprintf("&%s=%s", EmbedParam, url.QueryEscape(strings.Join(slices.Sorted(maps.Keys(r.Embed)), ",")))
+	}
+	return query
+}
+
+// Shape returns resource with only the asked fields and, when embedded is
+// not nil, the related resources under _embedded. The members starting with
+// "_", such as _meta, are always kept. Resource is returned as is when there
+// is nothing to change.
+func (r Representation) Shape(resource any, embedded map[string]any) (any, error) {
+	if r.Fields == nil && embedded == nil {
+		return resource, nil
+	}
+	payload, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var members map[string]json.RawMessage
+	err = json.Unmarshal(payload, &members)
+	if err != nil {
+		return nil, err
+	}
+
+	shaped := make(map[string]any, len(members)+1)
+	for name, value := range members {
+		if r.Fields == nil || r.Fields[name] || strings.HasPrefix(name, "_") {
+			shaped[name] = value
+		}
+	}
+	if embedded != nil {
+		shaped["_embedded"] = embedded
+	}
+	return shaped, nil
+}
+
+// splitList reads the values of a comma separated list parameter, which may
+// also be repeated.
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// jsonFields lists the names the fields of a struct have in JSON, those of
+// the embedded structs included.
+func jsonFields(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var names []string
+	for index := range t.NumField() {
+		field := t.Field(index)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" {
+			names = append(names, jsonFields(field.Type)...)
+			continue
+		}
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
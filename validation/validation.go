@@ -0,0 +1,190 @@
+// Package validation checks request bodies against rules declared per field,
+// such as
+//
+//	validator := validation.New()
+//	validation.Field(validator, "name", product.Name, validation.Required[string](), validation.Length(1, 255))
+//	return validator.Error(op)
+//
+// collecting the failures of every field so they are returned at once.
+package validation
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"rest-api-example/entities"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MinPasswordLength is the least number of bytes of a password.
+	MinPasswordLength = 8
+	// MaxPasswordLength is the limit of bcrypt, which ignores what follows.
+	MaxPasswordLength = 72
+)
+
+var (
+	ErrCamposInvalidos = errors.New("um ou mais campos são inválidos")
+	ErrObrigatorio     = errors.New("deve ser informado")
+	ErrUUIDInvalido    = errors.New("deve ser um UUID válido")
+	ErrSenhaFraca      = fmt.Errorf("a senha deve ter de %d a %d caracteres, com letras maiúsculas, minúsculas, números e símbolos", MinPasswordLength, MaxPasswordLength)
+)
+
+// Rule returns the message of the failure of value, nil when it is valid.
+type Rule[T any] func(value T) error
+
+// Validator collects the failures of the fields of a request body.
+type Validator struct {
+	fields []entities.FieldError
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// Field checks value against the rules in order, stopping at the first
+// failure, so a missing field is not reported as too short as well.
+func Field[T any](v *Validator, name string, value T, rules ...Rule[T]) {
+	for _, rule := range rules {
+		if err := rule(value); err != nil {
+			v.Add(name, err.Error())
+			return
+		}
+	}
+}
+
+// Add reports a failure checked outside the rules, such as one depending on
+// the database.
+func (v *Validator) Add(field string, message string) {
+	v.fields = append(v.fields, entities.FieldError{Field: field, Message: message})
+}
+
+// Failed tells whether the field name has a failure.
+func (v *Validator) Failed(name string) bool {
+	for _, field := range v.fields {
+		if field.Field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Error returns the failures as a 422, nil when there are none.
+func (v *Validator) Error(op string) error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return entities.NewUnprocessableEntityError(ErrCamposInvalidos, ErrCamposInvalidos.Error(), op, v.fields...)
+}
+
+// Required rejects the zero value.
+func Required[T comparable]() Rule[T] {
+	return func(value T) error {
+		var zero T
+		if value == zero {
+			return ErrObrigatorio
+		}
+		return nil
+	}
+}
+
+// Length accepts a text with between min and max characters.
+func Length(min int, max int) Rule[string] {
+	return func(value string) error {
+		length := utf8.RuneCountInString(value)
+		if length < min || length > max {
+			return fmt.Errorf("deve ter entre %d e %d caracteres", min, max)
+		}
+		return nil
+	}
+}
+
+// Min accepts a value of at least min.
+func Min[T cmp.Ordered](min T) Rule[T] {
+	return func(value T) error {
+		if value < min {
+			return fmt.Errorf("deve ser maior ou igual a %v", min)
+		}
+		return nil
+	}
+}
+
+// MinItems accepts a list with at least min items.
+func MinItems[T any](min int) Rule[[]T] {
+	return func(values []T) error {
+		if len(values) < min {
+			return fmt.Errorf("deve ter ao menos %d item(s)", min)
+		}
+		return nil
+	}
+}
+
+// Each checks every item of a list against the rules, reporting the first
+// failure along with the index of the item.
+func Each[T any](rules ...Rule[T]) Rule[[]T] {
+	return func(values []T) error {
+		for index, value := range values {
+			for _, rule := range rules {
+				if err := rule(value); err != nil {
+					return fmt.Errorf("item %d: %w", index, err)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Optional checks the value pointed to against the rules, accepting nil.
+func Optional[T any](rules ...Rule[T]) Rule[*T] {
+	return func(value *T) error {
+		if value == nil {
+			return nil
+		}
+		for _, rule := range rules {
+			if err := rule(*value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// UUID rejects the nil UUID, which the JSON decoding accepts.
+func UUID() Rule[uuid.UUID] {
+	return func(value uuid.UUID) error {
+		if value == uuid.Nil {
+			return ErrUUIDInvalido
+		}
+		return nil
+	}
+}
+
+// Password accepts a text of MinPasswordLength to MaxPasswordLength bytes with
+// upper and lower case letters, digits and symbols.
+func Password() Rule[string] {
+	return func(value string) error {
+		if len(value) < MinPasswordLength || len(value) > MaxPasswordLength {
+			return ErrSenhaFraca
+		}
+		var upper, lower, digit, symbol bool
+		for _, char := range value {
+			switch {
+			case unicode.IsUpper(char):
+				upper = true
+			case unicode.IsLower(char):
+				lower = true
+			case unicode.IsDigit(char):
+				digit = true
+			case unicode.IsPunct(char) || unicode.IsSymbol(char) || unicode.IsSpace(char):
+				symbol = true
+			}
+		}
+		if !upper || !lower || !digit || !symbol {
+			return ErrSenhaFraca
+		}
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package validation
+
+import (
+	"errors"
+	"rest-api-example/entities"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRules(t *testing.T) {
+	name := "caneca"
+	tests := []struct {
+		name string
+		err  error
+		// message is empty for the valid values
+		message string
+	}{
+		{name: "accepts required text", err: Required[string]()("a")},
+		{name: "accepts required int", err: Required[int64]()(1)},
+		{name: "accepts length counts characters", err: Length(1, 3)("ção")},
+		{name: "accepts min", err: Min[int64](0)(0)},
+		{name: "accepts min items", err: MinItems[uuid.UUID](1)([]uuid.UUID{uuid.New()})},
+		{name: "accepts each", err: Each(UUID())([]uuid.UUID{uuid.New(), uuid.New()})},
+		{name: "accepts optional absent", err: Optional(Length(1, 3))(nil)},
+		{name: "accepts optional present", err: Optional(Length(1, 10))(&name)},
+		{name: "accepts strong password", err: Password()("Senha@123")},
+		{name: "accepts password with space as the symbol", err: Password()("Senha 123")},
+		{name: "rejects required text", err: Required[string]()(""), message: ErrObrigatorio.Error()},
+		{name: "rejects required int", err: Required[int64]()(0), message: ErrObrigatorio.Error()},
+		{name: "rejects too short", err: Length(2, 3)("ç"), message: "deve ter entre 2 e 3 caracteres"},
+		{name: "rejects too long", err: Length(1, 3)("çãoo"), message: "deve ter entre 1 e 3 caracteres"},
+		{name: "rejects min", err: Min[int64](0)(-1), message: "deve ser maior ou igual a 0"},
+		{name: "rejects min items", err: MinItems[uuid.UUID](1)(nil), message: "deve ter ao menos 1 item(s)"},
+		{name: "rejects each reports the index", err: Each(UUID())([]uuid.UUID{uuid.New(), uuid.Nil}), message: "item 1: " + ErrUUIDInvalido.Error()},
+		{name: "rejects optional present", err: Optional(Length(1, 3))(&name), message: "deve ter entre 1 e 3 caracteres"},
+		{name: "rejects nil UUID", err: UUID()(uuid.Nil), message: ErrUUIDInvalido.Error()},
+		{name: "rejects short password", err: Password()("S@1a"), message: ErrSenhaFraca.Error()},
+		{name: "rejects password beyond bcrypt", err: Password()("Senha@1" + strings.Repeat("a", MaxPasswordLength)), message: ErrSenhaFraca.Error()},
+		{name: "rejects password without symbol", err: Password()("Senha1234"), message: ErrSenhaFraca.Error()},
+		{name: "rejects password without upper case", err: Password()("senha@123"), message: ErrSenhaFraca.Error()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			message := ""
+			if test.err != nil {
+				message = test.err.Error()
+			}
+			if message != test.message {
+				t.Errorf("error = %q, want %q", message, test.message)
+			}
+		})
+	}
+}
+
+func TestValidator(t *testing.T) {
+	validator := New()
+	Field(validator, "name", "", Required[string](), Length(1, 255))
+	Field(validator, "description", "ok", Required[string](), Length(1, 5000))
+	Field(validator, "CategoriesId", []uuid.UUID{}, MinItems[uuid.UUID](1))
+	validator.Add("sku", "já cadastrado")
+
+	if !validator.Failed("name") || validator.Failed("description") {
+		t.Errorf("Failed() name = %v, description = %v", validator.Failed("name"), validator.Failed("description"))
+	}
+
+	var apiError *entities.Error
+	if !errors.As(validator.Error("test"), &apiError) || apiError.Code != entities.UNPROCESSABLE_ENTITY {
+		t.Fatalf("Error() = %v, want a 422", validator.Error("test"))
+	}
+	// the first failed rule of a field is the only one reported
+	expected := []entities.FieldError{
+		{Field: "name", Message: ErrObrigatorio.Error()},
+		{Field: "CategoriesId", Message: "deve ter ao menos 1 item(s)"},
+		{Field: "sku", Message: "já cadastrado"},
+	}
+	if !slices.Equal(apiError.Fields, expected) {
+		t.Errorf("fields = %v, want %v", apiError.Fields, expected)
+	}
+
+	if err := New().Error("test"); err != nil {
+		t.Errorf("Error() without failures = %v, want nil", err)
+	}
+}